@@ -0,0 +1,430 @@
+// Package xconfighcl decodes HCL configuration files for the xconfig loader.
+//
+// Attributes map onto fields like keys of any other format. A block maps onto
+// a nested struct, a block repeated with the same type onto a slice of
+// structs, and a labelled block onto a map keyed by its labels:
+//
+//	server {                 # Server struct
+//	  port = 8080
+//	}
+//
+//	upstream "api" {         # Upstreams map[string]Upstream `hcl:"upstream"`
+//	  url = "http://api"
+//	}
+//
+// Expressions may only use literals: variables and functions are rejected.
+// Keys are matched against the yaml, json, toml or hcl tag of a field, in that
+// order, or case-insensitively against its Go name, like the loader does when
+// it detects unknown and present fields.
+package xconfighcl
+
+import (
+	"encoding"
+	"fmt"
+	"math"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Decoder of hcl.
+type Decoder struct{}
+
+// New hcl decoder.
+func New() *Decoder { return &Decoder{} }
+
+// Format of the decoder.
+func (d *Decoder) Format() string {
+	return "hcl"
+}
+
+// Unmarshal decodes the given data into the provided struct. Decoding into a
+// *map[string]any yields blocks as nested maps and repeated blocks as []any.
+func (d *Decoder) Unmarshal(data []byte, v any) error {
+	file, diags := hclsyntax.ParseConfig(data, "config.hcl", hcl.InitialPos)
+	if diags.HasErrors() {
+		return diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return fmt.Errorf("xconfighcl: unexpected body type %T", file.Body)
+	}
+	tree, err := bodyValue(body)
+	if err != nil {
+		return err
+	}
+
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("xconfighcl: Unmarshal: v must be a non-nil pointer, got %T", v)
+	}
+	return decode(tree, rv.Elem(), "")
+}
+
+// bodyValue converts a body into a generic map of attribute values and blocks.
+func bodyValue(body *hclsyntax.Body) (map[string]any, error) {
+	out := make(map[string]any, len(body.Attributes)+len(body.Blocks))
+	for name, attr := range body.Attributes {
+		value, diags := attr.Expr.Value(nil)
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		goVal, err := ctyToGo(value)
+		if err != nil {
+			return nil, fmt.Errorf("xconfighcl: attribute %q: %w", name, err)
+		}
+		out[name] = goVal
+	}
+
+	unlabelled := make(map[string][]any)
+	var order []string
+	for _, block := range body.Blocks {
+		if _, ok := body.Attributes[block.Type]; ok {
+			return nil, fmt.Errorf("xconfighcl: %q is defined both as an attribute and as a block", block.Type)
+		}
+		value, err := bodyValue(block.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(block.Labels) == 0 {
+			if _, ok := out[block.Type]; ok {
+				return nil, fmt.Errorf("xconfighcl: block %q is used both with and without labels", block.Type)
+			}
+			if _, ok := unlabelled[block.Type]; !ok {
+				order = append(order, block.Type)
+			}
+			unlabelled[block.Type] = append(unlabelled[block.Type], value)
+			continue
+		}
+
+		if _, ok := unlabelled[block.Type]; ok {
+			return nil, fmt.Errorf("xconfighcl: block %q is used both with and without labels", block.Type)
+		}
+		if err := insertLabelled(out, block.Type, block.Labels, value); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, name := range order {
+		blocks := unlabelled[name]
+		if len(blocks) == 1 {
+			out[name] = blocks[0]
+			continue
+		}
+		out[name] = blocks
+	}
+	return out, nil
+}
+
+// insertLabelled stores value at out[blockType][label0][label1]...
+func insertLabelled(out map[string]any, blockType string, labels []string, value map[string]any) error {
+	path := append([]string{blockType}, labels...)
+	cur := out
+	for i, key := range path[:len(path)-1] {
+		next, ok := cur[key]
+		if !ok {
+			nested := make(map[string]any)
+			cur[key] = nested
+			cur = nested
+			continue
+		}
+		nested, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("xconfighcl: block %q conflicts with %q", strings.Join(path, " "), strings.Join(path[:i+1], " "))
+		}
+		cur = nested
+	}
+
+	last := path[len(path)-1]
+	if _, ok := cur[last]; ok {
+		return fmt.Errorf("xconfighcl: duplicate block %q", strings.Join(path, " "))
+	}
+	cur[last] = value
+	return nil
+}
+
+// ctyToGo converts a literal value into string, int64, float64, bool, nil,
+// []any or map[string]any.
+func ctyToGo(v cty.Value) (any, error) {
+	if v.IsNull() {
+		return nil, nil
+	}
+	if !v.IsKnown() {
+		return nil, fmt.Errorf("value is not known")
+	}
+
+	t := v.Type()
+	switch {
+	case t == cty.String:
+		return v.AsString(), nil
+	case t == cty.Bool:
+		return v.True(), nil
+	case t == cty.Number:
+		bf := v.AsBigFloat()
+		if bf.IsInt() {
+			if i, acc := bf.Int64(); acc == 0 {
+				return i, nil
+			}
+		}
+		f, _ := bf.Float64()
+		return f, nil
+	case t.IsListType() || t.IsTupleType() || t.IsSetType():
+		out := make([]any, 0, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			_, elem := it.Element()
+			goVal, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, goVal)
+		}
+		return out, nil
+	case t.IsMapType() || t.IsObjectType():
+		out := make(map[string]any, v.LengthInt())
+		for it := v.ElementIterator(); it.Next(); {
+			key, elem := it.Element()
+			goVal, err := ctyToGo(elem)
+			if err != nil {
+				return nil, err
+			}
+			out[key.AsString()] = goVal
+		}
+		return out, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t.FriendlyName())
+	}
+}
+
+var (
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+	durationType        = reflect.TypeFor[time.Duration]()
+)
+
+// decode stores value, as produced by bodyValue, into dst. Values already in
+// dst are kept unless value replaces them, as with encoding/json.
+func decode(value any, dst reflect.Value, path string) error {
+	if value == nil {
+		dst.Set(reflect.Zero(dst.Type()))
+		return nil
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decode(value, dst.Elem(), path)
+	}
+
+	if dst.CanAddr() && dst.Addr().Type().Implements(textUnmarshalerType) {
+		if s, ok := value.(string); ok {
+			if err := dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return fieldError(path, err)
+			}
+			return nil
+		}
+	}
+
+	if dst.Type() == durationType {
+		if s, ok := value.(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return fieldError(path, err)
+			}
+			dst.SetInt(int64(d))
+			return nil
+		}
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if dst.NumMethod() != 0 {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.Set(reflect.ValueOf(value))
+		return nil
+
+	case reflect.Struct:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(path, value, dst.Type())
+		}
+		for key, item := range m {
+			field, ok := fieldByKey(dst, key)
+			if !ok {
+				// Unknown keys are reported by the loader.
+				continue
+			}
+			if err := decode(item, field, join(path, key)); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.Map:
+		m, ok := value.(map[string]any)
+		if !ok {
+			return mismatch(path, value, dst.Type())
+		}
+		if dst.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("xconfighcl: %s: unsupported map key type %s", path, dst.Type().Key())
+		}
+		if dst.IsNil() {
+			dst.Set(reflect.MakeMapWithSize(dst.Type(), len(m)))
+		}
+		for key, item := range m {
+			mapKey := reflect.ValueOf(key).Convert(dst.Type().Key())
+			entry := reflect.New(dst.Type().Elem()).Elem()
+			if current := dst.MapIndex(mapKey); current.IsValid() {
+				entry.Set(current)
+			}
+			if err := decode(item, entry, join(path, key)); err != nil {
+				return err
+			}
+			dst.SetMapIndex(mapKey, entry)
+		}
+		return nil
+
+	case reflect.Slice, reflect.Array:
+		items, ok := value.([]any)
+		if !ok {
+			// A single block for a list of structs or maps.
+			if _, isMap := value.(map[string]any); !isMap {
+				return mismatch(path, value, dst.Type())
+			}
+			items = []any{value}
+		}
+		if dst.Kind() == reflect.Array {
+			if len(items) != dst.Len() {
+				return fmt.Errorf("xconfighcl: %s: expected %d elements, got %d", path, dst.Len(), len(items))
+			}
+		} else {
+			dst.Set(reflect.MakeSlice(dst.Type(), len(items), len(items)))
+		}
+		for i, item := range items {
+			if err := decode(item, dst.Index(i), join(path, fmt.Sprint(i))); err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.SetString(s)
+		return nil
+
+	case reflect.Bool:
+		b, ok := value.(bool)
+		if !ok {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.SetBool(b)
+		return nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := value.(int64)
+		if !ok || dst.OverflowInt(i) {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.SetInt(i)
+		return nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := value.(int64)
+		if !ok || i < 0 || dst.OverflowUint(uint64(i)) {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.SetUint(uint64(i))
+		return nil
+
+	case reflect.Float32, reflect.Float64:
+		var f float64
+		switch n := value.(type) {
+		case int64:
+			f = float64(n)
+		case float64:
+			f = n
+		default:
+			return mismatch(path, value, dst.Type())
+		}
+		if dst.Kind() == reflect.Float32 && math.Abs(f) > math.MaxFloat32 {
+			return mismatch(path, value, dst.Type())
+		}
+		dst.SetFloat(f)
+		return nil
+
+	default:
+		return fmt.Errorf("xconfighcl: %s: unsupported type %s", path, dst.Type())
+	}
+}
+
+// fieldByKey finds the settable field of struct value v, including promoted
+// fields of embedded structs, that key refers to.
+func fieldByKey(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			embedded := v.Field(i)
+			if embedded.Kind() == reflect.Pointer && embedded.Type().Elem().Kind() == reflect.Struct {
+				if embedded.IsNil() {
+					embedded.Set(reflect.New(embedded.Type().Elem()))
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				if found, ok := fieldByKey(embedded, key); ok {
+					return found, true
+				}
+				continue
+			}
+		}
+		if name, ok := keyName(field); ok && strings.EqualFold(name, key) {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// keyName returns the key of field: its yaml, json, toml or hcl tag, or its
+// Go name. Fields tagged "-" have no key. It follows loader.FileKey, which
+// the module does not import so that it does not depend on xconfig.
+func keyName(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{"yaml", "json", "toml", "hcl"} {
+		name, _, _ := strings.Cut(field.Tag.Get(tag), ",")
+		if name == "-" {
+			return "", false
+		}
+		if name != "" {
+			return name, true
+		}
+	}
+	return field.Name, true
+}
+
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func fieldError(path string, err error) error {
+	return fmt.Errorf("xconfighcl: %s: %w", path, err)
+}
+
+// mismatch reports a value of the wrong kind. The value itself is not quoted
+// because it may be a secret.
+func mismatch(path string, value any, t reflect.Type) error {
+	return fmt.Errorf("xconfighcl: %s: cannot decode %T into %s", path, value, t)
+}
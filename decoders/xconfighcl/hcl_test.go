@@ -0,0 +1,165 @@
+package xconfighcl_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig/decoders/xconfighcl"
+)
+
+type listener struct {
+	Address string `hcl:"address"`
+	TLS     bool   `hcl:"tls"`
+}
+
+type upstream struct {
+	URL     string        `hcl:"url"`
+	Timeout time.Duration `hcl:"timeout"`
+	Retries int           `hcl:"retries"`
+}
+
+type database struct {
+	Host string `hcl:"host"`
+	Port int    `hcl:"port"`
+}
+
+type config struct {
+	Name      string              `hcl:"name"`
+	Tags      []string            `hcl:"tags"`
+	Database  database            `hcl:"database"`
+	Listeners []listener          `hcl:"listener"`
+	Upstreams map[string]upstream `hcl:"upstream"`
+}
+
+func TestDecoderFormat(t *testing.T) {
+	t.Parallel()
+	if got := xconfighcl.New().Format(); got != "hcl" {
+		t.Errorf("Format() = %q, want hcl", got)
+	}
+}
+
+func TestUnmarshalBlocks(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	err := xconfighcl.New().Unmarshal([]byte(`
+name = "edge"
+tags = ["a", "b"]
+
+database {
+  host = "db"
+}
+
+listener {
+  address = ":80"
+  tls     = true
+}
+
+listener {
+  address = ":443"
+}
+
+upstream "api" {
+  url     = "http://api"
+  timeout = "2s"
+}
+
+upstream "auth" {
+  url     = "http://auth"
+  retries = 3
+}
+`), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := config{
+		Name:     "edge",
+		Tags:     []string{"a", "b"},
+		Database: database{Host: "db"},
+		Listeners: []listener{
+			{Address: ":80", TLS: true},
+			{Address: ":443"},
+		},
+		Upstreams: map[string]upstream{
+			"api":  {URL: "http://api", Timeout: 2 * time.Second},
+			"auth": {URL: "http://auth", Retries: 3},
+		},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestUnmarshalSingleBlockIntoSlice(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	if err := xconfighcl.New().Unmarshal([]byte(`listener { address = ":80" }`), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	if want := []listener{{Address: ":80"}}; !reflect.DeepEqual(cfg.Listeners, want) {
+		t.Errorf("Listeners = %+v, want %+v", cfg.Listeners, want)
+	}
+}
+
+func TestUnmarshalIntoMap(t *testing.T) {
+	t.Parallel()
+
+	// The loader reads the keys of a file from generic maps and lists.
+	var raw map[string]any
+	err := xconfighcl.New().Unmarshal([]byte(`
+name = "edge"
+
+listener {
+  address = ":80"
+}
+
+listener {
+  address = ":443"
+}
+
+upstream "api" {
+  url = "http://api"
+}
+`), &raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"name": "edge",
+		"listener": []any{
+			map[string]any{"address": ":80"},
+			map[string]any{"address": ":443"},
+		},
+		"upstream": map[string]any{
+			"api": map[string]any{"url": "http://api"},
+		},
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("Unmarshal() = %#v, want %#v", raw, want)
+	}
+}
+
+func TestUnmarshalRejectsVariables(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	if err := xconfighcl.New().Unmarshal([]byte(`name = var.name`), &cfg); err == nil {
+		t.Fatal("Unmarshal() error = nil, want an error for a variable reference")
+	}
+}
+
+func TestUnmarshalRejectsDuplicateLabelledBlock(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	err := xconfighcl.New().Unmarshal([]byte(`
+upstream "api" {}
+upstream "api" {}
+`), &cfg)
+	if err == nil {
+		t.Fatal("Unmarshal() error = nil, want a duplicate block error")
+	}
+}
@@ -0,0 +1,5 @@
+module github.com/sxwebdev/xconfig/decoders/xconfigtoml
+
+go 1.23.0
+
+require github.com/pelletier/go-toml/v2 v2.4.3
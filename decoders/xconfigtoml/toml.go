@@ -0,0 +1,23 @@
+package xconfigtoml
+
+import (
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Decoder of toml.
+type Decoder struct{}
+
+// New toml decoder.
+func New() *Decoder { return &Decoder{} }
+
+// Format of the decoder.
+func (d *Decoder) Format() string {
+	return "toml"
+}
+
+// Unmarshal decodes the given data into the provided struct. Tables decode
+// into map[string]any and arrays into []any, so the loader can detect unknown
+// and present fields of TOML files.
+func (d *Decoder) Unmarshal(data []byte, v any) error {
+	return toml.Unmarshal(data, v)
+}
@@ -0,0 +1,91 @@
+package xconfigtoml_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sxwebdev/xconfig/decoders/xconfigtoml"
+)
+
+type server struct {
+	Name    string `toml:"name"`
+	Enabled bool   `toml:"enabled"`
+}
+
+type config struct {
+	Title   string            `toml:"title"`
+	Port    int               `toml:"port"`
+	Labels  map[string]string `toml:"labels"`
+	Servers []server          `toml:"servers"`
+}
+
+const content = `
+title = "api"
+port = 8080
+
+[labels]
+team = "core"
+
+[[servers]]
+name = "one"
+enabled = true
+
+[[servers]]
+name = "two"
+`
+
+func TestDecoderFormat(t *testing.T) {
+	t.Parallel()
+	if got := xconfigtoml.New().Format(); got != "toml" {
+		t.Errorf("Format() = %q, want toml", got)
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	if err := xconfigtoml.New().Unmarshal([]byte(content), &cfg); err != nil {
+		t.Fatal(err)
+	}
+	want := config{
+		Title:   "api",
+		Port:    8080,
+		Labels:  map[string]string{"team": "core"},
+		Servers: []server{{Name: "one", Enabled: true}, {Name: "two"}},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Unmarshal() = %+v, want %+v", cfg, want)
+	}
+}
+
+func TestUnmarshalIntoMap(t *testing.T) {
+	t.Parallel()
+
+	// The loader reads the keys of a file from generic maps and lists.
+	var raw map[string]any
+	if err := xconfigtoml.New().Unmarshal([]byte(content), &raw); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]any{
+		"title":  "api",
+		"port":   int64(8080),
+		"labels": map[string]any{"team": "core"},
+		"servers": []any{
+			map[string]any{"name": "one", "enabled": true},
+			map[string]any{"name": "two"},
+		},
+	}
+	if !reflect.DeepEqual(raw, want) {
+		t.Errorf("Unmarshal() = %#v, want %#v", raw, want)
+	}
+}
+
+func TestUnmarshalInvalid(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	if err := xconfigtoml.New().Unmarshal([]byte(`title = `), &cfg); err == nil {
+		t.Fatal("Unmarshal() error = nil, want a syntax error")
+	}
+}
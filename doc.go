@@ -52,6 +52,11 @@
 //	    Enabled bool   `default:"true"`
 //	}
 //
+// With WithComputedDefaults, a default may reference other fields by their
+// flat names, such as `default:"${Server.Host}:${Server.Port}"`. It is
+// computed once every other source ran, in dependency order, and again on
+// Config.Refresh. Without it the tag is set verbatim.
+//
 // ## Environment Variables
 //
 // Use the "env" tag to bind fields to environment variables:
@@ -66,6 +71,15 @@
 //	_, err := xconfig.Load(cfg, xconfig.WithEnvPrefix("MYAPP"))
 //	// Will look for: MYAPP_API_KEY, MYAPP_SECRET
 //
+// A field tagged env_file:"true" whose env var is unset is read from the file
+// named by the same variable with a _FILE suffix, such as
+// MYAPP_SECRET_FILE=/run/secrets/secret, without its trailing newline. On a
+// map of scalars the tag applies to every entry. Setting both variables fails
+// Load.
+//
+// WithEnvSource reads the variables from an env.Source, such as an env.Map,
+// instead of the process environment.
+//
 // The env plugin can also populate slices of structs and maps directly from
 // environment variables — including nil/empty containers. Slices grow
 // automatically to fit the largest index found; map entries take the suffix
@@ -83,7 +97,8 @@
 //	}
 //
 // Pointer element types ([]*T, map[string]*T) are also supported — empty
-// slots are allocated as &T{}.
+// slots are allocated as &T{}. Arrays of structs ([3]Item) are named like
+// slices but never grow: a key past their end fails with flat.ErrArrayIndex.
 //
 // An env tag on a field nested inside a slice element or map value acts as a
 // per-segment override (the surrounding slice index or map key is preserved
@@ -123,6 +138,27 @@
 //
 //	_, err = xconfig.Load(cfg, xconfig.WithLoader(l))
 //
+// WithInterpolation expands ${VAR}, ${VAR:-default} and ${VAR:?error}
+// references in the string values of the files, reading the variables
+// from the environment or a loader.Resolver:
+//
+//	dsn: postgres://${DB_USER}@${DB_HOST:-localhost}/app
+//
+// AddDir and AddGlob load conf.d-style drop-in files in lexical order, and
+// pick up added and removed files on refresh:
+//
+//	err = l.AddDir("conf.d", "*.yaml", true)
+//
+// The merge tag, such as `merge:"append"` or `merge:"merge-by-key:Name"`,
+// sets how the maps and lists of a file combine with those of the files
+// loaded before it; WithMergeStrategy sets it for the other fields.
+//
+// WithProfiles and WithProfileEnv load the overlays config.<profile>.yaml of
+// every active profile and then config.local.yaml after each file, when they
+// exist, and honor `default_<profile>` tags:
+//
+//	_, err = xconfig.Load(cfg, xconfig.WithLoader(l), xconfig.WithProfileEnv("APP_PROFILE"))
+//
 // ## Custom Defaults
 //
 // Implement SetDefaults() for programmatic default values:
@@ -195,6 +231,30 @@
 // FieldChange.FieldName contains the full field path. Events intentionally omit old
 // and new values so secret material cannot leak through logs or metrics.
 //
+// Subscribe to a flat-name prefix to react only when part of the configuration
+// changed, with owned copies of the previous and new configuration:
+//
+//	unsubscribe, err := xconfig.Subscribe(xc, "Database", func(previous, current Config) {
+//	    pool.Reconnect(current.Database)
+//	})
+//
+// Load with WithWatchFiles to refresh as soon as a loaded file changes instead of
+// waiting for the next tick. Writes, atomic renames and the symlink swap of a
+// mounted Kubernetes ConfigMap are detected and debounced into one refresh.
+//
+// # Value Provenance
+//
+// Provenance reports which sources set each field, oldest first, so the last
+// entry explains the current value:
+//
+//	for _, source := range xc.Explain("Port") {
+//	    log.Printf("Port set by %s %s", source.Plugin, source.Name)
+//	}
+//
+// Custom plugins describe their origins by implementing [plugins.SourceReporter],
+// and implement [plugins.SetReporter] to be recorded when they set a field to
+// the value it already holds.
+//
 // # Secret Management
 //
 // Use the secret tag to mark fields as sensitive. The secret plugin can also load
@@ -228,6 +288,22 @@
 //	    return nil
 //	}
 //
+// Validate() is called recursively on nested structs, slice elements and map
+// values too, and failures are reported with their flat path, such as
+// "Servers.2.TLS: cert is empty". Refresh runs the same checks before it
+// publishes a snapshot and keeps the previous one when they fail.
+//
+// The validate plugin also evaluates rules written in the validate tag, such as
+// min, max, len, oneof, nonzero, url, hostport, cidr, regexp and omitempty, and
+// reports every failing flat field in a *validate.RulesError:
+//
+//	type Config struct {
+//	    Workers int           `validate:"min=1,max=64"`
+//	    Timeout time.Duration `validate:"min=100ms"`
+//	}
+//
+//	_, err := xconfig.Load(cfg, xconfig.WithPlugins(validate.New()))
+//
 // Or use external validators with the validate plugin:
 //
 //	import (
@@ -257,6 +333,7 @@
 //   - secret: Marks field as sensitive (metadata for masking/docs)
 //   - vault: Field sourced from HashiCorp Vault (vault:"true")
 //   - usage: Description for documentation and help text
+//   - required: Field must be set; Load fails with *required.MissingFieldsError otherwise
 //   - xconfig: Override field name in flat structure
 //
 // # Supported Types
@@ -269,8 +346,30 @@
 //   - uint, uint8, uint16, uint32, uint64
 //   - float32, float64
 //   - time.Duration
+//   - *bool, *int, *time.Duration, etc., which stay nil while unset
+//   - pointers to structs, optional sections which stay nil while unset
 //   - []string, []int, []float64, etc.
+//   - [3]string, [2]int, etc., set from exactly as many values
+//   - map[string]string, map[string]time.Duration, etc.
 //   - Custom types via encoding.TextUnmarshaler
+//   - Any type with a converter, see flat.RegisterConverter and WithConverter
+//   - ByteSize, HostPort, LogLevel and the other types of the types package
+//   - types.FileContent, set from a path and holding the file content, which
+//     Load reads during Parse and re-reads on every Refresh
+//   - types.TLS sections, which the tlsconfig package turns into a *tls.Config
+//     following the certificates of the latest published snapshot
+//
+// Types implementing FormatHinter describe their format in Usage and
+// GenerateMarkdown.
+//
+// Slices and maps are set from a single value such as "a,b" or
+// "team=core,tier=1"; the xconfig_sep and xconfig_kvsep tags change the
+// separators.
+//
+// The fields of an optional section are named like those of a nested struct.
+// The section is allocated once a source sets one of them, and its other
+// fields then receive their defaults. Tag the pointer alloc:"always" to let
+// defaults allocate it as well.
 //
 // # Custom Plugins
 //
@@ -0,0 +1,125 @@
+package flat
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"sync"
+)
+
+// Converter parses text into values of a single type, taking precedence over
+// the built-in parsing of that type. Create one with NewConverter.
+type Converter struct {
+	typ reflect.Type
+	set func(reflect.Value, string) error
+}
+
+// NewConverter returns a Converter parsing text into values of type T.
+func NewConverter[T any](parse func(string) (T, error)) Converter {
+	if parse == nil {
+		panic("flat: nil converter")
+	}
+	return Converter{
+		typ: reflect.TypeFor[T](),
+		set: func(v reflect.Value, s string) error {
+			value, err := parse(s)
+			if err != nil {
+				return err
+			}
+			v.Set(reflect.ValueOf(&value).Elem())
+			return nil
+		},
+	}
+}
+
+// RegisterConverter makes every view parse fields, slice and array elements,
+// map keys and map values of type T with parse. Registering a type again
+// replaces its converter. Fields of type T are never walked, even when T is a
+// struct.
+//
+//	flat.RegisterConverter(func(s string) (decimal.Decimal, error) {
+//	    return decimal.NewFromString(s)
+//	})
+func RegisterConverter[T any](parse func(string) (T, error)) {
+	c := NewConverter(parse)
+
+	convertersMu.Lock()
+	defer convertersMu.Unlock()
+	converters[c.typ] = c.set
+}
+
+// WithConverters makes the view parse values with cs before the converters
+// registered with RegisterConverter. Views of the same configuration should
+// be given the same converters, since they change which fields are walked.
+func WithConverters(cs ...Converter) Option {
+	return func(w *viewer) {
+		if len(cs) == 0 {
+			return
+		}
+		bound := make(map[reflect.Type]func(reflect.Value, string) error, len(w.bound)+len(cs))
+		maps.Copy(bound, w.bound)
+		for _, c := range cs {
+			bound[c.typ] = c.set
+		}
+		w.bound = bound
+	}
+}
+
+var (
+	convertersMu sync.RWMutex
+	converters   = map[reflect.Type]func(reflect.Value, string) error{}
+)
+
+// viewer walks structs into fields, parsing values with the converters of
+// its options before the registered ones.
+type viewer struct {
+	bound map[reflect.Type]func(reflect.Value, string) error
+	// sectionDefault applies the default of a field of an allocated
+	// section; see WithSectionDefaults.
+	sectionDefault func(Field) error
+	// computedDefaults is set when the defaults referencing other fields
+	// are computed; see WithComputedDefaults.
+	computedDefaults bool
+	// walking holds the struct types on the walk stack, outermost first.
+	walking []reflect.Type
+	// keySuffix is also matched after the keys of the fields suffixed
+	// accepts; see WithKeySuffix.
+	keySuffix string
+	suffixed  func(reflect.StructField) bool
+}
+
+func newViewer(opts []Option) viewer {
+	var w viewer
+	for _, opt := range opts {
+		opt(&w)
+	}
+	return w
+}
+
+// enter returns the viewer walking the struct type t, nested in the structs w
+// walks.
+func (w viewer) enter(t reflect.Type) viewer {
+	w.walking = append(slices.Clip(w.walking), t)
+	return w
+}
+
+// converter returns the converter of type t, or nil when there is none.
+func (w viewer) converter(t reflect.Type) func(reflect.Value, string) error {
+	if set, ok := w.bound[t]; ok {
+		return set
+	}
+
+	convertersMu.RLock()
+	defer convertersMu.RUnlock()
+	return converters[t]
+}
+
+// isScalar reports whether values of type t are parsed from text as a whole
+// rather than walked, because a converter or encoding.TextUnmarshaler parses
+// them or the values they point to.
+func (w viewer) isScalar(t reflect.Type) bool {
+	if w.converter(t) != nil || implementsTextUnmarshaler(t) {
+		return true
+	}
+	return t.Kind() == reflect.Pointer && w.converter(t.Elem()) != nil
+}
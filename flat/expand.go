@@ -1,6 +1,8 @@
 package flat
 
 import (
+	"errors"
+	"fmt"
 	"reflect"
 	"strconv"
 	"strings"
@@ -16,7 +18,8 @@ import (
 // inner struct's leaf env names (longest match wins).
 //
 // Pointer element types ([]*T, map[string]*T) are supported — empty slots
-// are allocated as &T{}.
+// are allocated as &T{}. Arrays of structs ([N]T, [N]*T) are named like
+// slices but never grow: a key past their end is reported as ErrArrayIndex.
 //
 // Returns a map from each leaf field's flat path (e.g. "Items.0.Host") to
 // its computed env-style name (e.g. "ITEMS_0_HOST"). Both env and Vault
@@ -24,18 +27,41 @@ import (
 // the (now expanded) conf.
 //
 // globalPrefix is uppercased and prepended (with an underscore) to all
-// top-level field env names. Pass "" if not using a prefix.
-func ExpandContainersFromKeys(conf any, globalPrefix string, keys []string) (map[string]string, error) {
+// top-level field env names. Pass "" if not using a prefix. opts are those
+// of the views of conf, as for View.
+func ExpandContainersFromKeys(conf any, globalPrefix string, keys []string, opts ...Option) (map[string]string, error) {
 	nameMap := make(map[string]string, 32)
 	if conf == nil {
 		return nameMap, nil
 	}
-	if err := walkAndExpand(reflect.ValueOf(conf), "", "", false, globalPrefix, keys, nameMap); err != nil {
+	if err := newViewer(opts).walkAndExpand(reflect.ValueOf(conf), "", "", false, globalPrefix, keys, nameMap); err != nil {
 		return nil, err
 	}
 	return nameMap, nil
 }
 
+// WithKeySuffix makes ExpandContainersFromKeys also expand the keys of the
+// fields accepted by suffixed followed by suffix, as the env plugin does for
+// the _FILE variables of the fields read from files. A map of scalars
+// accepted by suffixed gets the entry TEAM, not TEAM_FILE, from the key
+// LABELS_TEAM_FILE.
+func WithKeySuffix(suffix string, suffixed func(reflect.StructField) bool) Option {
+	return func(w *viewer) {
+		w.keySuffix = suffix
+		w.suffixed = suffixed
+	}
+}
+
+// hasKeySuffix reports whether the keys of ft may end in the suffix of
+// WithKeySuffix.
+func (w viewer) hasKeySuffix(ft reflect.StructField) bool {
+	return w.keySuffix != "" && w.suffixed != nil && w.suffixed(ft)
+}
+
+// ErrArrayIndex is returned by ExpandContainersFromKeys when a key addresses
+// an element past the end of an array of structs, which cannot grow.
+var ErrArrayIndex = errors.New("array index out of range")
+
 const envTagName = "env"
 
 // MakeEnvName prepends globalPrefix (uppercased) to name with an underscore
@@ -47,7 +73,7 @@ func MakeEnvName(globalPrefix, name string) string {
 	return name
 }
 
-func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer bool, globalPrefix string, keys []string, nameMap map[string]string) error {
+func (w viewer) walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer bool, globalPrefix string, keys []string, nameMap map[string]string) error {
 	for rs.Kind() == reflect.Pointer || rs.Kind() == reflect.Interface {
 		if rs.IsNil() {
 			return nil
@@ -59,6 +85,7 @@ func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer b
 	}
 
 	ts := rs.Type()
+	w = w.enter(ts)
 	for i := 0; i < rs.NumField(); i++ {
 		fv := rs.Field(i)
 		ft := ts.Field(i)
@@ -80,7 +107,26 @@ func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer b
 
 		switch fv.Kind() {
 		case reflect.Struct:
-			if err := walkAndExpand(fv, fieldPath, fieldEnv, inContainer, globalPrefix, keys, nameMap); err != nil {
+			if w.isScalar(fv.Type()) {
+				nameMap[fieldPath] = fieldEnv
+				continue
+			}
+			if err := w.walkAndExpand(fv, fieldPath, fieldEnv, inContainer, globalPrefix, keys, nameMap); err != nil {
+				return err
+			}
+
+		case reflect.Pointer:
+			if !w.isSection(ft) {
+				nameMap[fieldPath] = fieldEnv
+				continue
+			}
+			// A nil optional section is named from a zero value, without
+			// allocating it: only setting one of its fields does.
+			section := fv
+			if section.IsNil() {
+				section = reflect.New(ft.Type.Elem())
+			}
+			if err := w.walkAndExpand(section, fieldPath, fieldEnv, inContainer, globalPrefix, keys, nameMap); err != nil {
 				return err
 			}
 
@@ -92,7 +138,7 @@ func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer b
 				innerType = innerType.Elem()
 			}
 
-			if innerType.Kind() != reflect.Struct || implementsTextUnmarshaler(elemType) {
+			if innerType.Kind() != reflect.Struct || w.isScalar(elemType) {
 				nameMap[fieldPath] = fieldEnv
 				continue
 			}
@@ -121,7 +167,40 @@ func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer b
 				}
 				idxPath := fieldPath + "." + strconv.Itoa(j)
 				idxEnv := fieldEnv + "_" + strconv.Itoa(j)
-				if err := walkAndExpand(elem, idxPath, idxEnv, true, globalPrefix, keys, nameMap); err != nil {
+				if err := w.walkAndExpand(elem, idxPath, idxEnv, true, globalPrefix, keys, nameMap); err != nil {
+					return err
+				}
+			}
+
+		case reflect.Array:
+			elemType := fv.Type().Elem()
+			innerType := elemType
+			if innerType.Kind() == reflect.Pointer {
+				innerType = innerType.Elem()
+			}
+
+			if innerType.Kind() != reflect.Struct || w.isScalar(elemType) {
+				nameMap[fieldPath] = fieldEnv
+				continue
+			}
+
+			// Unlike a slice, an array never grows.
+			if maxIdx := scanSliceMaxIndex(fieldEnv, keys); maxIdx >= fv.Len() {
+				return fmt.Errorf("%w: %s sets element %d of %s, which has %d elements",
+					ErrArrayIndex, findIndexKey(fieldEnv, maxIdx, keys), maxIdx, fieldPath, fv.Len())
+			}
+
+			for j := 0; j < fv.Len(); j++ {
+				elem := fv.Index(j)
+				if elem.Kind() == reflect.Pointer {
+					if elem.IsNil() {
+						elem.Set(reflect.New(innerType))
+					}
+					elem = elem.Elem()
+				}
+				idxPath := fieldPath + "." + strconv.Itoa(j)
+				idxEnv := fieldEnv + "_" + strconv.Itoa(j)
+				if err := w.walkAndExpand(elem, idxPath, idxEnv, true, globalPrefix, keys, nameMap); err != nil {
 					return err
 				}
 			}
@@ -136,16 +215,16 @@ func walkAndExpand(rs reflect.Value, pathPrefix, envPrefix string, inContainer b
 			if isPtr {
 				innerType = innerType.Elem()
 			}
-			structValue := innerType.Kind() == reflect.Struct && !implementsTextUnmarshaler(elemType)
+			structValue := innerType.Kind() == reflect.Struct && !w.isScalar(elemType)
 
 			if !structValue {
-				if err := expandPrimitiveMap(fv, fieldPath, fieldEnv, keys, nameMap); err != nil {
+				if err := w.expandPrimitiveMap(fv, ft, fieldPath, fieldEnv, keys, nameMap); err != nil {
 					return err
 				}
 				continue
 			}
 
-			if err := expandStructMap(fv, fieldPath, fieldEnv, innerType, isPtr, globalPrefix, keys, nameMap); err != nil {
+			if err := w.expandStructMap(fv, fieldPath, fieldEnv, innerType, isPtr, globalPrefix, keys, nameMap); err != nil {
 				return err
 			}
 
@@ -178,11 +257,13 @@ func computeFieldEnv(envPrefix string, ft reflect.StructField, globalPrefix stri
 	return envPrefix + "_" + seg
 }
 
-func expandPrimitiveMap(fv reflect.Value, fieldPath, fieldEnv string, keys []string, nameMap map[string]string) error {
+func (w viewer) expandPrimitiveMap(fv reflect.Value, ft reflect.StructField, fieldPath, fieldEnv string, keys []string, nameMap map[string]string) error {
 	if fv.IsNil() {
 		fv.Set(reflect.MakeMap(fv.Type()))
 	}
 
+	nameMap[fieldPath] = fieldEnv
+
 	keyType := fv.Type().Key()
 	envPrefix := fieldEnv + "_"
 
@@ -191,6 +272,9 @@ func expandPrimitiveMap(fv reflect.Value, fieldPath, fieldEnv string, keys []str
 			continue
 		}
 		mapKey := key[len(envPrefix):]
+		if w.hasKeySuffix(ft) {
+			mapKey = strings.TrimSuffix(mapKey, w.keySuffix)
+		}
 		if mapKey == "" {
 			continue
 		}
@@ -208,12 +292,12 @@ func expandPrimitiveMap(fv reflect.Value, fieldPath, fieldEnv string, keys []str
 	return nil
 }
 
-func expandStructMap(fv reflect.Value, fieldPath, fieldEnv string, innerType reflect.Type, isPtr bool, globalPrefix string, keys []string, nameMap map[string]string) error {
+func (w viewer) expandStructMap(fv reflect.Value, fieldPath, fieldEnv string, innerType reflect.Type, isPtr bool, globalPrefix string, keys []string, nameMap map[string]string) error {
 	if fv.IsNil() {
 		fv.Set(reflect.MakeMap(fv.Type()))
 	}
 
-	suffixes := enumerateLeafSuffixes(innerType)
+	suffixes := w.enumerateLeafSuffixes(innerType)
 
 	envPrefix := fieldEnv + "_"
 	for _, key := range keys {
@@ -256,14 +340,14 @@ func expandStructMap(fv reflect.Value, fieldPath, fieldEnv string, innerType ref
 		k := iter.Key().String()
 		entryPath := fieldPath + "." + k
 		entryEnv := fieldEnv + "_" + k
-		if err := walkAndExpand(scratch, entryPath, entryEnv, true, globalPrefix, keys, nameMap); err != nil {
+		if err := w.walkAndExpand(scratch, entryPath, entryEnv, true, globalPrefix, keys, nameMap); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func enumerateLeafSuffixes(t reflect.Type) []string {
+func (w viewer) enumerateLeafSuffixes(t reflect.Type) []string {
 	for t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
@@ -272,7 +356,7 @@ func enumerateLeafSuffixes(t reflect.Type) []string {
 	}
 
 	var suffixes []string
-	collectLeafSuffixes(t, "", &suffixes)
+	w.collectLeafSuffixes(t, "", &suffixes)
 	for i := 0; i < len(suffixes)-1; i++ {
 		for j := i + 1; j < len(suffixes); j++ {
 			if len(suffixes[j]) > len(suffixes[i]) {
@@ -283,7 +367,7 @@ func enumerateLeafSuffixes(t reflect.Type) []string {
 	return suffixes
 }
 
-func collectLeafSuffixes(t reflect.Type, prefix string, out *[]string) {
+func (w viewer) collectLeafSuffixes(t reflect.Type, prefix string, out *[]string) {
 	for t.Kind() == reflect.Pointer {
 		t = t.Elem()
 	}
@@ -321,26 +405,35 @@ func collectLeafSuffixes(t reflect.Type, prefix string, out *[]string) {
 
 		switch ft.Kind() {
 		case reflect.Struct:
-			if implementsTextUnmarshaler(f.Type) {
-				*out = append(*out, newPrefix)
+			if w.isScalar(f.Type) {
+				w.addLeafSuffix(f, newPrefix, out)
 				continue
 			}
-			collectLeafSuffixes(ft, newPrefix, out)
-		case reflect.Slice, reflect.Map:
+			w.collectLeafSuffixes(ft, newPrefix, out)
+		case reflect.Slice, reflect.Array, reflect.Map:
 			elem := ft.Elem()
 			for elem.Kind() == reflect.Pointer {
 				elem = elem.Elem()
 			}
-			if elem.Kind() == reflect.Struct && !implementsTextUnmarshaler(ft.Elem()) {
+			if elem.Kind() == reflect.Struct && !w.isScalar(ft.Elem()) {
 				continue
 			}
-			*out = append(*out, newPrefix)
+			w.addLeafSuffix(f, newPrefix, out)
 		default:
-			*out = append(*out, newPrefix)
+			w.addLeafSuffix(f, newPrefix, out)
 		}
 	}
 }
 
+// addLeafSuffix adds the env name suffix of the leaf f to out, followed by
+// the key suffix too when f may have it.
+func (w viewer) addLeafSuffix(f reflect.StructField, suffix string, out *[]string) {
+	*out = append(*out, suffix)
+	if w.hasKeySuffix(f) {
+		*out = append(*out, suffix+w.keySuffix)
+	}
+}
+
 func scanSliceMaxIndex(prefix string, keys []string) int {
 	if prefix == "" {
 		return -1
@@ -370,6 +463,17 @@ func scanSliceMaxIndex(prefix string, keys []string) int {
 	return max
 }
 
+// findIndexKey returns the first key addressing element index below prefix.
+func findIndexKey(prefix string, index int, keys []string) string {
+	wanted := prefix + "_" + strconv.Itoa(index)
+	for _, key := range keys {
+		if key == wanted || strings.HasPrefix(key, wanted+"_") {
+			return key
+		}
+	}
+	return wanted
+}
+
 func implementsTextUnmarshaler(t reflect.Type) bool {
 	if t.Implements(textUnmarshalerType) {
 		return true
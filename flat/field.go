@@ -22,14 +22,33 @@ type field struct {
 	tag       reflect.StructTag
 	field     reflect.Value
 	fieldType reflect.StructField
+	w         viewer
 
 	// mapSync is called after field modification to sync back to map
 	mapSync func()
+
+	// section is the optional section the field was viewed in while it was
+	// nil, and index locates the field in the struct of that section.
+	section *section
+	index   []int
+}
+
+// bind points the field at the struct currently holding it, since its
+// section may have been allocated since the field was viewed.
+func (f *field) bind() {
+	if f.section != nil {
+		f.field = valueAt(f.section.elem(), f.index)
+	}
 }
 
-// Used by standard library flag package.
+// Used by standard library flag package. A *bool field is a boolean flag too,
+// so -debug sets it to true without an explicit value.
 func (f *field) IsBoolFlag() bool {
-	return f.field.Kind() == reflect.Bool
+	t := f.field.Type()
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Bool
 }
 
 func (f *field) Name() string {
@@ -68,8 +87,17 @@ func (f *field) String() string {
 	return f.tag.Get("default")
 }
 
+// IsZero reports whether the field holds its zero value. A map without
+// entries counts as zero, so an empty map still receives its default.
 func (f *field) IsZero() bool {
-	return f.field.IsValid() && f.field.IsZero()
+	f.bind()
+	if !f.field.IsValid() {
+		return false
+	}
+	if f.field.Kind() == reflect.Map {
+		return f.field.Len() == 0
+	}
+	return f.field.IsZero()
 }
 
 var textUnmarshalerType = reflect.TypeOf(new(encoding.TextUnmarshaler)).Elem()
@@ -80,15 +108,49 @@ func (f *field) Set(value string) error {
 }
 
 // SetChanged sets value and reports whether the field's semantic value changed.
+// Setting a field of a nil optional section allocates the section, which
+// counts as a change even when the value itself is the zero one.
 func (f *field) SetChanged(value string) (bool, error) {
+	f.bind()
+	changed, err := f.set(value)
+	if err == nil && f.section != nil {
+		var allocated bool
+		allocated, err = f.section.allocate(f.name)
+		changed = changed || allocated
+	}
+	if err == nil && changed && f.mapSync != nil {
+		f.mapSync()
+	}
+	return changed, err
+}
+
+func (f *field) set(value string) (bool, error) {
 	t := f.field.Type()
 
+	if set := f.w.converter(t); set != nil {
+		return f.setText(set, value)
+	}
+
+	if f.field.Kind() == reflect.Map {
+		return f.setMap(value)
+	}
+
 	if t.Implements(textUnmarshalerType) {
-		changed, err := f.setUnmarshale([]byte(value))
-		if err == nil && changed && f.mapSync != nil {
-			f.mapSync()
+		return f.setUnmarshale([]byte(value))
+	}
+
+	if f.field.Kind() == reflect.Pointer {
+		return f.setPointer(value)
+	}
+
+	// Structs, and other types such as slog.Level whose pointer implements
+	// encoding.TextUnmarshaler, are parsed as a whole.
+	if f.field.Kind() == reflect.Struct || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		set := f.w.textSetter(t)
+		if set == nil {
+			return false, f.unsupported()
 		}
-		return changed, err
+		return f.setText(set, value)
 	}
 
 	before := reflect.New(t).Elem()
@@ -111,32 +173,63 @@ func (f *field) SetChanged(value string) (bool, error) {
 		err = f.setFloat(value)
 	case reflect.Slice:
 		err = f.setSlice(value)
+	case reflect.Array:
+		err = f.setArray(value)
+	default:
+		// Complex numbers, funcs, channels, interfaces and unsafe pointers
+		// need a converter.
+		err = f.unsupported()
+	}
 
-		// Soon case reflect.Map:
-
-		// Maybe case reflect.Array:
+	return err == nil && !equalIgnoringFuncs(before, f.field), err
+}
 
-		// Why? case reflect.Complex64:
-		// Why? case reflect.Complex128:
+// setPointer parses value into a new pointee for a pointer to a scalar, such
+// as *bool or *time.Duration, so a nil pointer stays distinguishable from an
+// explicit zero value. The pointer is replaced rather than written through,
+// because the previous pointee may be shared with other fields.
+func (f *field) setPointer(value string) (bool, error) {
+	elem := f.field.Type().Elem()
+	set := f.w.textSetter(elem)
+	if set == nil {
+		return false, f.unsupported()
+	}
 
-		// Never case reflect.Func:
-		// Never case reflect.Chan:
-		// Never case reflect.Interface:
-		// Never case reflect.Pointer:
-		// Never case reflect.Struct:
-		// Never case reflect.UnsafePointer:
+	candidate := reflect.New(elem)
+	if err := set(candidate.Elem(), value); err != nil {
+		return false, err
+	}
+	if !f.field.IsNil() && equalIgnoringFuncs(f.field.Elem(), candidate.Elem()) {
+		return false, nil
 	}
+	f.field.Set(candidate)
+	return true, nil
+}
 
-	changed := err == nil && !equalIgnoringFuncs(before, f.field)
-	if changed && f.mapSync != nil {
-		f.mapSync()
+// setText parses value with set into a copy of the field, so a failure leaves
+// the field untouched and a type decoding only part of its state keeps the
+// rest.
+func (f *field) setText(set func(reflect.Value, string) error, value string) (bool, error) {
+	candidate := reflect.New(f.field.Type()).Elem()
+	candidate.Set(f.field)
+	if err := set(candidate, value); err != nil {
+		return false, err
+	}
+	if equalIgnoringFuncs(f.field, candidate) {
+		return false, nil
 	}
+	f.field.Set(candidate)
+	return true, nil
+}
 
-	return changed, err
+// unsupported reports a field whose type cannot be parsed from text.
+func (f *field) unsupported() error {
+	return fmt.Errorf("field %s: cannot set %s from text: register a converter for it", f.name, f.field.Type())
 }
 
 // FieldValue is a field in a struct.
 func (f *field) FieldValue() reflect.Value {
+	f.bind()
 	return f.field
 }
 
@@ -301,13 +394,13 @@ func (f *field) setFloat(value string) error {
 
 func (f *field) setSlice(value string) error {
 	t := f.field.Type()
-	setSliceElem := setSliceElem(t.Elem())
+	setSliceElem := f.w.textSetter(t.Elem())
 
 	if setSliceElem == nil {
-		return nil
+		return f.unsupported()
 	}
 
-	values := strings.Split(value, ",")
+	values := strings.Split(value, f.separator())
 	valuesLen := len(values)
 
 	candidate := reflect.MakeSlice(t, valuesLen, valuesLen)
@@ -323,6 +416,31 @@ func (f *field) setSlice(value string) error {
 	return nil
 }
 
+// setArray parses exactly as many values as the array holds. A list of the
+// wrong length or with an invalid value leaves the array unchanged.
+func (f *field) setArray(value string) error {
+	t := f.field.Type()
+	setElem := f.w.textSetter(t.Elem())
+	if setElem == nil {
+		return f.unsupported()
+	}
+
+	values := strings.Split(value, f.separator())
+	if len(values) != t.Len() {
+		return fmt.Errorf("field %s: got %d values, want %d", f.name, len(values), t.Len())
+	}
+
+	candidate := reflect.New(t).Elem()
+	for i, value := range values {
+		if err := setElem(candidate.Index(i), strings.TrimSpace(value)); err != nil {
+			return err
+		}
+	}
+
+	f.field.Set(candidate)
+	return nil
+}
+
 func setSliceElem(elem reflect.Type) func(reflect.Value, string) error {
 	switch elem.Kind() {
 	case reflect.String:
@@ -386,3 +504,11 @@ func setSliceElemFloat(f reflect.Value, value string) error {
 	f.SetFloat(v)
 	return nil
 }
+
+// Sync stores f back into the map entry it belongs to, after its value was
+// modified in place through FieldValue. Fields outside maps are left as is.
+func Sync(f Field) {
+	if ff, ok := f.(*field); ok && ff.mapSync != nil {
+		ff.mapSync()
+	}
+}
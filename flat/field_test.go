@@ -2,11 +2,14 @@ package flat_test
 
 import (
 	"errors"
+	"fmt"
 	"log/slog"
 	"net/http"
+	"net/netip"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/internal/testutil"
@@ -375,9 +378,11 @@ func TestFieldSetChangedIgnoresFuncState(t *testing.T) {
 			t.Fatalf("View() error = %v", err)
 		}
 
+		// Nothing parses a func from text: the field is reported, not
+		// silently skipped, and never reported changed.
 		changed, err := fields[0].SetChanged("anything")
-		if err != nil {
-			t.Fatalf("SetChanged() error = %v", err)
+		if err == nil {
+			t.Fatal("SetChanged() error = nil for a func field")
 		}
 		if changed {
 			t.Error("SetChanged() on an untouched func field reported changed = true")
@@ -476,3 +481,320 @@ func TestFieldSetValueReceiverTextUnmarshaler(t *testing.T) {
 		}
 	})
 }
+
+func TestFieldSetChangedParsesMaps(t *testing.T) {
+	t.Parallel()
+
+	config := struct {
+		Labels   map[string]string
+		Weights  map[int]float64
+		Timeouts map[string]time.Duration
+		Hosts    map[string]netip.Addr
+		Flags    map[string]bool `xconfig_sep:";" xconfig_kvsep:":"`
+	}{}
+
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	values := map[string]string{
+		"Labels":   "team=core, tier = 1",
+		"Weights":  "1=0.5,2=1.5",
+		"Timeouts": "read=1s,write=250ms",
+		"Hosts":    "primary=10.0.0.1,backup=10.0.0.2",
+		"Flags":    "beta:true;legacy:false",
+	}
+	for _, fld := range fields {
+		changed, err := fld.SetChanged(values[fld.Name()])
+		if err != nil {
+			t.Fatalf("SetChanged(%s) error = %v", fld.Name(), err)
+		}
+		if !changed {
+			t.Errorf("SetChanged(%s) changed = false, want true", fld.Name())
+		}
+	}
+
+	testutil.Equal(t, map[string]string{"team": "core", "tier": "1"}, config.Labels)
+	testutil.Equal(t, map[int]float64{1: 0.5, 2: 1.5}, config.Weights)
+	testutil.Equal(t, map[string]time.Duration{"read": time.Second, "write": 250 * time.Millisecond}, config.Timeouts)
+	testutil.Equal(t, map[string]netip.Addr{
+		"primary": netip.MustParseAddr("10.0.0.1"),
+		"backup":  netip.MustParseAddr("10.0.0.2"),
+	}, config.Hosts)
+	testutil.Equal(t, map[string]bool{"beta": true, "legacy": false}, config.Flags)
+
+	changed, err := fields[0].SetChanged("tier=1,team=core")
+	if err != nil {
+		t.Fatalf("SetChanged() error = %v", err)
+	}
+	if changed {
+		t.Error("SetChanged() with the same entries reported changed = true")
+	}
+}
+
+func TestFieldSetChangedMapIsTransactional(t *testing.T) {
+	t.Parallel()
+
+	config := struct {
+		Limits map[string]int
+	}{Limits: map[string]int{"cpu": 2}}
+
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	for _, value := range []string{"cpu=4,memory", "cpu=4,memory=lots"} {
+		changed, err := fields[0].SetChanged(value)
+		if err == nil {
+			t.Fatalf("SetChanged(%q) error = nil, want an error", value)
+		}
+		if changed {
+			t.Errorf("SetChanged(%q) changed = true, want false", value)
+		}
+		testutil.Equal(t, map[string]int{"cpu": 2}, config.Limits)
+	}
+}
+
+func TestFieldSetMapKeepsEntryFieldsBound(t *testing.T) {
+	t.Parallel()
+
+	config := struct {
+		Labels map[string]string
+	}{Labels: map[string]string{"team": "core"}}
+
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	testutil.Equal(t, "Labels", fields[0].Name())
+	testutil.Equal(t, "Labels.team", fields[1].Name())
+
+	if err := fields[0].Set("team=edge,tier=1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	// An entry field created before the whole map was set still writes to it.
+	if err := fields[1].Set("platform"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	testutil.Equal(t, map[string]string{"team": "platform", "tier": "1"}, config.Labels)
+}
+
+func TestFieldSetChangedAllocatesPointerScalars(t *testing.T) {
+	t.Parallel()
+
+	shared := 1
+	config := struct {
+		Enabled *bool
+		Retries *int
+		Timeout *time.Duration
+		Host    *netip.Addr
+		First   *int
+		Second  *int
+	}{First: &shared, Second: &shared}
+
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	for _, fld := range fields[:4] {
+		if !fld.IsZero() {
+			t.Errorf("%s.IsZero() = false for a nil pointer", fld.Name())
+		}
+	}
+
+	values := map[string]string{
+		"Enabled": "false",
+		"Retries": "0",
+		"Timeout": "1500ms",
+		"Host":    "10.0.0.1",
+		"First":   "2",
+		"Second":  "1",
+	}
+	for _, fld := range fields {
+		changed, err := fld.SetChanged(values[fld.Name()])
+		if err != nil {
+			t.Fatalf("SetChanged(%s) error = %v", fld.Name(), err)
+		}
+		testutil.Equal(t, fld.Name() != "Second", changed)
+	}
+
+	// Explicit zero values are set, not mistaken for unset.
+	if config.Enabled == nil || *config.Enabled || config.Retries == nil || *config.Retries != 0 {
+		t.Fatalf("Enabled = %v, Retries = %v, want explicit false and 0", config.Enabled, config.Retries)
+	}
+	testutil.Equal(t, 1500*time.Millisecond, *config.Timeout)
+	testutil.Equal(t, netip.MustParseAddr("10.0.0.1"), *config.Host)
+	// A pointee shared with another field is replaced, not written through.
+	testutil.Equal(t, 2, *config.First)
+	testutil.Equal(t, 1, shared)
+
+	retries := config.Retries
+	if _, err := fields[1].SetChanged("many"); err == nil {
+		t.Fatal("SetChanged(many) error = nil, want a parse error")
+	}
+	if config.Retries != retries || *config.Retries != 0 {
+		t.Fatalf("failed SetChanged changed Retries to %v", config.Retries)
+	}
+}
+
+func TestFieldSetChangedParsesArrays(t *testing.T) {
+	t.Parallel()
+
+	config := struct {
+		Replicas [3]string
+		Weights  [2]float64 `xconfig_sep:";"`
+		Hosts    [2]netip.Addr
+	}{}
+
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	values := map[string]string{
+		"Replicas": "a, b,c",
+		"Weights":  "0.5;1.5",
+		"Hosts":    "10.0.0.1,10.0.0.2",
+	}
+	for _, fld := range fields {
+		changed, err := fld.SetChanged(values[fld.Name()])
+		if err != nil {
+			t.Fatalf("SetChanged(%s) error = %v", fld.Name(), err)
+		}
+		if !changed {
+			t.Errorf("SetChanged(%s) changed = false, want true", fld.Name())
+		}
+	}
+	testutil.Equal(t, [3]string{"a", "b", "c"}, config.Replicas)
+	testutil.Equal(t, [2]float64{0.5, 1.5}, config.Weights)
+	testutil.Equal(t, [2]netip.Addr{netip.MustParseAddr("10.0.0.1"), netip.MustParseAddr("10.0.0.2")}, config.Hosts)
+
+	// A list of the wrong length or with an invalid value changes nothing.
+	for _, value := range []string{"a,b", "a,b,c,d"} {
+		if _, err := fields[0].SetChanged(value); err == nil {
+			t.Errorf("SetChanged(%q) error = nil, want a length error", value)
+		}
+	}
+	if _, err := fields[1].SetChanged("1;x"); err == nil {
+		t.Error("SetChanged(1;x) error = nil, want a parse error")
+	}
+	testutil.Equal(t, [3]string{"a", "b", "c"}, config.Replicas)
+	testutil.Equal(t, [2]float64{0.5, 1.5}, config.Weights)
+}
+
+// version is a struct only parsed by converters, never walked.
+type version struct {
+	Major, Minor int
+}
+
+func parseVersion(s string) (version, error) {
+	var v version
+	_, err := fmt.Sscanf(s, "v%d.%d", &v.Major, &v.Minor)
+	return v, err
+}
+
+func TestFieldSetUsesConverters(t *testing.T) {
+	t.Parallel()
+
+	flat.RegisterConverter(parseVersion)
+
+	config := struct {
+		Version  version
+		Optional *version
+		History  []version
+		Pinned   [2]version
+		Services map[string]version
+	}{}
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+	names := make([]string, 0, len(fields))
+	for _, fld := range fields {
+		names = append(names, fld.Name())
+	}
+	testutil.Equal(t, []string{"Version", "Optional", "History", "Pinned", "Services"}, names)
+
+	values := []string{"v1.2", "v0.1", "v1.0,v1.1", "v2.0,v2.1", "api=v3.0"}
+	for i, fld := range fields {
+		if err := fld.Set(values[i]); err != nil {
+			t.Fatalf("Set(%s) error = %v", fld.Name(), err)
+		}
+	}
+	testutil.Equal(t, version{1, 2}, config.Version)
+	testutil.Equal(t, version{0, 1}, *config.Optional)
+	testutil.Equal(t, []version{{1, 0}, {1, 1}}, config.History)
+	testutil.Equal(t, [2]version{{2, 0}, {2, 1}}, config.Pinned)
+	testutil.Equal(t, map[string]version{"api": {3, 0}}, config.Services)
+
+	if err := fields[0].Set("latest"); err == nil {
+		t.Fatal("Set(latest) error = nil, want the converter error")
+	}
+	testutil.Equal(t, version{1, 2}, config.Version)
+}
+
+func TestFieldSetViewConvertersOverrideRegistered(t *testing.T) {
+	t.Parallel()
+
+	type level int
+	length := func(s string) (level, error) {
+		return level(len(s)), nil
+	}
+
+	config := struct{ Level level }{}
+	fields, err := flat.View(&config, flat.WithConverters(flat.NewConverter(length)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fields[0].Set("3"); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, level(1), config.Level)
+
+	// Views without the option parse values as before.
+	fields, err = flat.View(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fields[0].Set("42"); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, level(42), config.Level)
+}
+
+func TestFieldSetSlicesOfTextTypes(t *testing.T) {
+	t.Parallel()
+
+	config := struct {
+		Flags    []bool
+		Networks []netip.Prefix
+		Address  netip.Addr
+		Events   chan string
+		Ratios   []complex64
+	}{}
+	fields, err := flat.View(&config)
+	if err != nil {
+		t.Fatalf("View() error = %v", err)
+	}
+
+	values := map[string]string{
+		"Flags":    "true,false",
+		"Networks": "10.0.0.0/8, 192.168.0.0/16",
+		"Address":  "10.0.0.1",
+	}
+	for _, fld := range fields[:3] {
+		if err := fld.Set(values[fld.Name()]); err != nil {
+			t.Fatalf("Set(%s) error = %v", fld.Name(), err)
+		}
+	}
+	testutil.Equal(t, []bool{true, false}, config.Flags)
+	testutil.Equal(t, []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.168.0.0/16")}, config.Networks)
+	testutil.Equal(t, netip.MustParseAddr("10.0.0.1"), config.Address)
+
+	// Types nothing can parse are reported instead of silently skipped.
+	for _, fld := range fields[3:] {
+		if err := fld.Set("x"); err == nil {
+			t.Errorf("Set(%s) error = nil for an unsupported type", fld.Name())
+		}
+	}
+}
@@ -31,26 +31,57 @@ type Field interface {
 	FieldType() reflect.StructField
 }
 
+// Option configures a view.
+type Option func(*viewer)
+
+// WithSectionDefaults makes the view set the defaults of an optional section
+// with apply once the section is allocated, instead of with the default tags
+// of its fields. apply is called for every zero field of the section but the
+// one whose value allocated it, and sets the default of the field, if any.
+func WithSectionDefaults(apply func(f Field) error) Option {
+	return func(w *viewer) {
+		w.sectionDefault = apply
+	}
+}
+
+// WithComputedDefaults tells the plugins viewing the configuration that
+// default tags referencing other fields, such as `default:"${Host}:${Port}"`,
+// are computed by a later plugin, so they skip these tags instead of setting
+// them verbatim. defaults.NewComputed provides it.
+func WithComputedDefaults() Option {
+	return func(w *viewer) {
+		w.computedDefaults = true
+	}
+}
+
+// ComputedDefaults reports whether opts hold WithComputedDefaults.
+func ComputedDefaults(opts []Option) bool {
+	return newViewer(opts).computedDefaults
+}
+
 // View provides a flat view of the provided structs an array of fields.
 // sub-struct fields are prefixed with the struct key (not type) followed by a dot,
 // this is repeated for each nested level.
-func View(s any) (Fields, error) {
+func View(s any, opts ...Option) (Fields, error) {
 	rs, err := unwrap(s)
 	if err != nil {
 		return nil, err
 	}
 
-	return walkStruct("", rs)
+	return newViewer(opts).walkStruct("", rs)
 }
 
-func walkStruct(prefix string, rs reflect.Value) ([]Field, error) {
-	return walkStructWithParentTags(prefix, rs, "")
+func (w viewer) walkStruct(prefix string, rs reflect.Value) ([]Field, error) {
+	return w.walkStructWithParentTags(prefix, rs, "", location{})
 }
 
-func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflect.StructTag) ([]Field, error) {
+// walkStructWithParentTags walks the struct rs found at the location at. Fields
+// of a nil optional section are walked in its scratch struct; see section.
+func (w viewer) walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflect.StructTag, at location) ([]Field, error) {
 	fields := []Field{}
 
 	ts := rs.Type()
+	w = w.enter(ts)
 	for i := range rs.NumField() {
 		fv := rs.Field(i)
 		ft := ts.Field(i)
@@ -59,9 +90,15 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 		if !ft.IsExported() {
 			continue
 		}
+		loc := at.child(i)
 
 		switch fv.Kind() {
 		case reflect.Struct:
+			if w.isScalar(fv.Type()) {
+				// Parsed from text as a whole, like time.Time or netip.Prefix.
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
+				continue
+			}
 			structPrefix := prefix
 			if !ft.Anonymous {
 				// Unless it is anonymous struct, append the field name to the prefix.
@@ -72,20 +109,29 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 				}
 			}
 			// Pass the struct's tags to children
-			fs, err := walkStructWithParentTags(structPrefix, fv, ft.Tag)
+			fs, err := w.walkStructWithParentTags(structPrefix, fv, ft.Tag, loc)
 			if err != nil {
 				return nil, err
 			}
 			fields = append(fields, fs...)
 		case reflect.Map:
+			if w.isScalar(fv.Type()) {
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
+				continue
+			}
+			if w.isTextMap(fv.Type()) {
+				// The map itself is a field too, so it can be set as a
+				// whole from a value like "team=core,tier=1".
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
+			}
 			if fv.IsNil() {
 				continue
 			}
 
 			mapElemType := fv.Type().Elem()
 			elemKind := mapElemType.Kind()
-			isPtrToStruct := elemKind == reflect.Pointer && mapElemType.Elem().Kind() == reflect.Struct
-			isStruct := elemKind == reflect.Struct
+			isPtrToStruct := elemKind == reflect.Pointer && mapElemType.Elem().Kind() == reflect.Struct && !w.isScalar(mapElemType)
+			isStruct := elemKind == reflect.Struct && !w.isScalar(mapElemType)
 
 			mapPrefix := prefix
 			if mapPrefix == "" {
@@ -111,7 +157,7 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 					addressableVal := reflect.New(mapElemType).Elem()
 					addressableVal.Set(val)
 
-					fs, err := walkStructWithParentTags(keyPrefix, addressableVal, ft.Tag)
+					fs, err := w.walkStructWithParentTags(keyPrefix, addressableVal, ft.Tag, location{})
 					if err != nil {
 						return nil, err
 					}
@@ -141,7 +187,7 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 					addressableVal := reflect.New(mapElemType.Elem())
 					addressableVal.Elem().Set(val.Elem())
 
-					fs, err := walkStructWithParentTags(keyPrefix, addressableVal.Elem(), ft.Tag)
+					fs, err := w.walkStructWithParentTags(keyPrefix, addressableVal.Elem(), ft.Tag, location{})
 					if err != nil {
 						return nil, err
 					}
@@ -168,7 +214,7 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 					addressableVal := reflect.New(mapElemType).Elem()
 					addressableVal.Set(val)
 
-					f := newMapEntryField(keyPrefix, ft, addressableVal, parentTags)
+					f := w.newMapEntryField(keyPrefix, ft, addressableVal, parentTags)
 					mapValue := fv
 					mapKey := key
 					syncVal := addressableVal
@@ -188,8 +234,8 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 			if elemType.Kind() == reflect.Pointer {
 				elemType = elemType.Elem()
 			}
-			if elemType.Kind() != reflect.Struct {
-				fields = append(fields, newScalarField(prefix, ft, fv, parentTags))
+			if elemType.Kind() != reflect.Struct || w.isScalar(sliceElemType) || w.isScalar(fv.Type()) {
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
 				continue
 			}
 
@@ -217,14 +263,93 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 				// returned Field values persist in place, so no sync callback is
 				// needed (unlike map values).
 				indexPrefix := slicePrefix + "." + strconv.Itoa(i)
-				fs, err := walkStructWithParentTags(indexPrefix, elemVal, ft.Tag)
+				fs, err := w.walkStructWithParentTags(indexPrefix, elemVal, ft.Tag, location{})
 				if err != nil {
 					return nil, err
 				}
 				fields = append(fields, fs...)
 			}
+		case reflect.Array:
+			// Arrays of structs are walked like slices of structs, with one
+			// set of fields per element; other arrays are a single Field
+			// set from a list of exactly as many values.
+			arrayElemType := fv.Type().Elem()
+			elemType := arrayElemType
+			if elemType.Kind() == reflect.Pointer {
+				elemType = elemType.Elem()
+			}
+			if elemType.Kind() != reflect.Struct || w.isScalar(arrayElemType) || w.isScalar(fv.Type()) {
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
+				continue
+			}
+
+			arrayPrefix := prefix
+			if arrayPrefix == "" {
+				arrayPrefix = ft.Name
+			} else {
+				arrayPrefix = arrayPrefix + "." + ft.Name
+			}
+
+			for i := 0; i < fv.Len(); i++ {
+				elemVal := fv.Index(i)
+				if elemVal.Kind() == reflect.Pointer {
+					if elemVal.IsNil() {
+						continue
+					}
+					elemVal = elemVal.Elem()
+				}
+
+				indexPrefix := arrayPrefix + "." + strconv.Itoa(i)
+				fs, err := w.walkStructWithParentTags(indexPrefix, elemVal, ft.Tag, loc.child(i))
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, fs...)
+			}
+		case reflect.Pointer:
+			if !w.isSection(ft) {
+				fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
+				continue
+			}
+
+			structPrefix := prefix
+			if !ft.Anonymous {
+				if structPrefix == "" {
+					structPrefix = ft.Name
+				} else {
+					structPrefix = structPrefix + "." + ft.Name
+				}
+			}
+			if !fv.IsNil() {
+				fs, err := w.walkStructWithParentTags(structPrefix, fv.Elem(), ft.Tag, location{})
+				if err != nil {
+					return nil, err
+				}
+				fields = append(fields, fs...)
+				continue
+			}
+
+			// A nil section still exposes its fields, bound to a scratch
+			// struct which the first field set installs.
+			s := &section{
+				w:       w,
+				parent:  at.section,
+				scratch: reflect.New(ft.Type.Elem()),
+				prefix:  structPrefix,
+				tag:     ft.Tag,
+			}
+			if s.parent == nil {
+				s.pointer = fv
+			} else {
+				s.index = loc.index
+			}
+			fs, err := w.walkStructWithParentTags(structPrefix, s.scratch.Elem(), ft.Tag, location{section: s})
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, fs...)
 		default:
-			fields = append(fields, newScalarField(prefix, ft, fv, parentTags))
+			fields = append(fields, w.newScalarField(prefix, ft, fv, parentTags, loc))
 		}
 	}
 
@@ -236,7 +361,7 @@ func walkStructWithParentTags(prefix string, rs reflect.Value, parentTags reflec
 // the field name so EnvName() formats it as "TAGS_FOO" via SplitNameByWords.
 // Tags from the parent map field (e.g. `env:"TAGS"`) are exposed through
 // ParentTag() so the env plugin can build a custom prefix.
-func newMapEntryField(name string, ft reflect.StructField, fv reflect.Value, _ reflect.StructTag) *field {
+func (w viewer) newMapEntryField(name string, ft reflect.StructField, fv reflect.Value, _ reflect.StructTag) *field {
 	return &field{
 		name:      name,
 		meta:      make(map[string]string, 5),
@@ -244,10 +369,11 @@ func newMapEntryField(name string, ft reflect.StructField, fv reflect.Value, _ r
 		parentTag: ft.Tag,
 		field:     fv,
 		fieldType: ft,
+		w:         w,
 	}
 }
 
-func newScalarField(prefix string, ft reflect.StructField, fv reflect.Value, parentTags reflect.StructTag) Field {
+func (w viewer) newScalarField(prefix string, ft reflect.StructField, fv reflect.Value, parentTags reflect.StructTag, at location) Field {
 	fieldName := ft.Name
 
 	// unless it is override
@@ -266,6 +392,9 @@ func newScalarField(prefix string, ft reflect.StructField, fv reflect.Value, par
 		parentTag: parentTags,
 		field:     fv,
 		fieldType: ft,
+		w:         w,
+		section:   at.section,
+		index:     at.index,
 	}
 }
 
@@ -1,6 +1,8 @@
 package flat_test
 
 import (
+	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -102,15 +104,18 @@ func TestFlattenPrimitiveMap(t *testing.T) {
 	gotPaths := map[string]string{}
 	gotEnv := map[string]string{}
 	for _, fld := range fs {
-		gotPaths[fld.Name()] = fld.FieldValue().String()
+		gotPaths[fld.Name()] = fmt.Sprint(fld.FieldValue())
 		gotEnv[fld.Name()] = fld.EnvName()
 	}
 
+	// The map itself is a field next to one field per entry.
 	wantPaths := map[string]string{
+		"Tags":     "map[BAR:v2 FOO:v1]",
 		"Tags.FOO": "v1",
 		"Tags.BAR": "v2",
 	}
 	wantEnv := map[string]string{
+		"Tags":     "TAGS",
 		"Tags.FOO": "TAGS_FOO",
 		"Tags.BAR": "TAGS_BAR",
 	}
@@ -215,3 +220,181 @@ func TestFlattenTypes(t *testing.T) {
 
 	testutil.Equal(t, expect, value)
 }
+
+type sectionTLS struct {
+	Cert string
+}
+
+type sectionSentry struct {
+	DSN  string
+	Rate float64 `default:"0.5"`
+	TLS  *sectionTLS
+}
+
+type sectionKafka struct {
+	Brokers string `default:"localhost:9092"`
+}
+
+type sectionConfig struct {
+	Sentry *sectionSentry
+	Kafka  *sectionKafka `alloc:"always"`
+}
+
+func TestFlattenOptionalSections(t *testing.T) {
+	t.Parallel()
+
+	conf := sectionConfig{}
+	first, err := flat.View(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := flat.View(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := make([]string, 0, len(first))
+	byName := map[string]flat.Field{}
+	for _, fld := range first {
+		names = append(names, fld.Name()+"="+fld.EnvName())
+		byName[fld.Name()] = fld
+		if !flat.Unallocated(fld) {
+			t.Errorf("Unallocated(%s) = false for a nil section", fld.Name())
+		}
+	}
+	testutil.Equal(t, []string{
+		"Sentry.DSN=SENTRY_DSN",
+		"Sentry.Rate=SENTRY_RATE",
+		"Sentry.TLS.Cert=SENTRY_TLS_CERT",
+		"Kafka.Brokers=KAFKA_BROKERS",
+	}, names)
+	testutil.Equal(t, true, flat.DefaultDeferred(byName["Sentry.Rate"]))
+	testutil.Equal(t, false, flat.DefaultDeferred(byName["Kafka.Brokers"]))
+	if conf.Sentry != nil || conf.Kafka != nil {
+		t.Fatalf("View() allocated sections: %+v", conf)
+	}
+
+	// Setting a field allocates its section with the defaults of the others.
+	changed, err := second[0].SetChanged("")
+	if err != nil {
+		t.Fatalf("SetChanged() error = %v", err)
+	}
+	testutil.Equal(t, true, changed)
+	testutil.Equal(t, sectionSentry{Rate: 0.5}, *conf.Sentry)
+
+	// Fields viewed before the allocation follow the allocated section.
+	if err := byName["Sentry.DSN"].Set("https://sentry"); err != nil {
+		t.Fatal(err)
+	}
+	if err := byName["Sentry.TLS.Cert"].Set("cert.pem"); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, sectionSentry{DSN: "https://sentry", Rate: 0.5, TLS: &sectionTLS{Cert: "cert.pem"}}, *conf.Sentry)
+	testutil.Equal(t, false, flat.Unallocated(byName["Sentry.Rate"]))
+	testutil.Equal(t, "cert.pem", byName["Sentry.TLS.Cert"].FieldValue().Interface())
+
+	if conf.Kafka != nil {
+		t.Fatal("Kafka allocated by another section")
+	}
+}
+
+func TestFlattenOptionalSectionsWithSectionDefaults(t *testing.T) {
+	t.Parallel()
+
+	conf := sectionConfig{}
+	var applied []string
+	fields, err := flat.View(&conf, flat.WithSectionDefaults(func(f flat.Field) error {
+		applied = append(applied, f.Name())
+		return f.Set("0.25")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The option sets the defaults in place of the default tags, and not the
+	// field allocating the section.
+	if err := fields[0].Set("https://sentry"); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []string{"Sentry.Rate"}, applied)
+	testutil.Equal(t, sectionSentry{DSN: "https://sentry", Rate: 0.25}, *conf.Sentry)
+}
+
+type recursiveNode struct {
+	Name string
+	Next *recursiveNode
+}
+
+func TestFlattenRecursiveSection(t *testing.T) {
+	t.Parallel()
+
+	// A section pointing back to a struct being walked is a field of its
+	// own, whether it is nil or not.
+	conf := struct {
+		Head *recursiveNode
+	}{}
+	for _, head := range []*recursiveNode{nil, {Name: "a", Next: &recursiveNode{Name: "b"}}} {
+		conf.Head = head
+		fs, err := flat.View(&conf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := make([]string, 0, len(fs))
+		for _, fld := range fs {
+			names = append(names, fld.Name())
+		}
+		testutil.Equal(t, []string{"Head.Name", "Head.Next"}, names)
+	}
+
+	nameMap, err := flat.ExpandContainersFromKeys(&recursiveNode{}, "", []string{"NAME", "NEXT_NAME"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, map[string]string{"Name": "NAME", "Next": "NEXT"}, nameMap)
+}
+
+type arrayShard struct {
+	Host string
+	Port int `env:"P"`
+}
+
+func TestFlattenStructArrays(t *testing.T) {
+	t.Parallel()
+
+	conf := struct {
+		Shards   [2]arrayShard
+		Replicas [2]*arrayShard
+		Zones    [3]string
+	}{}
+	conf.Replicas[1] = &arrayShard{}
+
+	fs, err := flat.View(&conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := make([]string, 0, len(fs))
+	for _, fld := range fs {
+		names = append(names, fld.Name()+"="+fld.EnvName())
+	}
+	testutil.Equal(t, []string{
+		"Shards.0.Host=SHARDS_0_HOST",
+		"Shards.0.Port=SHARDS_0_PORT",
+		"Shards.1.Host=SHARDS_1_HOST",
+		"Shards.1.Port=SHARDS_1_PORT",
+		"Replicas.1.Host=REPLICAS_1_HOST",
+		"Replicas.1.Port=REPLICAS_1_PORT",
+		"Zones=ZONES",
+	}, names)
+
+	envNames, err := flat.ExpandContainersFromKeys(&conf, "", []string{"SHARDS_1_P"})
+	if err != nil {
+		t.Fatalf("ExpandContainersFromKeys() error = %v", err)
+	}
+	testutil.Equal(t, "SHARDS_1_P", envNames["Shards.1.Port"])
+	testutil.Equal(t, "REPLICAS_0_HOST", envNames["Replicas.0.Host"])
+
+	_, err = flat.ExpandContainersFromKeys(&conf, "", []string{"SHARDS_2_HOST"})
+	if !errors.Is(err, flat.ErrArrayIndex) {
+		t.Fatalf("ExpandContainersFromKeys() error = %v, want ErrArrayIndex", err)
+	}
+}
@@ -0,0 +1,131 @@
+package flat
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Tags changing how a slice or map field is parsed from a single value such
+// as "team=core,tier=1".
+const (
+	// separatorTag separates slice elements and map entries, "," by default.
+	separatorTag = "xconfig_sep"
+	// keyValueSeparatorTag separates the key from the value of a map entry,
+	// "=" by default.
+	keyValueSeparatorTag = "xconfig_kvsep"
+)
+
+func (f *field) separator() string {
+	if sep, ok := f.tag.Lookup(separatorTag); ok && sep != "" {
+		return sep
+	}
+	return ","
+}
+
+func (f *field) keyValueSeparator() string {
+	if sep, ok := f.tag.Lookup(keyValueSeparatorTag); ok && sep != "" {
+		return sep
+	}
+	return "="
+}
+
+// setMap replaces the entries of a map field with the key/value pairs of
+// value. Every pair is parsed before the field is touched, so a rejected pair
+// leaves the map unchanged. The map is updated in place, which keeps the
+// fields of its entries bound to it.
+func (f *field) setMap(value string) (bool, error) {
+	t := f.field.Type()
+	setKey, setElem := f.w.textSetter(t.Key()), f.w.textSetter(t.Elem())
+	if setKey == nil || setElem == nil {
+		return false, fmt.Errorf("field %s: cannot set %s from text", f.name, t)
+	}
+
+	candidate := reflect.MakeMap(t)
+	kvSep := f.keyValueSeparator()
+	for i, pair := range strings.Split(value, f.separator()) {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		rawKey, rawElem, ok := strings.Cut(pair, kvSep)
+		if !ok {
+			return false, fmt.Errorf("map entry %d: missing %q between key and value", i+1, kvSep)
+		}
+		key := reflect.New(t.Key()).Elem()
+		if err := setKey(key, strings.TrimSpace(rawKey)); err != nil {
+			return false, fmt.Errorf("map entry %d key: %w", i+1, err)
+		}
+		elem := reflect.New(t.Elem()).Elem()
+		if err := setElem(elem, strings.TrimSpace(rawElem)); err != nil {
+			return false, fmt.Errorf("map entry %d value: %w", i+1, err)
+		}
+		candidate.SetMapIndex(key, elem)
+	}
+
+	if f.field.Len() == 0 && candidate.Len() == 0 {
+		return false, nil
+	}
+	if !f.field.IsNil() && equalIgnoringFuncs(f.field, candidate) {
+		return false, nil
+	}
+
+	if f.field.IsNil() {
+		f.field.Set(candidate)
+		return true, nil
+	}
+	f.field.Clear()
+	iter := candidate.MapRange()
+	for iter.Next() {
+		f.field.SetMapIndex(iter.Key(), iter.Value())
+	}
+	return true, nil
+}
+
+// isTextMap reports whether a map of type t can be set from a single value,
+// which requires both its keys and its values to be parsable from text.
+func (w viewer) isTextMap(t reflect.Type) bool {
+	return w.textSetter(t.Key()) != nil && w.textSetter(t.Elem()) != nil
+}
+
+// textSetter returns a function parsing text into an addressable value of
+// type t, or nil when t cannot be parsed from text. Converters, then types
+// implementing encoding.TextUnmarshaler, take precedence over the underlying
+// kind.
+func (w viewer) textSetter(t reflect.Type) func(reflect.Value, string) error {
+	if set := w.converter(t); set != nil {
+		return set
+	}
+
+	switch {
+	case t.Kind() == reflect.Pointer && t.Implements(textUnmarshalerType):
+		return func(v reflect.Value, s string) error {
+			candidate := reflect.New(t.Elem())
+			if err := callUnmarshalText(candidate, []byte(s)); err != nil {
+				return err
+			}
+			v.Set(candidate)
+			return nil
+		}
+	case t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(textUnmarshalerType):
+		if t.Implements(textUnmarshalerType) {
+			// A value receiver only ever unmarshals into a copy.
+			return nil
+		}
+		return func(v reflect.Value, s string) error {
+			return callUnmarshalText(v.Addr(), []byte(s))
+		}
+	case t.Kind() == reflect.Bool:
+		return func(v reflect.Value, s string) error {
+			b, err := strconv.ParseBool(s)
+			if err != nil {
+				return err
+			}
+			v.SetBool(b)
+			return nil
+		}
+	default:
+		return setSliceElem(t)
+	}
+}
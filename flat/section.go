@@ -0,0 +1,174 @@
+package flat
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sxwebdev/xconfig/internal/interpolate"
+)
+
+// allocTag chooses when an optional section is allocated. By default a section
+// is allocated once a source sets one of its fields; alloc:"always" lets the
+// default of one of its fields allocate it as well.
+const allocTag = "alloc"
+
+// section is an optional part of the configuration: a pointer-to-struct field
+// that was nil when it was viewed. Its fields are bound to a scratch struct
+// until one of them is set, which allocates the section with that struct.
+type section struct {
+	w viewer
+	// parent is the section the pointer field itself lives in, if any.
+	parent *section
+	// pointer is the pointer field when parent is nil, and index locates it
+	// in the struct of parent otherwise.
+	pointer reflect.Value
+	index   []int
+
+	scratch reflect.Value
+	prefix  string
+	tag     reflect.StructTag
+}
+
+// isSection reports whether the struct field ft is walked as an optional
+// section rather than set as a whole. Shared dependencies, types parsed from
+// text and sections pointing back to a struct being walked, such as the Next
+// field of a linked list node, are values of their own.
+func (w viewer) isSection(ft reflect.StructField) bool {
+	t := ft.Type
+	if t.Kind() != reflect.Pointer || t.Elem().Kind() != reflect.Struct {
+		return false
+	}
+	if ft.Tag.Get("xconfig_shared") == "true" || slices.Contains(w.walking, t.Elem()) {
+		return false
+	}
+	return !w.isScalar(t)
+}
+
+// ptr returns the pointer field of the section where it currently lives.
+func (s *section) ptr() reflect.Value {
+	if s.parent == nil {
+		return s.pointer
+	}
+	return valueAt(s.parent.elem(), s.index)
+}
+
+// elem returns the struct holding the fields of the section: the allocated
+// one, possibly allocated through another view, or the scratch struct.
+func (s *section) elem() reflect.Value {
+	if p := s.ptr(); !p.IsNil() {
+		return p.Elem()
+	}
+	return s.scratch.Elem()
+}
+
+func (s *section) allocated() bool {
+	return !s.ptr().IsNil()
+}
+
+// allocate installs the scratch struct, allocating the parent sections first,
+// and reports whether anything was allocated. The defaults of the fields the
+// defaults plugins had to skip while the section was nil are applied, except
+// for the field named trigger whose value allocated it, with the
+// WithSectionDefaults option of the view or else their default tags, leaving
+// those referencing other fields to computed defaults when enabled.
+func (s *section) allocate(trigger string) (bool, error) {
+	allocated := false
+	if s.parent != nil {
+		var err error
+		if allocated, err = s.parent.allocate(trigger); err != nil {
+			return allocated, err
+		}
+	}
+	p := s.ptr()
+	if !p.IsNil() {
+		return allocated, nil
+	}
+	p.Set(s.scratch)
+	s.scratch = reflect.New(s.scratch.Type().Elem())
+
+	fields, err := s.w.walkStructWithParentTags(s.prefix, p.Elem(), s.tag, location{})
+	if err != nil {
+		return true, err
+	}
+	for _, f := range fields {
+		if f.Name() == trigger || Unallocated(f) || !f.IsZero() {
+			continue
+		}
+		if s.w.sectionDefault != nil {
+			if err := s.w.sectionDefault(f); err != nil {
+				return true, fmt.Errorf("field %s: default: %w", f.Name(), err)
+			}
+			continue
+		}
+		value, ok := f.Tag("default")
+		if !ok || s.w.computedDefaults && interpolate.HasReferences(value) {
+			continue
+		}
+		if err := f.Set(value); err != nil {
+			return true, fmt.Errorf("field %s: default: %w", f.Name(), err)
+		}
+	}
+	return true, nil
+}
+
+// valueAt follows index from v, through struct fields and array elements.
+func valueAt(v reflect.Value, index []int) reflect.Value {
+	for _, i := range index {
+		if v.Kind() == reflect.Array {
+			v = v.Index(i)
+		} else {
+			v = v.Field(i)
+		}
+	}
+	return v
+}
+
+// location tells where a walked struct lives: in live memory, or in the
+// scratch struct of a section at index.
+type location struct {
+	section *section
+	index   []int
+}
+
+// child returns the location of the i-th field of the struct, or the i-th
+// element of the array, at l.
+func (l location) child(i int) location {
+	if l.section == nil {
+		return location{}
+	}
+	return location{section: l.section, index: append(append([]int(nil), l.index...), i)}
+}
+
+// Unallocated reports whether f belongs to an optional section, a nil
+// pointer-to-struct field, that is still nil. Setting f allocates the section,
+// so plugins checking values such as required skip these fields.
+func Unallocated(f Field) bool {
+	ff, ok := f.(*field)
+	if !ok {
+		return false
+	}
+	for s := ff.section; s != nil; s = s.parent {
+		if !s.allocated() {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultDeferred reports whether the default of f waits for its optional
+// section to be allocated by another source. Defaults plugins skip these
+// fields; their defaults are applied when the section is allocated. Sections
+// tagged alloc:"always" are allocated by their defaults instead.
+func DefaultDeferred(f Field) bool {
+	ff, ok := f.(*field)
+	if !ok {
+		return false
+	}
+	for s := ff.section; s != nil; s = s.parent {
+		if !s.allocated() && s.tag.Get(allocTag) != "always" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+// Package interpolate expands the shell-style ${VAR} references shared by
+// file interpolation and computed defaults.
+package interpolate
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Lookup returns the value of the named variable and whether it is set.
+type Lookup func(name string) (string, bool)
+
+// Failure describes a reference that could not be expanded.
+type Failure struct {
+	// Ref is the reference text, such as ${DB_HOST:?required}.
+	Ref string
+	// Name is the referenced variable, or Ref when it is malformed.
+	Name string
+	// Reason is the message of a ${VAR:?message} reference, or why the
+	// reference could not be expanded.
+	Reason string
+}
+
+// Expand replaces the references of s:
+//
+//	${VAR}          the value of VAR, a failure when VAR is not set
+//	${VAR:-word}    word when VAR is not set or empty
+//	${VAR-word}     word when VAR is not set
+//	${VAR:?message} a failure with message when VAR is not set or empty
+//	${VAR?message}  a failure with message when VAR is not set
+//	$$              a literal $
+//
+// Words and messages may hold references themselves. Names consist of
+// letters, digits, underscores and dots. A $ not followed by { or $ is kept.
+// Expand returns the failures of every reference it could not expand.
+func Expand(s string, lookup Lookup) (string, []Failure) {
+	var (
+		out      strings.Builder
+		failures []Failure
+	)
+	for {
+		i := strings.IndexByte(s, '$')
+		if i < 0 {
+			out.WriteString(s)
+			return out.String(), failures
+		}
+		out.WriteString(s[:i])
+		s = s[i:]
+
+		switch {
+		case strings.HasPrefix(s, "$$"):
+			out.WriteByte('$')
+			s = s[2:]
+		case strings.HasPrefix(s, "${"):
+			end := closingBrace(s)
+			if end < 0 {
+				ref, _, _ := strings.Cut(s, "\n")
+				failures = append(failures, Failure{Ref: ref, Name: ref, Reason: "unterminated reference"})
+				out.WriteString(s)
+				return out.String(), failures
+			}
+			value, refFailures := resolve(s[:end+1], lookup)
+			failures = append(failures, refFailures...)
+			out.WriteString(value)
+			s = s[end+1:]
+		default:
+			out.WriteByte('$')
+			s = s[1:]
+		}
+	}
+}
+
+// HasReferences reports whether s holds a reference that is not escaped.
+func HasReferences(s string) bool {
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$$"):
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			return true
+		}
+	}
+	return false
+}
+
+// Names returns the variables s references, including those in words and
+// messages, in order of appearance.
+func Names(s string) []string {
+	var names []string
+	Expand(s, func(name string) (string, bool) {
+		names = append(names, name)
+		return "", false
+	})
+	return names
+}
+
+// closingBrace returns the index of the brace closing the reference s starts
+// with, or -1 when it is not closed.
+func closingBrace(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch {
+		case strings.HasPrefix(s[i:], "$$"):
+			i++
+		case strings.HasPrefix(s[i:], "${"):
+			depth++
+			i++
+		case s[i] == '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// resolve expands the reference ref, such as ${VAR:-word}.
+func resolve(ref string, lookup Lookup) (string, []Failure) {
+	body := ref[2 : len(ref)-1]
+	nameEnd := strings.IndexFunc(body, func(r rune) bool {
+		return !(r == '_' || r == '.' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+	if nameEnd < 0 {
+		nameEnd = len(body)
+	}
+	name, rest := body[:nameEnd], body[nameEnd:]
+
+	var op, word string
+	for _, candidate := range []string{":-", ":?", "-", "?"} {
+		if strings.HasPrefix(rest, candidate) {
+			op, word = candidate, rest[len(candidate):]
+			break
+		}
+	}
+	if name == "" || op == "" && rest != "" {
+		return "", []Failure{{Ref: ref, Name: ref, Reason: "invalid reference"}}
+	}
+
+	value, ok := lookup(name)
+	missing := !ok || strings.HasPrefix(op, ":") && value == ""
+	switch {
+	case !missing:
+		return value, nil
+	case op == ":-" || op == "-":
+		return Expand(word, lookup)
+	case op == ":?" || op == "?":
+		message, failures := Expand(word, lookup)
+		if len(failures) > 0 {
+			return "", failures
+		}
+		if message == "" {
+			message = "not set"
+		}
+		return "", []Failure{{Ref: ref, Name: name, Reason: message}}
+	default:
+		return "", []Failure{{Ref: ref, Name: name, Reason: "not set"}}
+	}
+}
+
+// Format renders a field value the way it is written in text sources, so it
+// can be referenced: text marshalers are marshaled, lists are joined with
+// commas and nil pointers render empty.
+func Format(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+		if text, err := m.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+	if v.CanAddr() {
+		if m, ok := v.Addr().Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+	}
+	if (v.Kind() == reflect.Slice || v.Kind() == reflect.Array) && v.Type().Elem().Kind() != reflect.Uint8 {
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = Format(v.Index(i))
+		}
+		return strings.Join(items, ",")
+	}
+	return fmt.Sprint(v.Interface())
+}
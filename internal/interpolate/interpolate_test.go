@@ -0,0 +1,145 @@
+package interpolate
+
+import (
+	"testing"
+
+	"github.com/sxwebdev/xconfig/internal/testutil"
+)
+
+func TestExpand(t *testing.T) {
+	vars := map[string]string{
+		"HOST":     "db",
+		"EMPTY":    "",
+		"FALLBACK": "backup",
+		"app.name": "api",
+	}
+	lookup := func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}
+
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+		failures []Failure
+	}{
+		{
+			name:     "no references",
+			input:    "plain $5 text",
+			expected: "plain $5 text",
+		},
+		{
+			name:     "set variable",
+			input:    "postgres://${HOST}/app",
+			expected: "postgres://db/app",
+		},
+		{
+			name:     "dotted name",
+			input:    "${app.name}",
+			expected: "api",
+		},
+		{
+			name:     "escaped dollar",
+			input:    "$$ and $${HOST}",
+			expected: "$ and ${HOST}",
+		},
+		{
+			name:     "default when unset",
+			input:    "${MISSING:-word}|${MISSING-word}",
+			expected: "word|word",
+		},
+		{
+			name:     "default when empty",
+			input:    "${EMPTY:-word}|${EMPTY-word}",
+			expected: "word|",
+		},
+		{
+			name:     "nested default",
+			input:    "${MISSING:-${OTHER:-${FALLBACK}}}",
+			expected: "backup",
+		},
+		{
+			name:     "nested failure in default",
+			input:    "${MISSING:-${OTHER}}",
+			failures: []Failure{{Ref: "${OTHER}", Name: "OTHER", Reason: "not set"}},
+		},
+		{
+			name:     "unset variable",
+			input:    "a${MISSING}b",
+			expected: "ab",
+			failures: []Failure{{Ref: "${MISSING}", Name: "MISSING", Reason: "not set"}},
+		},
+		{
+			name:     "required when empty",
+			input:    "${EMPTY:?host required}",
+			failures: []Failure{{Ref: "${EMPTY:?host required}", Name: "EMPTY", Reason: "host required"}},
+		},
+		{
+			name:     "required only when unset",
+			input:    "${EMPTY?host required}",
+			expected: "",
+		},
+		{
+			name:     "required message with reference",
+			input:    "${MISSING?${HOST} is down}",
+			failures: []Failure{{Ref: "${MISSING?${HOST} is down}", Name: "MISSING", Reason: "db is down"}},
+		},
+		{
+			name:     "required without message",
+			input:    "${MISSING:?}",
+			failures: []Failure{{Ref: "${MISSING:?}", Name: "MISSING", Reason: "not set"}},
+		},
+		{
+			name:     "invalid name",
+			input:    "${-bad}",
+			failures: []Failure{{Ref: "${-bad}", Name: "${-bad}", Reason: "invalid reference"}},
+		},
+		{
+			name:     "invalid operator",
+			input:    "${HOST+word}",
+			failures: []Failure{{Ref: "${HOST+word}", Name: "${HOST+word}", Reason: "invalid reference"}},
+		},
+		{
+			name:     "empty reference",
+			input:    "${}",
+			failures: []Failure{{Ref: "${}", Name: "${}", Reason: "invalid reference"}},
+		},
+		{
+			name:     "unterminated reference",
+			input:    "x ${HOST",
+			expected: "x ${HOST",
+			failures: []Failure{{Ref: "${HOST", Name: "${HOST", Reason: "unterminated reference"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, failures := Expand(tt.input, lookup)
+			if len(tt.failures) == 0 {
+				testutil.Equal(t, tt.expected, got)
+			}
+			testutil.Equal(t, tt.failures, failures)
+		})
+	}
+}
+
+func TestHasReferences(t *testing.T) {
+	for input, expected := range map[string]bool{
+		"":             false,
+		"$5":           false,
+		"$${HOST}":     false,
+		"$$$${HOST}":   false,
+		"${HOST}":      true,
+		"$$${HOST}":    true,
+		"a ${HOST":     true,
+		"${Name}-host": true,
+	} {
+		testutil.Equal(t, expected, HasReferences(input))
+	}
+}
+
+func TestNames(t *testing.T) {
+	testutil.Equal(t, []string{"A", "B", "C", "D", "C"}, Names("${A} ${B:-${C}} $${E} ${D:?${C}}"))
+	testutil.Equal(t, []string(nil), Names("no references"))
+}
@@ -0,0 +1,122 @@
+package utils
+
+import (
+	"reflect"
+	"strings"
+)
+
+// ConfigPath translates a flat field name (e.g. "Indexers.bsc.Parser.Enabled")
+// into the key path a configuration file uses for it, naming each field by
+// its FileKey (e.g. "indexers.bsc.Parser.Enabled" under a yaml:"indexers"
+// tag). Decoders match untagged fields case-insensitively, and so should
+// callers comparing the path with the keys of a file.
+func ConfigPath(conf any, flatName string) (string, bool) {
+	t := reflect.TypeOf(conf)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", false
+	}
+
+	segments := strings.Split(flatName, ".")
+	pathParts := make([]string, 0, len(segments))
+
+	cur := t
+	for _, seg := range segments {
+		for cur.Kind() == reflect.Pointer {
+			cur = cur.Elem()
+		}
+
+		switch cur.Kind() {
+		case reflect.Struct:
+			sf, ok := findFieldByFlatSegment(cur, seg)
+			if !ok {
+				return "", false
+			}
+			name, ok := FileKey(sf)
+			if !ok {
+				return "", false
+			}
+			pathParts = append(pathParts, name)
+			cur = sf.Type
+		case reflect.Map:
+			// Map keys are dynamic and appear in flat field names as-is.
+			pathParts = append(pathParts, seg)
+			cur = cur.Elem()
+		case reflect.Slice, reflect.Array:
+			// Slice indices appear as numeric segments in flat field names.
+			pathParts = append(pathParts, seg)
+			cur = cur.Elem()
+		default:
+			return "", false
+		}
+	}
+
+	return strings.Join(pathParts, "."), true
+}
+
+func findFieldByFlatSegment(structType reflect.Type, seg string) (reflect.StructField, bool) {
+	// Fast path: exact Go field name.
+	if sf, ok := structType.FieldByName(seg); ok {
+		return sf, true
+	}
+
+	// Otherwise, try to match by tags. This matters when flat names were overridden
+	// with `xconfig:"..."` (often snake_case), because FieldByName() won't find it.
+	for i := 0; i < structType.NumField(); i++ {
+		sf := structType.Field(i)
+		if !sf.IsExported() {
+			continue
+		}
+
+		if xname, ok := sf.Tag.Lookup("xconfig"); ok && xname == seg {
+			return sf, true
+		}
+
+		if name, ok := fileTag(sf); ok && name == seg {
+			return sf, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// HoldsPath reports whether one of the key paths of present is configPath or
+// lies within it, such as Hosts.0 for a list of strings or Labels.tier for a
+// map, matched case-insensitively.
+func HoldsPath(present map[string]struct{}, configPath string) bool {
+	for p := range present {
+		if len(p) < len(configPath) || !strings.EqualFold(p[:len(configPath)], configPath) {
+			continue
+		}
+		if len(p) == len(configPath) || p[len(configPath)] == '.' {
+			return true
+		}
+	}
+	return false
+}
+
+// FileKey returns the key a configuration file uses for field: the name in
+// its yaml, json, toml or hcl tag, the first of them that names it, or else
+// its Go name. It reports false for fields tagged "-".
+func FileKey(field reflect.StructField) (string, bool) {
+	if name, ok := fileTag(field); ok {
+		if name == "-" {
+			return "", false
+		}
+		return name, true
+	}
+	return field.Name, true
+}
+
+// fileTag returns the name in the first of the yaml, json, toml and hcl tags
+// of field that names it, "-" included, and whether there is one.
+func fileTag(field reflect.StructField) (string, bool) {
+	for _, tag := range []string{"yaml", "json", "toml", "hcl"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(tag), ","); name != "" {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,109 @@
+package utils
+
+import (
+	"reflect"
+	"testing"
+)
+
+type configPathDatabase struct {
+	Host     string `yaml:"db_host"`
+	Port     int
+	Password string `xconfig:"secret"`
+	Ignored  string `json:"-"`
+}
+
+type configPathConfig struct {
+	Database configPathDatabase `yaml:"database"`
+	Nodes    []configPathDatabase
+	Labels   map[string]string
+}
+
+func TestConfigPath(t *testing.T) {
+	tests := []struct {
+		name     string
+		flatName string
+		wantPath string
+		wantOK   bool
+	}{
+		{
+			name:     "file tag",
+			flatName: "Database.Host",
+			wantPath: "database.db_host",
+			wantOK:   true,
+		},
+		{
+			name:     "untagged field keeps its Go name",
+			flatName: "Database.Port",
+			wantPath: "database.Port",
+			wantOK:   true,
+		},
+		{
+			name:     "xconfig tag renames the flat name only",
+			flatName: "Database.secret",
+			wantPath: "database.Password",
+			wantOK:   true,
+		},
+		{
+			name:     "field skipped by files",
+			flatName: "Database.Ignored",
+			wantOK:   false,
+		},
+		{
+			name:     "slice element",
+			flatName: "Nodes.1.Host",
+			wantPath: "Nodes.1.db_host",
+			wantOK:   true,
+		},
+		{
+			name:     "map entry",
+			flatName: "Labels.tier",
+			wantPath: "Labels.tier",
+			wantOK:   true,
+		},
+		{
+			name:     "unknown field",
+			flatName: "Database.Missing",
+			wantOK:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, ok := ConfigPath(&configPathConfig{}, tt.flatName)
+			if ok != tt.wantOK || path != tt.wantPath {
+				t.Errorf("ConfigPath(%q) = %q, %v, want %q, %v", tt.flatName, path, ok, tt.wantPath, tt.wantOK)
+			}
+		})
+	}
+
+	// ConfigPath names fields like FileKey does.
+	field, _ := reflect.TypeFor[configPathDatabase]().FieldByName("Host")
+	if key, _ := FileKey(field); key != "db_host" {
+		t.Errorf("FileKey(Host) = %q, want db_host", key)
+	}
+}
+
+func TestHoldsPath(t *testing.T) {
+	present := map[string]struct{}{
+		"database.DB_HOST": {},
+		"labels.tier":      {},
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "database.db_host", want: true},
+		{path: "Labels", want: true},
+		{path: "labels.tier", want: true},
+		{path: "database", want: true},
+		{path: "database.db", want: false},
+		{path: "database.Port", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := HoldsPath(present, tt.path); got != tt.want {
+			t.Errorf("HoldsPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,17 +1,21 @@
 package xconfig
 
 import (
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/plugins"
 	"github.com/sxwebdev/xconfig/plugins/customdefaults"
 	"github.com/sxwebdev/xconfig/plugins/defaults"
 	"github.com/sxwebdev/xconfig/plugins/env"
+	"github.com/sxwebdev/xconfig/plugins/filecontent"
 	"github.com/sxwebdev/xconfig/plugins/flag"
 	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/plugins/required"
 )
 
 // Load creates a xconfig manager with defaults, environment variables,
 // and flags (in that order) and optionally file loaders based on the provided
-// Files map and parses them right away.
+// Files map and parses them right away. Parsing fails with a
+// *required.MissingFieldsError when a field tagged required is left unset.
 func Load(conf any, opts ...Option) (Config, error) {
 	return load(conf, opts...)
 }
@@ -37,11 +41,38 @@ func loadConfig(conf any, publishSnapshot bool, opts ...Option) (*config, error)
 		o.loader.DisallowUnknownFields(true)
 	}
 
+	if o.loader != nil && o.watchFiles {
+		o.loader.WatchFiles(o.watchDebounce)
+	}
+
+	if o.loader != nil && o.mergeStrategy != "" {
+		o.loader.Merge(o.mergeStrategy)
+	}
+
+	if o.withProfiles {
+		o.activeProfiles = o.resolveProfiles()
+		if o.loader != nil {
+			o.loader.Profiles(o.activeProfiles...)
+		}
+	}
+
+	if o.loader != nil && o.interpolate {
+		resolver := o.resolver
+		if resolver == nil && o.envSource != nil {
+			resolver = loader.ResolverFunc(func(name string, _ any) (string, bool) {
+				return o.envSource.LookupEnv(name)
+			})
+		} else if resolver == nil {
+			resolver = loader.EnvResolver()
+		}
+		o.loader.Interpolate(resolver)
+	}
+
 	ps := make([]plugins.Plugin, 0)
 
 	// Register default metadata early for usage/documentation
 	if !o.skipDefaults {
-		ps = append(ps, defaults.NewMetaOnly())
+		ps = append(ps, defaults.NewMetaOnly(o.activeProfiles...))
 	}
 
 	if !o.skipCustomDefaults {
@@ -49,17 +80,30 @@ func loadConfig(conf any, publishSnapshot bool, opts ...Option) (*config, error)
 	}
 
 	if !o.skipFiles {
+		// Files merged again on refresh are filled with the defaults the
+		// way NewWithRescan fills them below, so a key removed from a file
+		// takes its default again.
+		if !o.skipDefaults {
+			profiles := o.activeProfiles
+			o.loader.Fallback(func(conf any, present map[string]struct{}, opts []flat.Option) error {
+				return defaults.Fill(conf, present, opts, profiles...)
+			})
+		}
 		ps = append(ps, o.loader.Plugins()...)
 	}
 
 	// Apply defaults after loading files to fill in zero values including those in loaded maps
 	// Use NewWithRescan to rescan the structure and find fields in maps that were created during loading
 	if !o.skipDefaults {
-		ps = append(ps, defaults.NewWithRescan(o.loader))
+		ps = append(ps, defaults.NewWithRescan(o.loader, o.activeProfiles...))
 	}
 
 	if !o.skipEnv {
-		ps = append(ps, env.New(o.envPrefix))
+		if o.envSource != nil {
+			ps = append(ps, env.NewWithSource(o.envPrefix, o.envSource))
+		} else {
+			ps = append(ps, env.New(o.envPrefix))
+		}
 	}
 
 	if !o.skipFlags {
@@ -70,7 +114,32 @@ func loadConfig(conf any, publishSnapshot bool, opts ...Option) (*config, error)
 		ps = append(ps, o.plugins...)
 	}
 
-	c, err := newConfig(conf, ps...)
+	// Compute the defaults referencing other fields once those are set.
+	if !o.skipDefaults && o.computedDefaults && publishSnapshot && defaults.UsesReferences(conf, o.activeProfiles...) {
+		ps = append(ps, defaults.NewComputed(o.loader, o.activeProfiles...))
+	}
+
+	// Read the files of types.FileContent fields once their paths are set.
+	// Documentation only shows the paths, which need not exist where it is
+	// generated.
+	if publishSnapshot && filecontent.Uses(conf) {
+		ps = append(ps, filecontent.New())
+	}
+
+	// Enforce required fields once every source had its chance to set them.
+	// Documentation only describes the fields, so it does not need them set.
+	if !o.skipRequired && publishSnapshot {
+		ps = append(ps, required.New(o.envPrefix))
+	}
+
+	viewOptions := []flat.Option{flat.WithConverters(o.converters...)}
+	if o.computedDefaults {
+		// Documentation does not compute the defaults, but still leaves them
+		// unset.
+		viewOptions = append(viewOptions, flat.WithComputedDefaults())
+	}
+
+	c, err := newConfig(conf, viewOptions, ps...)
 	if err != nil {
 		return c, err
 	}
@@ -1,16 +1,25 @@
 package xconfig_test
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
 	"testing"
 
 	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/internal/f"
 	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/env"
 	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/plugins/required"
 	"github.com/sxwebdev/xconfig/plugins/secret"
 )
 
@@ -156,3 +165,387 @@ func TestClassicBadPlugin(t *testing.T) {
 		t.Errorf("Expected unsupported plugin error, got: %v", err)
 	}
 }
+
+func TestLoadEnforcesRequired(t *testing.T) {
+	type Config struct {
+		Password string `required:"true" secret:"DB_PASSWORD"`
+	}
+
+	_, err := xconfig.Load(&Config{}, xconfig.WithSkipFlags())
+	var missingErr *required.MissingFieldsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("Load() error = %v, want *required.MissingFieldsError", err)
+	}
+	testutil.Equal(t, []required.MissingField{{Name: "Password", Env: "PASSWORD"}}, missingErr.Fields)
+
+	if _, err := xconfig.Load(&Config{}, xconfig.WithSkipFlags(), xconfig.WithSkipRequired()); err != nil {
+		t.Fatalf("Load() with WithSkipRequired error = %v", err)
+	}
+
+	// Secrets, Vault and other user plugins run before the check.
+	_, err = xconfig.Load(&Config{}, xconfig.WithSkipFlags(), xconfig.WithPlugins(secret.New(func(string) (string, error) {
+		return "from-secret", nil
+	})))
+	if err != nil {
+		t.Fatalf("Load() with a plugin setting the field error = %v", err)
+	}
+}
+
+type optionalSentry struct {
+	DSN         string `required:"true"`
+	Environment string `default:"production"`
+}
+
+type optionalKafka struct {
+	Brokers []string `required:"true"`
+	Topic   string   `default:"events"`
+}
+
+type optionalConfig struct {
+	Sentry *optionalSentry
+	Kafka  *optionalKafka
+}
+
+func TestLoadOptionalSections(t *testing.T) {
+	t.Setenv("SENTRY_DSN", "https://sentry")
+
+	value := optionalConfig{}
+	c, err := xconfig.Load(&value, xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Kafka is absent, so its required fields are not missing.
+	testutil.Equal(t, optionalConfig{Sentry: &optionalSentry{DSN: "https://sentry", Environment: "production"}}, value)
+
+	snapshot, err := xconfig.Snapshot[optionalConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snapshot.Kafka != nil || snapshot.Sentry == nil || snapshot.Sentry == value.Sentry {
+		t.Fatalf("Snapshot() = %+v, want an owned Sentry and no Kafka", snapshot)
+	}
+}
+
+func TestLoadRecursiveSection(t *testing.T) {
+	type node struct {
+		Name string `default:"head"`
+		Next *node
+	}
+	t.Setenv("NAME", "first")
+
+	value := node{}
+	if _, err := xconfig.Load(&value, xconfig.WithSkipFlags()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	testutil.Equal(t, node{Name: "first"}, value)
+}
+
+func TestLoadOptionalSectionProfileAndComputedDefaults(t *testing.T) {
+	type service struct {
+		Name string
+		Env  string `default:"${Name}-env"`
+		Tier string `default:"dev" default_prod:"prod"`
+	}
+	type config struct {
+		Service *service
+	}
+	t.Setenv("SERVICE_NAME", "api")
+
+	value := config{}
+	c, err := xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithProfiles("prod"), xconfig.WithComputedDefaults())
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// The section allocated by env takes the profile and computed defaults.
+	testutil.Equal(t, config{Service: &service{Name: "api", Env: "api-env", Tier: "prod"}}, value)
+	testutil.Equal(t, []plugins.Source{{Plugin: "env", Name: "SERVICE_NAME"}}, c.Explain("Service.Name"))
+	testutil.Equal(t, []plugins.Source{{Plugin: "default", Name: "${Name}-env"}}, c.Explain("Service.Env"))
+	testutil.Equal(t, []plugins.Source{{Plugin: "default"}}, c.Explain("Service.Tier"))
+}
+
+type converterLevel int
+
+type converterConfig struct {
+	Level  converterLevel   `default:"info"`
+	Levels []converterLevel `env:"LEVELS"`
+}
+
+// converterRefreshPlugin sets Level through flat fields on every refresh,
+// viewing the working copy with the options of the configuration.
+type converterRefreshPlugin struct {
+	value string
+	opts  []flat.Option
+}
+
+func (*converterRefreshPlugin) Walk(any) error { return nil }
+
+func (p *converterRefreshPlugin) SetViewOptions(opts []flat.Option) { p.opts = opts }
+
+func (*converterRefreshPlugin) Parse() error { return nil }
+
+func (p *converterRefreshPlugin) Refresh(_ context.Context, target any) (plugins.RefreshOutcome, error) {
+	fields, err := flat.View(target, p.opts...)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	changed, err := fields[0].SetChanged(p.value)
+	if err != nil || !changed {
+		return plugins.RefreshOutcome{}, err
+	}
+	return plugins.RefreshOutcome{Changes: []plugins.FieldChange{{FieldName: fields[0].Name()}}}, nil
+}
+
+func TestLoadWithConverter(t *testing.T) {
+	t.Setenv("LEVELS", "debug,error")
+
+	levels := map[string]converterLevel{"debug": -4, "info": 0, "error": 8}
+	parseLevel := func(s string) (converterLevel, error) {
+		level, ok := levels[s]
+		if !ok {
+			return 0, fmt.Errorf("unknown level %q", s)
+		}
+		return level, nil
+	}
+
+	plugin := &converterRefreshPlugin{value: "error"}
+	value := converterConfig{Level: 1}
+	c, err := xconfig.Load(&value,
+		xconfig.WithSkipFlags(),
+		xconfig.WithConverter(parseLevel),
+		xconfig.WithPlugins(plugin),
+	)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	testutil.Equal(t, []converterLevel{-4, 8}, value.Levels)
+
+	if result := c.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want the converted level published", result)
+	}
+	snapshot, err := xconfig.Snapshot[converterConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, converterLevel(8), snapshot.Level)
+
+	// Other configurations keep parsing the type as an integer.
+	other := converterConfig{}
+	if _, err := xconfig.Load(&other, xconfig.WithSkipFlags()); err == nil {
+		t.Fatal("Load() without the converter parsed the level names")
+	}
+}
+
+func TestLoadWithEnvSource(t *testing.T) {
+	t.Parallel()
+
+	type node struct {
+		Addr string
+	}
+	type tenantConfig struct {
+		Host  string `default:"localhost"`
+		Port  int    `required:"true"`
+		Nodes []node
+	}
+
+	load := func(source env.Map) tenantConfig {
+		t.Helper()
+		var value tenantConfig
+		if _, err := xconfig.Load(&value,
+			xconfig.WithSkipFlags(),
+			xconfig.WithEnvPrefix("TENANT"),
+			xconfig.WithEnvSource(source),
+		); err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		return value
+	}
+
+	testutil.Equal(t, tenantConfig{Host: "a.example.com", Port: 8080, Nodes: []node{{Addr: "10.0.0.1"}}}, load(env.Map{
+		"TENANT_HOST":         "a.example.com",
+		"TENANT_PORT":         "8080",
+		"TENANT_NODES_0_ADDR": "10.0.0.1",
+	}))
+	testutil.Equal(t, tenantConfig{Host: "localhost", Port: 9090}, load(env.Map{
+		"TENANT_PORT": "9090",
+	}))
+
+	var value tenantConfig
+	_, err := xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithEnvSource(env.Map{}))
+	var missing *required.MissingFieldsError
+	if !errors.As(err, &missing) {
+		t.Fatalf("Load() error = %v, want *required.MissingFieldsError", err)
+	}
+}
+
+func TestLoadWithInterpolation(t *testing.T) {
+	t.Parallel()
+
+	type appConfig struct {
+		DSN  string
+		Port int `default:"80"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"DSN": "postgres://${DB_USER}@${DB_HOST:-localhost}/app"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(source env.Map, resolver loader.Resolver) (appConfig, error) {
+		t.Helper()
+		l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.AddFile(path, false); err != nil {
+			t.Fatal(err)
+		}
+		var value appConfig
+		_, err = xconfig.Load(&value,
+			xconfig.WithSkipFlags(),
+			xconfig.WithLoader(l),
+			xconfig.WithEnvSource(source),
+			xconfig.WithInterpolation(resolver),
+		)
+		return value, err
+	}
+
+	// Without a resolver, references resolve from the env source.
+	value, err := load(env.Map{"DB_USER": "admin", "PORT": "8080"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, appConfig{DSN: "postgres://admin@localhost/app", Port: 8080}, value)
+
+	value, err = load(env.Map{}, loader.MapResolver(map[string]string{"DB_USER": "ops", "DB_HOST": "db"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, appConfig{DSN: "postgres://ops@db/app", Port: 80}, value)
+
+	_, err = load(env.Map{}, nil)
+	var interpolationErr *loader.InterpolationError
+	if !errors.As(err, &interpolationErr) || interpolationErr.Path != "DSN" {
+		t.Fatalf("Load() error = %v, want a *loader.InterpolationError for DSN", err)
+	}
+}
+
+func TestLoadWithProfiles(t *testing.T) {
+	t.Parallel()
+
+	type appConfig struct {
+		Host     string `default:"localhost"`
+		LogLevel string `default:"debug" default_prod:"warn"`
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"Host": "app.local"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "config.prod.json"), []byte(`{"Host": "app.internal"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(source env.Map, opts ...xconfig.Option) (appConfig, xconfig.Config) {
+		t.Helper()
+		l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.AddFile(filepath.Join(dir, "config.json"), false); err != nil {
+			t.Fatal(err)
+		}
+		var value appConfig
+		c, err := xconfig.Load(&value, append([]xconfig.Option{
+			xconfig.WithSkipFlags(),
+			xconfig.WithLoader(l),
+			xconfig.WithEnvSource(source),
+		}, opts...)...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return value, c
+	}
+
+	// APP_PROFILE adds its profiles after those of WithProfiles.
+	value, c := load(env.Map{"APP_PROFILE": "prod"}, xconfig.WithProfiles("base"), xconfig.WithProfileEnv("APP_PROFILE"))
+	testutil.Equal(t, appConfig{Host: "app.internal", LogLevel: "warn"}, value)
+
+	usage, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(usage, "Profiles: base, prod\n") {
+		t.Errorf("Usage() = %q, want the profiles base, prod", usage)
+	}
+	for name, status := range map[string]string{
+		"config.json":       "found",
+		"config.base.json":  "not found",
+		"config.prod.json":  "found",
+		"config.local.json": "not found",
+	} {
+		if ok, _ := regexp.MatchString(`(?m)^\s+`+regexp.QuoteMeta(filepath.Join(dir, name))+`\s+`+status+`$`, usage); !ok {
+			t.Errorf("Usage() = %q, want %s %s", usage, name, status)
+		}
+	}
+
+	value, _ = load(env.Map{}, xconfig.WithProfileEnv("APP_PROFILE"))
+	testutil.Equal(t, appConfig{Host: "app.local", LogLevel: "debug"}, value)
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(filepath.Join(dir, "config.json"), false); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := xconfig.GenerateMarkdown(&appConfig{},
+		xconfig.WithSkipFlags(),
+		xconfig.WithLoader(l),
+		xconfig.WithEnvSource(env.Map{}),
+		xconfig.WithProfiles("prod"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"**Profiles:** `prod`",
+		"- `" + filepath.Join(dir, "config.prod.json") + "` ✅",
+		"- `" + filepath.Join(dir, "config.local.json") + "` (not found)",
+		"| `LOG_LEVEL` |",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("GenerateMarkdown() = %q, want it to contain %q", markdown, want)
+		}
+	}
+}
+
+func TestLoadKeepsFileValuesOfRenamedFields(t *testing.T) {
+	t.Parallel()
+
+	// Files name fields by their file tag or Go name, never by the xconfig
+	// tag, so these values are not taken for unset ones.
+	type appConfig struct {
+		Enabled bool   `xconfig:"on" default:"true"`
+		Host    string `json:"db_host" default:"localhost"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"Enabled": false, "db_host": ""}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var value appConfig
+	if _, err := xconfig.Load(&value, xconfig.WithSkipFlags(), xconfig.WithSkipEnv(), xconfig.WithLoader(l)); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, appConfig{}, value)
+}
@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"strings"
 	"unicode/utf8"
+
+	"github.com/sxwebdev/xconfig/plugins/env"
+	"github.com/sxwebdev/xconfig/plugins/required"
 )
 
 const cellSeparator = "|"
@@ -17,11 +20,24 @@ func GenerateMarkdown(cfg any, opts ...Option) (string, error) {
 	// Use fields from config that have been processed by plugins
 	fields := manager.fields
 
+	// The env plugin names a file env var, such as DB_PASSWORD_FILE, for the
+	// fields tagged env_file:"true"; list them when there are any.
+	var withEnvFiles bool
+	for _, f := range fields {
+		if _, ok := f.Meta()[env.FileMetaKey]; ok {
+			withEnvFiles = true
+			break
+		}
+	}
+
 	var table [][]string //nolint:prealloc
 
-	table = append(table, []string{
-		"**Name**", "**Required**", "**Secret**", "**Default value**", "**Usage**", "**Example**",
-	})
+	header := []string{"**Name**"}
+	if withEnvFiles {
+		header = append(header, "**Env file**")
+	}
+	header = append(header, "**Required**", "**Secret**", "**Default value**", "**Usage**", "**Example**")
+	table = append(table, header)
 
 	sizes := make([]int, len(table[0]))
 
@@ -51,16 +67,10 @@ func GenerateMarkdown(cfg any, opts ...Option) (string, error) {
 		var usage string
 		var example string
 
-		if _, ok := f.Tag("required"); ok {
+		if required.IsRequired(f) {
 			isRequired = true
 		}
 
-		if !isRequired {
-			if val, ok := f.Tag("validate"); ok && strings.Contains(val, "required") {
-				isRequired = true
-			}
-		}
-
 		if _, ok := f.Tag("secret"); ok {
 			isSecret = true
 		}
@@ -77,18 +87,28 @@ func GenerateMarkdown(cfg any, opts ...Option) (string, error) {
 			usage = val
 		}
 
+		if hint := formatHint(f); hint != "" {
+			if usage != "" {
+				usage += " "
+			}
+			usage += "(" + hint + ")"
+		}
+
 		if val, ok := f.Tag("example"); ok {
 			example = val
 		}
 
-		cell := []string{
-			"`" + envName + "`",
+		cell := []string{"`" + envName + "`"}
+		if withEnvFiles {
+			cell = append(cell, codeBlock(f.Meta()[env.FileMetaKey]))
+		}
+		cell = append(cell,
 			boolIcon(isRequired),
 			boolIcon(isSecret),
 			codeBlock(defaultValue),
 			usage,
 			codeBlock(example),
-		}
+		)
 		table = append(table, cell)
 
 		lineSize = 0
@@ -102,6 +122,21 @@ func GenerateMarkdown(cfg any, opts ...Option) (string, error) {
 	}
 
 	var out strings.Builder
+	if manager.options.withProfiles {
+		_, _ = fmt.Fprintf(&out, "**Profiles:** %s\n\n", profileList(manager.options.activeProfiles, "`"))
+		if l := manager.options.loader; l != nil && len(l.Files()) > 0 {
+			_, _ = out.WriteString("**Files:**\n\n")
+			for _, file := range l.Files() {
+				status := "✅"
+				if !file.Found {
+					status = "(not found)"
+				}
+				_, _ = fmt.Fprintf(&out, "- `%s` %s\n", file.Path, status)
+			}
+			_, _ = out.WriteRune('\n')
+		}
+	}
+
 	for i, row := range table {
 		_, _ = out.WriteString(cellSeparator)
 
@@ -13,7 +13,7 @@ import (
 type dummyConfig struct {
 	Foo         string `env:"FOO" required:"" usage:"Foo usage" example:"Foo example"`
 	Bar         string `env:"BAR" usage:"Bar usage" example:"Bar example"`
-	SecretField string `env:"SECRET_FIELD" secret:"" usage:"Secret usage" example:"Secret example"`
+	SecretField string `env:"SECRET_FIELD" env_file:"true" secret:"" usage:"Secret usage" example:"Secret example"`
 	WithDefault string `env:"WITH_DEFAULT" default:"defaultWithDefault" usage:"WithDefault usage" example:"WithDefault example"`
 }
 
@@ -39,6 +39,16 @@ func TestGenerateMarkdown(t *testing.T) {
 		}
 	}
 
+	// Check for the file env vars of the fields read from files.
+	for _, env := range []string{"**Env file**", "`SECRET_FIELD_FILE`"} {
+		if !strings.Contains(output, env) {
+			t.Errorf("expected output to contain env file %s, got: %s", env, output)
+		}
+	}
+	if strings.Contains(output, "`FOO_FILE`") {
+		t.Errorf("expected output not to contain env file `FOO_FILE`, got: %s", output)
+	}
+
 	// Check for expected usage texts.
 	expectedUsages := []string{"Foo usage", "Bar usage", "WithDefault usage"}
 	for _, usage := range expectedUsages {
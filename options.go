@@ -1,7 +1,14 @@
 package xconfig
 
 import (
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/env"
 	"github.com/sxwebdev/xconfig/plugins/loader"
 )
 
@@ -18,15 +25,41 @@ type options struct {
 	skipEnv bool
 	// SkipFlags set to true will not load config from flag parameters.
 	skipFlags bool
+	// SkipRequired set to true will not enforce the 'required' tag.
+	skipRequired bool
+	// computedDefaults set to true computes the defaults referencing other
+	// fields instead of setting them verbatim.
+	computedDefaults bool
 
 	// EnvPrefix is the prefix for environment variables.
 	envPrefix string
+	// envSource provides the environment variables instead of the process.
+	envSource env.Source
 
 	// DisallowUnknownFields set to true will cause loading to fail if unknown fields are found in config files.
 	disallowUnknownFields bool
 
-	loader  *loader.Loader
-	plugins []plugins.Plugin
+	// watchFiles set to true makes file changes trigger a background refresh.
+	watchFiles    bool
+	watchDebounce time.Duration
+
+	// interpolate set to true expands ${VAR} references in loaded files.
+	interpolate bool
+	resolver    loader.Resolver
+
+	// mergeStrategy, when set, is the merge strategy of the loader.
+	mergeStrategy loader.MergeStrategy
+
+	// withProfiles set to true loads the profile overlays of the files.
+	withProfiles bool
+	profiles     []string
+	profileEnv   string
+	// activeProfiles holds profiles followed by those named by profileEnv.
+	activeProfiles []string
+
+	loader     *loader.Loader
+	plugins    []plugins.Plugin
+	converters []flat.Converter
 }
 
 func WithSkipDefaults() Option {
@@ -35,6 +68,16 @@ func WithSkipDefaults() Option {
 	}
 }
 
+// WithComputedDefaults computes the default tags referencing other fields,
+// such as `default:"${Server.Host}:${Server.Port}"`, once every other source
+// ran, as described by defaults.NewComputed. Without it such tags are set
+// verbatim, like any other default.
+func WithComputedDefaults() Option {
+	return func(o *options) {
+		o.computedDefaults = true
+	}
+}
+
 func WithSkipCustomDefaults() Option {
 	return func(o *options) {
 		o.skipCustomDefaults = true
@@ -59,6 +102,12 @@ func WithSkipFlags() Option {
 	}
 }
 
+func WithSkipRequired() Option {
+	return func(o *options) {
+		o.skipRequired = true
+	}
+}
+
 func WithEnvPrefix(prefix string) Option {
 	return func(o *options) {
 		o.envPrefix = prefix
@@ -82,3 +131,111 @@ func WithDisallowUnknownFields() Option {
 		o.disallowUnknownFields = true
 	}
 }
+
+// WithWatchFiles makes Config.StartRefresh refresh as soon as a loaded file
+// changes, instead of waiting for its next tick. Rewrites, atomic renames and
+// the symlink swaps used by Kubernetes ConfigMap and Secret volumes are
+// detected. Bursts of events are debounced for the given period; a
+// non-positive debounce uses loader.DefaultWatchDebounce.
+func WithWatchFiles(debounce time.Duration) Option {
+	return func(o *options) {
+		o.watchFiles = true
+		o.watchDebounce = debounce
+	}
+}
+
+// WithConverter parses values of type T with parse in this configuration:
+// fields, slice and array elements, map keys and map values of type T, set
+// from defaults, env vars, flags or any other source. It takes precedence
+// over converters registered with flat.RegisterConverter.
+func WithConverter[T any](parse func(string) (T, error)) Option {
+	c := flat.NewConverter(parse)
+	return func(o *options) {
+		o.converters = append(o.converters, c)
+	}
+}
+
+// WithEnvSource makes the env plugin read environment variables, including
+// the ones discovering slice and map entries, from source instead of the
+// process environment:
+//
+//	xconfig.Load(&cfg, xconfig.WithEnvSource(env.Map{"PORT": "8080"}))
+func WithEnvSource(source env.Source) Option {
+	return func(o *options) {
+		o.envSource = source
+	}
+}
+
+// WithInterpolation expands ${VAR}, ${VAR:-default} and ${VAR:?error}
+// references in the string values of the loaded files, as described by
+// loader.Resolver. Variables are looked up with resolver or, when it is nil,
+// in the environment source set with WithEnvSource or the process
+// environment:
+//
+//	xconfig.Load(&cfg,
+//	    xconfig.WithLoader(l),
+//	    xconfig.WithInterpolation(loader.ChainResolver(loader.EnvResolver(), loader.FieldResolver())),
+//	)
+func WithInterpolation(resolver loader.Resolver) Option {
+	return func(o *options) {
+		o.interpolate = true
+		o.resolver = resolver
+	}
+}
+
+// WithMergeStrategy sets how the maps and lists of a loaded file are
+// combined with those of the files loaded before it, for the fields without a
+// merge tag, such as `merge:"append"`. See loader.MergeStrategy.
+func WithMergeStrategy(strategy loader.MergeStrategy) Option {
+	return func(o *options) {
+		o.mergeStrategy = strategy
+	}
+}
+
+// WithProfiles activates profiles, in order. Every file added to the loader,
+// such as config.yaml, is followed by the overlays config.<profile>.yaml of
+// each profile and config.local.yaml, loaded when they exist, and a
+// `default_<profile>` tag takes precedence over the `default` tag, the last
+// active profile winning. Usage and GenerateMarkdown list the active profiles
+// and the files that were found.
+//
+//	xconfig.Load(&cfg, xconfig.WithLoader(l), xconfig.WithProfiles("base", "staging"))
+func WithProfiles(profiles ...string) Option {
+	return func(o *options) {
+		o.withProfiles = true
+		o.profiles = append(o.profiles, profiles...)
+	}
+}
+
+// WithProfileEnv activates the comma-separated profiles named by the env var
+// name, such as APP_PROFILE=staging, after those passed to WithProfiles. The
+// variable is read from the source set with WithEnvSource or the process
+// environment. The overlays of WithProfiles are loaded even when it is unset.
+func WithProfileEnv(name string) Option {
+	return func(o *options) {
+		o.withProfiles = true
+		o.profileEnv = name
+	}
+}
+
+// resolveProfiles returns the active profiles without duplicates.
+func (o *options) resolveProfiles() []string {
+	candidates := slices.Clone(o.profiles)
+	if o.profileEnv != "" {
+		var value string
+		if o.envSource != nil {
+			value, _ = o.envSource.LookupEnv(o.profileEnv)
+		} else {
+			value = os.Getenv(o.profileEnv)
+		}
+		candidates = append(candidates, strings.Split(value, ",")...)
+	}
+
+	var profiles []string
+	for _, profile := range candidates {
+		if profile = strings.TrimSpace(profile); profile != "" && !slices.Contains(profiles, profile) {
+			profiles = append(profiles, profile)
+		}
+	}
+	return profiles
+}
@@ -11,6 +11,7 @@ package customdefaults
 import (
 	"reflect"
 
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/plugins"
 )
 
@@ -25,18 +26,49 @@ func New() plugins.Plugin {
 
 type visitor struct {
 	config any
+	// viewOptions configure the views of the configuration.
+	viewOptions []flat.Option
+	// set holds the fields the last Parse changed.
+	set []string
 }
 
 func (v *visitor) Parse() error {
+	v.set = nil
 	if v.config == nil {
 		return nil
 	}
 
 	rv := reflect.ValueOf(v.config)
+	if !holdsSetDefaults(rv.Type(), map[reflect.Type]bool{}) {
+		return nil
+	}
+	before, err := fieldValues(v.config, v.viewOptions)
+	if err != nil {
+		return err
+	}
 	walkAndSetDefaults(rv, map[uintptr]struct{}{})
-	return nil
+	v.set, err = changedFields(before, v.config, v.viewOptions)
+	return err
+}
+
+// SetFields returns the fields whose value the last Parse changed.
+func (v *visitor) SetFields() []string {
+	return v.set
 }
 
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *visitor) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// Source reports SetDefaults as the origin of the values it applied.
+func (v *visitor) Source(string) plugins.Source {
+	return plugins.Source{Plugin: "SetDefaults"}
+}
+
+// Fallback marks SetDefaults as setting values other sources override.
+func (*visitor) Fallback() {}
+
 func (v *visitor) Walk(config any) error {
 	v.config = config
 	return nil
@@ -128,6 +160,112 @@ func invokeSetDefaults(rv reflect.Value) {
 	}
 }
 
+var setCustomDefaultsType = reflect.TypeFor[setCustomDefaults]()
+
+// holdsSetDefaults reports whether values of type t may hold a struct
+// implementing SetDefaults. An interface may hold anything.
+func holdsSetDefaults(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+	if t.Implements(setCustomDefaultsType) || reflect.PointerTo(t).Implements(setCustomDefaultsType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsSetDefaults(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if t.Field(i).IsExported() && holdsSetDefaults(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// fieldValues returns a copy of the value of every flat field of conf.
+func fieldValues(conf any, opts []flat.Option) (map[string]reflect.Value, error) {
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]reflect.Value, len(fields))
+	for _, f := range fields {
+		values[f.Name()] = copyValue(f.FieldValue())
+	}
+	return values, nil
+}
+
+// copyValue copies v along with the elements of its slices and maps and the
+// value it points to, which SetDefaults may change in place.
+func copyValue(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			break
+		}
+		c.Set(reflect.New(v.Type().Elem()))
+		c.Elem().Set(copyValue(v.Elem()))
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := range v.Len() {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+	default:
+		c.Set(v)
+	}
+	return c
+}
+
+// changedFields lists the flat fields of conf whose value differs from
+// before. A field that did not exist before, such as the entry of a map
+// SetDefaults filled, is listed when it is not zero.
+func changedFields(before map[string]reflect.Value, conf any, opts []flat.Option) ([]string, error) {
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, f := range fields {
+		old, ok := before[f.Name()]
+		if ok && sameValue(old, f.FieldValue()) {
+			continue
+		}
+		if !ok && f.IsZero() {
+			continue
+		}
+		changed = append(changed, f.Name())
+	}
+	return changed, nil
+}
+
+// sameValue reports whether a and b are deeply equal. Funcs, which never are,
+// compare by their code pointer.
+func sameValue(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Func {
+		return a.Pointer() == b.Pointer()
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
+
 // containsStruct reports whether t is a struct type or (recursively) a
 // pointer/slice/array/map whose element type is a struct. Used to prune
 // traversal of primitive containers.
@@ -0,0 +1,294 @@
+package defaults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/interpolate"
+	"github.com/sxwebdev/xconfig/internal/utils"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+// NewComputed returns a plugin applying the default tags that reference
+// other fields, such as `default:"${Server.Host}:${Server.Port}"` or
+// `default:"${Database.Name}_test"`. Registering it makes the other defaults
+// plugins skip these tags, which they otherwise set verbatim. A
+// computed default is applied to a field that is still zero once every other
+// source ran, so the plugin must be registered after them, and fields
+// explicitly present in a loaded file keep their value.
+//
+// References use the syntax of file interpolation, such as
+// ${Cache.TTL:-1m}. Names are flat field names matched case-insensitively,
+// looked up from the struct holding the field outwards, so a struct used in
+// several places can reference its siblings as ${Host}. A zero field is not
+// set. Computed defaults referencing each other are applied in dependency
+// order, and a reference cycle fails with an error naming it.
+//
+// Refresh computes the defaults of the working copy again, so a derived field
+// follows the fields it references, unless another source set it since.
+// Profiles select default_<profile> tags as for New.
+func NewComputed(present presentFieldsProvider, profiles ...string) plugins.Plugin {
+	return &computed{present: present, profiles: profiles, derived: map[string]string{}}
+}
+
+// UsesReferences reports whether a default tag of the type of conf, or a
+// default_<profile> tag of one of profiles, references other fields, so
+// callers register the NewComputed plugin, which is refreshable, only when
+// it has work to do.
+func UsesReferences(conf any, profiles ...string) bool {
+	tags := []string{tag}
+	for _, profile := range profiles {
+		tags = append(tags, tag+"_"+profile)
+	}
+	return holdsReferences(reflect.TypeOf(conf), tags, map[reflect.Type]bool{})
+}
+
+func holdsReferences(t reflect.Type, tags []string, seen map[reflect.Type]bool) bool {
+	if t == nil || seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsReferences(t.Elem(), tags, seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			field := t.Field(i)
+			for _, name := range tags {
+				if value, ok := field.Tag.Lookup(name); ok && interpolate.HasReferences(value) {
+					return true
+				}
+			}
+			if holdsReferences(field.Type, tags, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type computed struct {
+	conf     any
+	present  presentFieldsProvider
+	profiles []string
+	// viewOptions configure the views of the configuration.
+	viewOptions []flat.Option
+
+	// derived holds, by flat name, the formatted value last computed for each
+	// field, so a refresh recomputes only the fields no other source set.
+	derived map[string]string
+	// exprs holds the default tag of every computed field, by flat name.
+	exprs map[string]string
+	// set holds the fields the last apply computed.
+	set []string
+}
+
+func (v *computed) Walk(conf any) error {
+	v.conf = conf
+	return nil
+}
+
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *computed) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// ViewOptions tells the other plugins that the defaults referencing other
+// fields are computed.
+func (*computed) ViewOptions() []flat.Option {
+	return []flat.Option{flat.WithComputedDefaults()}
+}
+
+// Source reports the default tag, with its expression, as the origin of the
+// values it applied.
+func (v *computed) Source(fieldName string) plugins.Source {
+	return plugins.Source{Plugin: tag, Name: v.exprs[fieldName]}
+}
+
+// SetFields returns the fields the last Parse computed.
+func (v *computed) SetFields() []string {
+	return v.set
+}
+
+// Fallback marks computed defaults as only filling the fields no source set.
+func (*computed) Fallback() {}
+
+func (v *computed) Parse() error {
+	_, err := v.apply(v.conf)
+	return err
+}
+
+func (v *computed) Refresh(ctx context.Context, target any) (plugins.RefreshOutcome, error) {
+	if err := ctx.Err(); err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	changes, err := v.apply(target)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	return plugins.RefreshOutcome{Changes: changes}, nil
+}
+
+// apply computes the pending defaults of conf in dependency order.
+func (v *computed) apply(conf any) ([]plugins.FieldChange, error) {
+	fields, err := flat.View(conf, v.viewOptions...)
+	if err != nil {
+		return nil, err
+	}
+
+	present := map[string]struct{}{}
+	if v.present != nil {
+		present = v.present.PresentFields()
+	}
+
+	byName := make(map[string]flat.Field, len(fields))
+	pending := make(map[string]flat.Field)
+	v.exprs = make(map[string]string)
+	for _, f := range fields {
+		byName[strings.ToLower(f.Name())] = f
+
+		expr, ok := lookup(f, v.profiles)
+		if !ok || !interpolate.HasReferences(expr) || flat.DefaultDeferred(f) {
+			continue
+		}
+		v.exprs[f.Name()] = expr
+
+		if last, ok := v.derived[f.Name()]; ok {
+			if interpolate.Format(f.FieldValue()) == last {
+				pending[f.Name()] = f
+				continue
+			}
+			// Another source set the field since it was computed.
+			delete(v.derived, f.Name())
+		}
+		if !f.IsZero() {
+			continue
+		}
+		if p, ok := utils.ConfigPath(conf, f.Name()); ok && utils.HoldsPath(present, p) {
+			continue
+		}
+		pending[f.Name()] = f
+	}
+
+	order, err := dependencyOrder(pending, byName, v.exprs)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []plugins.FieldChange
+	v.set = nil
+	for _, f := range order {
+		value, failures := interpolate.Expand(v.exprs[f.Name()], func(name string) (string, bool) {
+			ref := lookupField(byName, f.Name(), name)
+			if ref == nil || ref.IsZero() {
+				return "", false
+			}
+			return interpolate.Format(ref.FieldValue()), true
+		})
+		if len(failures) > 0 {
+			errs := make([]error, len(failures))
+			for i, failure := range failures {
+				reason := failure.Reason
+				if reason == "not set" && lookupField(byName, f.Name(), failure.Name) == nil {
+					reason = "no such field"
+				}
+				errs[i] = fmt.Errorf("defaults: field %s: %s: %s", f.Name(), failure.Name, reason)
+			}
+			return nil, errors.Join(errs...)
+		}
+
+		changed, err := f.SetChanged(value)
+		if err != nil {
+			return nil, fmt.Errorf("defaults: field %s: %w", f.Name(), err)
+		}
+		v.derived[f.Name()] = interpolate.Format(f.FieldValue())
+		v.set = append(v.set, f.Name())
+		if changed {
+			changes = append(changes, plugins.FieldChange{FieldName: f.Name()})
+		}
+	}
+	return changes, nil
+}
+
+// lookupField finds the field a reference from the field named from points
+// to, trying the struct holding from first and then its parents.
+func lookupField(byName map[string]flat.Field, from, name string) flat.Field {
+	name = strings.ToLower(name)
+	scope := strings.ToLower(from)
+	for {
+		i := strings.LastIndexByte(scope, '.')
+		if i < 0 {
+			return byName[name]
+		}
+		scope = scope[:i]
+		if f, ok := byName[scope+"."+name]; ok {
+			return f
+		}
+	}
+}
+
+// dependencyOrder sorts the pending fields so that every field comes after
+// the pending fields its expression in exprs references, failing on a
+// reference cycle.
+func dependencyOrder(pending, byName map[string]flat.Field, exprs map[string]string) ([]flat.Field, error) {
+	names := make([]string, 0, len(pending))
+	for name := range pending {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := make(map[string]int, len(pending))
+	order := make([]flat.Field, 0, len(pending))
+	var path []string
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case done:
+			return nil
+		case visiting:
+			start := 0
+			for path[start] != name {
+				start++
+			}
+			cycle := append(append([]string{}, path[start:]...), name)
+			return fmt.Errorf("defaults: reference cycle: %s", strings.Join(cycle, " -> "))
+		}
+		state[name] = visiting
+		path = append(path, name)
+
+		for _, ref := range interpolate.Names(exprs[name]) {
+			dep := lookupField(byName, name, ref)
+			if dep == nil {
+				continue
+			}
+			if _, ok := pending[dep.Name()]; ok {
+				if err := visit(dep.Name()); err != nil {
+					return err
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[name] = done
+		order = append(order, pending[name])
+		return nil
+	}
+
+	for _, name := range names {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
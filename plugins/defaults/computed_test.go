@@ -0,0 +1,216 @@
+package defaults_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/defaults"
+	"github.com/sxwebdev/xconfig/plugins/env"
+)
+
+type computedServer struct {
+	Host string `default:"localhost"`
+	Port int    `default:"8080"`
+}
+
+type computedUpstream struct {
+	Host string
+	URL  string `default:"http://${Host}/${Name:-api}"`
+}
+
+type computedConfig struct {
+	// Declared before the fields they reference, including each other.
+	HealthURL string `default:"${Addr}/health"`
+	Addr      string `default:"${Server.Host}:${Server.Port}"`
+	Server    computedServer
+	Database  struct {
+		Name   string `default:"app"`
+		TestDB string `default:"${Database.Name}_test"`
+	}
+	Literal   string `default:"costs $$5"`
+	Upstreams map[string]computedUpstream
+}
+
+func TestComputedDefaults(t *testing.T) {
+	t.Parallel()
+
+	var cfg computedConfig
+	c, err := xconfig.Load(&cfg, xconfig.WithSkipFlags(), xconfig.WithComputedDefaults(), xconfig.WithEnvSource(env.Map{
+		"SERVER_HOST":            "api.internal",
+		"DATABASE_TEST_DB":       "fixtures",
+		"UPSTREAMS_BILLING_HOST": "billing",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, "api.internal:8080", cfg.Addr)
+	testutil.Equal(t, "api.internal:8080/health", cfg.HealthURL)
+	// A source setting the derived field wins over its default.
+	testutil.Equal(t, "fixtures", cfg.Database.TestDB)
+	// Defaults without references are applied verbatim.
+	testutil.Equal(t, "costs $$5", cfg.Literal)
+	// References resolve from the struct holding the field outwards.
+	testutil.Equal(t, "http://billing/api", cfg.Upstreams["BILLING"].URL)
+
+	testutil.Equal(t, []plugins.Source{
+		{Plugin: "default", Name: "${Server.Host}:${Server.Port}"},
+	}, c.Explain("Addr"))
+
+	var plain computedConfig
+	if _, err := xconfig.Load(&plain, xconfig.WithSkipFlags(), xconfig.WithComputedDefaults(), xconfig.WithEnvSource(env.Map{})); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "localhost:8080", plain.Addr)
+	testutil.Equal(t, "app_test", plain.Database.TestDB)
+}
+
+func TestComputedDefaultsErrors(t *testing.T) {
+	t.Parallel()
+
+	type cycle struct {
+		A string `default:"${B}"`
+		B string `default:"${C}-b"`
+		C string `default:"${a}-c"`
+	}
+	_, err := xconfig.Load(&cycle{}, xconfig.WithSkipFlags(), xconfig.WithComputedDefaults(), xconfig.WithEnvSource(env.Map{}))
+	if err == nil || !strings.Contains(err.Error(), "reference cycle: A -> B -> C -> A") {
+		t.Fatalf("Load() error = %v, want the reference cycle A -> B -> C -> A", err)
+	}
+
+	// A cycle is broken by a source setting one of its fields.
+	var broken cycle
+	if _, err := xconfig.Load(&broken, xconfig.WithSkipFlags(), xconfig.WithComputedDefaults(), xconfig.WithEnvSource(env.Map{"C": "c"})); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, cycle{A: "c-b", B: "c-b", C: "c"}, broken)
+
+	type missing struct {
+		Name string
+		URL  string `default:"${Nope}/${Name}"`
+	}
+	_, err = xconfig.Load(&missing{}, xconfig.WithSkipFlags(), xconfig.WithComputedDefaults(), xconfig.WithEnvSource(env.Map{}))
+	for _, want := range []string{"field URL: Nope: no such field", "field URL: Name: not set"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestReferenceDefaultsWithoutComputed(t *testing.T) {
+	t.Parallel()
+
+	type service struct {
+		Name string
+		Dir  string `default:"${HOME}/${Name}"`
+	}
+	type config struct {
+		Data    string `default:"${HOME}/data"`
+		Service *service
+	}
+
+	// Without WithComputedDefaults the tags are set verbatim, also to the
+	// sections other sources allocate.
+	var loaded config
+	if _, err := xconfig.Load(&loaded, xconfig.WithSkipFlags(), xconfig.WithEnvSource(env.Map{"SERVICE_NAME": "api"})); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Data: "${HOME}/data", Service: &service{Name: "api", Dir: "${HOME}/${Name}"}}, loaded)
+
+	var custom config
+	c, err := xconfig.Custom(&custom, defaults.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "${HOME}/data", custom.Data)
+
+	var applied config
+	if err := xconfig.ApplyDefaults(&applied); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "${HOME}/data", applied.Data)
+}
+
+// rotateHost is a refreshable plugin setting Server.Host to the next name of
+// hosts on every refresh.
+type rotateHost struct {
+	hosts []string
+}
+
+func (*rotateHost) Visit(flat.Fields) error { return nil }
+
+func (*rotateHost) Parse() error { return nil }
+
+func (p *rotateHost) Refresh(_ context.Context, target any) (plugins.RefreshOutcome, error) {
+	fields, err := flat.View(target)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	host := p.hosts[0]
+	p.hosts = p.hosts[1:]
+	for _, f := range fields {
+		if f.Name() == "Server.Host" {
+			if err := f.Set(host); err != nil {
+				return plugins.RefreshOutcome{}, err
+			}
+		}
+	}
+	return plugins.RefreshOutcome{Changes: []plugins.FieldChange{{FieldName: "Server.Host"}}}, nil
+}
+
+func TestComputedDefaultsRefresh(t *testing.T) {
+	t.Parallel()
+
+	var cfg computedConfig
+	c, err := xconfig.Load(&cfg,
+		xconfig.WithSkipFlags(),
+		xconfig.WithComputedDefaults(),
+		xconfig.WithEnvSource(env.Map{}),
+		xconfig.WithPlugins(&rotateHost{hosts: []string{"db-2"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "localhost:8080", cfg.Addr)
+
+	result := c.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{
+		{FieldName: "Addr"},
+		{FieldName: "HealthURL"},
+		{FieldName: "Server.Host"},
+	}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[computedConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "db-2:8080", snapshot.Addr)
+	testutil.Equal(t, "db-2:8080/health", snapshot.HealthURL)
+}
+
+func TestUsesReferences(t *testing.T) {
+	t.Parallel()
+
+	type plain struct {
+		Name    string `default:"$$HOME"`
+		Entries map[string]computedServer
+	}
+	type nested struct {
+		Entries []map[string]computedUpstream
+	}
+
+	testutil.Equal(t, true, defaults.UsesReferences(&computedConfig{}))
+	testutil.Equal(t, true, defaults.UsesReferences(&nested{}))
+	testutil.Equal(t, false, defaults.UsesReferences(&plain{}))
+}
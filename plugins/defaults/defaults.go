@@ -3,6 +3,7 @@ package defaults
 
 import (
 	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/interpolate"
 	"github.com/sxwebdev/xconfig/plugins"
 )
 
@@ -12,28 +13,46 @@ func init() {
 	plugins.RegisterTag(tag)
 }
 
-// New returns a defaults plugin.
-func New() plugins.Plugin {
-	return &visitor{applyDefaults: true}
+// New returns a defaults plugin. With profiles, a field tagged for one of
+// them, such as `default_prod:"..."`, takes the default of the last such
+// profile instead of its default tag.
+func New(profiles ...string) plugins.Plugin {
+	return &visitor{applyDefaults: true, profiles: profiles}
 }
 
 // NewMetaOnly returns a defaults plugin that only registers metadata
 // without applying default values. This is useful when you want to
 // register defaults for usage/documentation but apply them later.
-func NewMetaOnly() plugins.Plugin {
-	return &visitor{applyDefaults: false}
+func NewMetaOnly(profiles ...string) plugins.Plugin {
+	return &visitor{applyDefaults: false, profiles: profiles}
+}
+
+// lookup returns the default of f: the default_<profile> tag of the last of
+// profiles f has one for, or its default tag.
+func lookup(f flat.Field, profiles []string) (string, bool) {
+	for i := len(profiles) - 1; i >= 0; i-- {
+		if value, ok := f.Tag(tag + "_" + profiles[i]); ok {
+			return value, true
+		}
+	}
+	return f.Tag(tag)
 }
 
 type visitor struct {
 	fields        flat.Fields
 	applyDefaults bool
+	profiles      []string
+	set           []string
+	// computed is set when NewComputed applies the defaults referencing
+	// other fields, which are otherwise set verbatim.
+	computed bool
 }
 
 func (v *visitor) Visit(f flat.Fields) error {
 	v.fields = f
 
 	for _, f := range v.fields {
-		value, ok := f.Tag(tag)
+		value, ok := lookup(f, v.profiles)
 		if !ok {
 			continue
 		}
@@ -43,7 +62,57 @@ func (v *visitor) Visit(f flat.Fields) error {
 	return nil
 }
 
+// Source reports the default tag as the origin of the values it applied.
+func (v *visitor) Source(string) plugins.Source {
+	return plugins.Source{Plugin: tag}
+}
+
+// SetFields returns the fields the last Parse set to their default.
+func (v *visitor) SetFields() []string {
+	return v.set
+}
+
+// Fallback marks defaults as only filling the fields no source set.
+func (*visitor) Fallback() {}
+
+// SetViewOptions tells whether NewComputed is registered.
+func (v *visitor) SetViewOptions(opts []flat.Option) {
+	v.computed = flat.ComputedDefaults(opts)
+}
+
+// ViewOptions makes the optional sections other plugins allocate take the
+// defaults of the active profiles, as the fields viewed by Visit do.
+func (v *visitor) ViewOptions() []flat.Option {
+	if !v.applyDefaults {
+		return nil
+	}
+	return []flat.Option{flat.WithSectionDefaults(v.setSectionDefault)}
+}
+
+func (v *visitor) setSectionDefault(f flat.Field) error {
+	ok, err := setSectionDefault(f, v.profiles, v.computed)
+	if ok {
+		v.set = append(v.set, f.Name())
+	}
+	return err
+}
+
+// setSectionDefault sets the default of f, a zero field of a section just
+// allocated, leaving defaults referencing other fields to NewComputed when
+// computed is set, and reports whether it set one.
+func setSectionDefault(f flat.Field, profiles []string, computed bool) (bool, error) {
+	value, ok := lookup(f, profiles)
+	if !ok || computed && interpolate.HasReferences(value) {
+		return false, nil
+	}
+	if err := f.Set(value); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
 func (v *visitor) Parse() error {
+	v.set = nil
 	// If applyDefaults is false, skip applying values (only metadata was registered)
 	if !v.applyDefaults {
 		return nil
@@ -55,8 +124,10 @@ func (v *visitor) Parse() error {
 			continue
 		}
 
-		// Only set default if field is zero (empty)
-		if !f.IsZero() {
+		// Only set default if field is zero (empty), and leave optional
+		// sections to be allocated by another source and defaults referencing
+		// other fields to NewComputed when it is registered.
+		if !f.IsZero() || flat.DefaultDeferred(f) || v.computed && interpolate.HasReferences(value) {
 			continue
 		}
 
@@ -64,6 +135,7 @@ func (v *visitor) Parse() error {
 		if err != nil {
 			return err
 		}
+		v.set = append(v.set, f.Name())
 	}
 
 	return nil
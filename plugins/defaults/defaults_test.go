@@ -123,3 +123,108 @@ func TestDefaultTagInSliceOfStructs(t *testing.T) {
 
 	testutil.Equal(t, expect, value)
 }
+
+func TestDefaultMap(t *testing.T) {
+	t.Parallel()
+
+	value := struct {
+		Weights map[string]float64       `default:"a=0.5,b=1"`
+		Periods map[string]time.Duration `default:"poll=5s"`
+		Kept    map[string]int           `default:"x=1"`
+	}{
+		Periods: map[string]time.Duration{},
+		Kept:    map[string]int{"y": 2},
+	}
+
+	conf, err := xconfig.Custom(&value, defaults.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, map[string]float64{"a": 0.5, "b": 1}, value.Weights)
+	// An empty map counts as unset; a map with entries keeps them.
+	testutil.Equal(t, map[string]time.Duration{"poll": 5 * time.Second}, value.Periods)
+	testutil.Equal(t, map[string]int{"y": 2}, value.Kept)
+}
+
+func TestDefaultPointerScalars(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+	value := struct {
+		Enabled  *bool          `default:"true"`
+		Explicit *bool          `default:"true"`
+		Timeout  *time.Duration `default:"5s"`
+	}{Explicit: &disabled}
+
+	conf, err := xconfig.Custom(&value, defaults.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, true, *value.Enabled)
+	// An explicit false is set, so the default does not apply.
+	testutil.Equal(t, false, *value.Explicit)
+	testutil.Equal(t, 5*time.Second, *value.Timeout)
+}
+
+type sectionOptional struct {
+	Host string `default:"localhost"`
+}
+
+func TestDefaultOptionalSections(t *testing.T) {
+	t.Parallel()
+
+	value := struct {
+		Optional *sectionOptional
+		Always   *sectionOptional `alloc:"always"`
+		Present  *sectionOptional
+	}{Present: &sectionOptional{}}
+
+	conf, err := xconfig.Custom(&value, defaults.New())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if value.Optional != nil {
+		t.Fatalf("Optional = %+v, want a nil section", value.Optional)
+	}
+	testutil.Equal(t, sectionOptional{Host: "localhost"}, *value.Always)
+	testutil.Equal(t, sectionOptional{Host: "localhost"}, *value.Present)
+}
+
+func TestDefaultProfileTags(t *testing.T) {
+	t.Parallel()
+
+	type profiled struct {
+		Host  string `default:"localhost" default_prod:"db.internal"`
+		Debug bool   `default:"true" default_prod:"false" default_test:"true"`
+		Level string `default:"info" default_test:"debug"`
+	}
+
+	var value profiled
+	c, err := xconfig.Custom(&value, defaults.New("test", "prod"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	// The tag of the last active profile wins.
+	testutil.Equal(t, profiled{Host: "db.internal", Level: "debug"}, value)
+
+	var plain profiled
+	if _, err := xconfig.Load(&plain, xconfig.WithSkipFlags(), xconfig.WithSkipEnv()); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, profiled{Host: "localhost", Debug: true, Level: "info"}, plain)
+}
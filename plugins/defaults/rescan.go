@@ -1,10 +1,9 @@
 package defaults
 
 import (
-	"reflect"
-	"strings"
-
 	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/interpolate"
+	"github.com/sxwebdev/xconfig/internal/utils"
 	"github.com/sxwebdev/xconfig/plugins"
 )
 
@@ -15,13 +14,18 @@ type presentFieldsProvider interface {
 // NewWithRescan returns a defaults plugin that rescans the structure
 // before applying defaults. This is useful when you want to apply defaults
 // after loading configuration files that may have created new structs in maps.
-func NewWithRescan(present presentFieldsProvider) plugins.Plugin {
-	return &rescanVisitor{present: present}
+// Profiles select default_<profile> tags as for New.
+func NewWithRescan(present presentFieldsProvider, profiles ...string) plugins.Plugin {
+	return &rescanVisitor{present: present, profiles: profiles}
 }
 
 type rescanVisitor struct {
-	conf    any
-	present presentFieldsProvider
+	conf     any
+	present  presentFieldsProvider
+	profiles []string
+	set      []string
+	// viewOptions configure the views of conf.
+	viewOptions []flat.Option
 }
 
 func (v *rescanVisitor) Walk(conf any) error {
@@ -29,21 +33,71 @@ func (v *rescanVisitor) Walk(conf any) error {
 	return nil
 }
 
-func (v *rescanVisitor) Parse() error {
-	// Rescan the structure to get all fields including those in maps
-	fields, err := flat.View(v.conf)
-	if err != nil {
-		return err
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *rescanVisitor) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// Source reports the default tag as the origin of the values it applied.
+func (v *rescanVisitor) Source(string) plugins.Source {
+	return plugins.Source{Plugin: tag}
+}
+
+// SetFields returns the fields the last Parse set to their default.
+func (v *rescanVisitor) SetFields() []string {
+	return v.set
+}
+
+// Fallback marks defaults as only filling the fields no source set.
+func (*rescanVisitor) Fallback() {}
+
+// ViewOptions makes the optional sections other plugins allocate take the
+// defaults of the active profiles, as the fields of the rescan do.
+func (v *rescanVisitor) ViewOptions() []flat.Option {
+	return []flat.Option{flat.WithSectionDefaults(v.setSectionDefault)}
+}
+
+func (v *rescanVisitor) setSectionDefault(f flat.Field) error {
+	ok, err := setSectionDefault(f, v.profiles, flat.ComputedDefaults(v.viewOptions))
+	if ok {
+		v.set = append(v.set, f.Name())
 	}
+	return err
+}
 
+func (v *rescanVisitor) Parse() error {
 	present := map[string]struct{}{}
 	if v.present != nil {
 		present = v.present.PresentFields()
 	}
+	set, err := fill(v.conf, present, v.viewOptions, v.profiles)
+	v.set = set
+	return err
+}
+
+// Fill sets the defaults of the zero fields of conf as NewWithRescan does
+// after loading files, leaving the fields whose config path is in present,
+// those a loaded file holds. opts are those of the views of conf. The loader
+// calls it through Loader.Fallback on the files merged again on refresh.
+func Fill(conf any, present map[string]struct{}, opts []flat.Option, profiles ...string) error {
+	_, err := fill(conf, present, opts, profiles)
+	return err
+}
+
+// fill sets the defaults of the zero fields of conf not in present and
+// returns the fields it set.
+func fill(conf any, present map[string]struct{}, opts []flat.Option, profiles []string) ([]string, error) {
+	// Rescan the structure to get all fields including those in maps
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	computed := flat.ComputedDefaults(opts)
 
 	// Register metadata and apply defaults only to zero fields
+	var set []string
 	for _, f := range fields {
-		value, ok := f.Tag(tag)
+		value, ok := lookup(f, profiles)
 		if !ok {
 			continue
 		}
@@ -53,134 +107,23 @@ func (v *rescanVisitor) Parse() error {
 
 		// If the field was explicitly present in a loaded config file, do not override it.
 		if len(present) > 0 {
-			if p, ok := fieldConfigPath(v.conf, f.Name()); ok {
-				if _, exists := present[p]; exists {
-					continue
-				}
-			}
-		}
-
-		// Only set default if field is zero (empty)
-		if !f.IsZero() {
-			continue
-		}
-
-		err := f.Set(value)
-		if err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-func fieldConfigPath(conf any, flatName string) (string, bool) {
-	t := reflect.TypeOf(conf)
-	for t.Kind() == reflect.Pointer {
-		t = t.Elem()
-	}
-	if t.Kind() != reflect.Struct {
-		return "", false
-	}
-
-	segments := strings.Split(flatName, ".")
-	pathParts := make([]string, 0, len(segments))
-
-	cur := t
-	for _, seg := range segments {
-		for cur.Kind() == reflect.Pointer {
-			cur = cur.Elem()
-		}
-
-		switch cur.Kind() {
-		case reflect.Struct:
-			sf, ok := findFieldByFlatSegment(cur, seg)
-			if !ok {
-				return "", false
+			if p, ok := utils.ConfigPath(conf, f.Name()); ok && utils.HoldsPath(present, p) {
+				continue
 			}
-			name, ok := fileFieldName(sf)
-			if !ok {
-				return "", false
-			}
-			pathParts = append(pathParts, name)
-			cur = sf.Type
-		case reflect.Map:
-			// Map keys are dynamic and appear in flat field names as-is.
-			pathParts = append(pathParts, seg)
-			cur = cur.Elem()
-		case reflect.Slice, reflect.Array:
-			// Slice indices appear as numeric segments in flat field names.
-			pathParts = append(pathParts, seg)
-			cur = cur.Elem()
-		default:
-			return "", false
 		}
-	}
-
-	return strings.Join(pathParts, "."), true
-}
 
-func findFieldByFlatSegment(structType reflect.Type, seg string) (reflect.StructField, bool) {
-	// Fast path: exact Go field name.
-	if sf, ok := structType.FieldByName(seg); ok {
-		return sf, true
-	}
-
-	// Otherwise, try to match by tags. This matters when flat names were overridden
-	// with `xconfig:"..."` (often snake_case), because FieldByName() won't find it.
-	for i := 0; i < structType.NumField(); i++ {
-		sf := structType.Field(i)
-		if !sf.IsExported() {
+		// Only set default if field is zero (empty), and leave optional
+		// sections to be allocated by another source and defaults referencing
+		// other fields to NewComputed when it is registered.
+		if !f.IsZero() || flat.DefaultDeferred(f) || computed && interpolate.HasReferences(value) {
 			continue
 		}
 
-		if xname, ok := sf.Tag.Lookup("xconfig"); ok && xname == seg {
-			return sf, true
-		}
-
-		if yamlTag := sf.Tag.Get("yaml"); yamlTag != "" {
-			parts := strings.Split(yamlTag, ",")
-			if parts[0] == seg {
-				return sf, true
-			}
-		}
-
-		if jsonTag := sf.Tag.Get("json"); jsonTag != "" {
-			parts := strings.Split(jsonTag, ",")
-			if parts[0] == seg {
-				return sf, true
-			}
-		}
-	}
-
-	return reflect.StructField{}, false
-}
-
-func fileFieldName(field reflect.StructField) (string, bool) {
-	if yamlTag := field.Tag.Get("yaml"); yamlTag != "" {
-		parts := strings.Split(yamlTag, ",")
-		if parts[0] == "-" {
-			return "", false
-		}
-		if parts[0] != "" {
-			return parts[0], true
-		}
-	}
-
-	if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-		parts := strings.Split(jsonTag, ",")
-		if parts[0] == "-" {
-			return "", false
-		}
-		if parts[0] != "" {
-			return parts[0], true
+		if err := f.Set(value); err != nil {
+			return set, err
 		}
+		set = append(set, f.Name())
 	}
 
-	if name, ok := field.Tag.Lookup("xconfig"); ok && name != "" {
-		return name, true
-	}
-
-	// No tags: config keys are typically lower-cased (e.g. "parser" for Go field "Parser").
-	return strings.ToLower(field.Name), true
+	return set, nil
 }
@@ -2,7 +2,11 @@
 package env
 
 import (
+	"fmt"
+	"maps"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
 
 	"github.com/sxwebdev/xconfig/flat"
@@ -11,21 +15,100 @@ import (
 
 const tag = "env"
 
+// FileMetaKey is the field meta key holding the env var naming a file to read
+// the value of the field from when its own env var is unset, such as
+// DB_PASSWORD_FILE for DB_PASSWORD.
+const FileMetaKey = "env_file"
+
+// fileTag opts a field, or the entries of a map field, into reading its
+// value from the file named by its file env var: `env_file:"true"`.
+const fileTag = "env_file"
+
+// fileSuffix turns the env var of a field into the one naming a file holding
+// its value, the convention of Docker and Kubernetes secrets.
+const fileSuffix = "_FILE"
+
 func init() {
 	plugins.RegisterTag(tag)
+	plugins.RegisterTag(fileTag)
 }
 
 // New returns an EnvSet.
 func New(prefix string) plugins.Plugin {
+	return NewWithSource(prefix, OS())
+}
+
+// NewWithSource returns an EnvSet reading the variables of source instead of
+// the process environment, for instance to load the configuration of a child
+// process or a tenant, or to run tests in parallel.
+func NewWithSource(prefix string, source Source) plugins.Plugin {
 	return &visitor{
 		prefix: prefix,
+		source: source,
 	}
 }
 
+// Source provides environment variables.
+type Source interface {
+	// LookupEnv returns the value of the variable key and whether it is set.
+	LookupEnv(key string) (string, bool)
+	// Keys returns the names of the variables that are set. They drive the
+	// expansion of slice and map fields.
+	Keys() []string
+}
+
+// OS returns the Source of the process environment.
+func OS() Source {
+	return osSource{}
+}
+
+type osSource struct{}
+
+func (osSource) LookupEnv(key string) (string, bool) {
+	return os.LookupEnv(key)
+}
+
+func (osSource) Keys() []string {
+	envs := os.Environ()
+	keys := make([]string, 0, len(envs))
+	for _, e := range envs {
+		if eq := strings.IndexByte(e, '='); eq >= 0 {
+			keys = append(keys, e[:eq])
+		}
+	}
+	return keys
+}
+
+// Map is a Source holding variables in a map.
+//
+//	env.NewWithSource("MYAPP", env.Map{"MYAPP_PORT": "8080"})
+type Map map[string]string
+
+// LookupEnv implements Source.
+func (m Map) LookupEnv(key string) (string, bool) {
+	value, ok := m[key]
+	return value, ok
+}
+
+// Keys implements Source.
+func (m Map) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
 type visitor struct {
 	conf   any
 	fields flat.Fields
 	prefix string
+	source Source
+	// viewOptions configure the views of conf.
+	viewOptions []flat.Option
+
+	// applied maps the fields set by the latest Parse to their env var name.
+	applied map[string]string
 }
 
 // Walk captures the conf reference so Parse can re-flatten and expand
@@ -35,6 +118,11 @@ func (v *visitor) Walk(conf any) error {
 	return nil
 }
 
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *visitor) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
 func (v *visitor) Visit(f flat.Fields) error {
 	v.fields = f
 
@@ -45,7 +133,7 @@ func (v *visitor) Visit(f flat.Fields) error {
 	// are expanded — we only resolve names for entries already present.
 	var nameMap map[string]string
 	if v.conf != nil {
-		m, err := flat.ExpandContainersFromKeys(v.conf, v.prefix, nil)
+		m, err := flat.ExpandContainersFromKeys(v.conf, v.prefix, nil, v.viewOptions...)
 		if err != nil {
 			return err
 		}
@@ -66,10 +154,38 @@ func (v *visitor) Visit(f flat.Fields) error {
 		}
 		f.Meta()[tag] = name
 	}
+	stampFileNames(v.fields)
 
 	return nil
 }
 
+// readsFile reports whether the field ft, or the entries of the map ft, read
+// their value from a file.
+func readsFile(ft reflect.StructField) bool {
+	return ft.Tag.Get(fileTag) == "true"
+}
+
+// stampFileNames records the file env var of every field with an env var that
+// reads its value from a file, unless another field already uses that name,
+// as CONFIG_FILE does for a ConfigFile field next to a Config field.
+func stampFileNames(fields flat.Fields) {
+	names := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		names[f.Meta()[tag]] = struct{}{}
+	}
+	for _, f := range fields {
+		name := f.Meta()[tag]
+		if name == "" || name == "-" || !readsFile(f.FieldType()) {
+			continue
+		}
+		if _, taken := names[name+fileSuffix]; taken {
+			delete(f.Meta(), FileMetaKey)
+			continue
+		}
+		f.Meta()[FileMetaKey] = name + fileSuffix
+	}
+}
+
 // buildEnvName constructs environment variable name considering parent struct tags
 func (v *visitor) buildEnvName(f flat.Field) string {
 	fieldName := f.Name()
@@ -118,13 +234,16 @@ func toSnakeCase(s string) string {
 func (v *visitor) Parse() error {
 	// Expand slices/maps based on env-vars BEFORE re-flattening so newly
 	// created entries are visible to subsequent flat.View calls.
-	envKeys := envKeys()
-	nameMap, err := flat.ExpandContainersFromKeys(v.conf, v.prefix, envKeys)
+	// Entries set through a file, such as ITEMS_0_PASSWORD_FILE, are
+	// expanded like those set directly, and only under that name: the
+	// variable LABELS_TEAM_FILE makes the map entry TEAM, not TEAM_FILE.
+	opts := append(slices.Clip(v.viewOptions), flat.WithKeySuffix(fileSuffix, readsFile))
+	nameMap, err := flat.ExpandContainersFromKeys(v.conf, v.prefix, v.source.Keys(), opts...)
 	if err != nil {
 		return err
 	}
 
-	fields, err := flat.View(v.conf)
+	fields, err := flat.View(v.conf, v.viewOptions...)
 	if err != nil {
 		return err
 	}
@@ -144,7 +263,9 @@ func (v *visitor) Parse() error {
 		}
 		f.Meta()[tag] = name
 	}
+	stampFileNames(fields)
 	v.fields = fields
+	v.applied = make(map[string]string)
 
 	for _, f := range v.fields {
 		name, ok := f.Meta()[tag]
@@ -152,7 +273,10 @@ func (v *visitor) Parse() error {
 			continue
 		}
 
-		value, ok := os.LookupEnv(name)
+		value, source, ok, err := v.lookup(name, f.Meta()[FileMetaKey])
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name(), err)
+		}
 		if !ok {
 			continue
 		}
@@ -160,19 +284,44 @@ func (v *visitor) Parse() error {
 		if err := f.Set(value); err != nil {
 			return err
 		}
+		v.applied[f.Name()] = source
 	}
 
 	return nil
 }
 
-// envKeys returns the names of currently-set environment variables.
-func envKeys() []string {
-	envs := os.Environ()
-	keys := make([]string, 0, len(envs))
-	for _, e := range envs {
-		if eq := strings.IndexByte(e, '='); eq >= 0 {
-			keys = append(keys, e[:eq])
-		}
+// lookup returns the value of the env var name or, when it is unset, the
+// content of the file named by the env var fileName without its trailing
+// newline, along with the env var it came from. Setting both is an error.
+func (v *visitor) lookup(name, fileName string) (value, source string, ok bool, err error) {
+	value, ok = v.source.LookupEnv(name)
+	if fileName == "" {
+		return value, name, ok, nil
 	}
-	return keys
+	path, fileOK := v.source.LookupEnv(fileName)
+	if !fileOK {
+		return value, name, ok, nil
+	}
+	if ok {
+		return "", "", false, fmt.Errorf("env: both %s and %s are set", name, fileName)
+	}
+
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", "", false, fmt.Errorf("env %s: %w", fileName, err)
+	}
+	value = strings.TrimSuffix(string(content), "\n")
+	value = strings.TrimSuffix(value, "\r")
+	return value, fileName, true, nil
+}
+
+// SetFields returns the fields the last Parse read from an env var.
+func (v *visitor) SetFields() []string {
+	return slices.Sorted(maps.Keys(v.applied))
+}
+
+// Source reports the environment variable a field was read from, which is
+// the one ending in _FILE for values read from a file.
+func (v *visitor) Source(fieldName string) plugins.Source {
+	return plugins.Source{Plugin: tag, Name: v.applied[fieldName]}
 }
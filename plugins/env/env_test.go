@@ -1,12 +1,19 @@
 package env_test
 
 import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/internal/f"
 	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
 	"github.com/sxwebdev/xconfig/plugins/env"
 )
 
@@ -475,3 +482,217 @@ func TestEnvMapAndSliceCombined(t *testing.T) {
 	}
 	testutil.Equal(t, expect, value)
 }
+
+func TestEnvMapWholeValue(t *testing.T) {
+	t.Setenv("TAGS", "team=core, tier=1")
+	t.Setenv("TAGS_EXTRA", "per-key")
+	t.Setenv("LIMITS", "cpu:2;memory:512")
+
+	value := struct {
+		Tags   map[string]string
+		Limits map[string]int `env:"LIMITS" xconfig_sep:";" xconfig_kvsep:":"`
+	}{Tags: map[string]string{"stale": "dropped"}}
+	conf, err := xconfig.Custom(&value, env.New(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The whole value replaces the entries; per-key variables still apply.
+	testutil.Equal(t, map[string]string{"team": "core", "tier": "1", "EXTRA": "per-key"}, value.Tags)
+	testutil.Equal(t, map[string]int{"cpu": 2, "memory": 512}, value.Limits)
+}
+
+type arrayConfig struct {
+	Shards [2]item
+	Zones  [3]string
+}
+
+func TestEnvArrays(t *testing.T) {
+	t.Setenv("SHARDS_1_KEY_1", "b")
+	t.Setenv("ZONES", "eu-1,eu-2,eu-3")
+
+	value := arrayConfig{}
+	conf, err := xconfig.Custom(&value, env.New(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := arrayConfig{
+		Shards: [2]item{{}, {Key1: "b"}},
+		Zones:  [3]string{"eu-1", "eu-2", "eu-3"},
+	}
+	testutil.Equal(t, expect, value)
+}
+
+func TestEnvArrayIndexOutOfRange(t *testing.T) {
+	t.Setenv("SHARDS_2_KEY_1", "c")
+
+	value := arrayConfig{}
+	conf, err := xconfig.Custom(&value, env.New(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); !errors.Is(err, flat.ErrArrayIndex) {
+		t.Fatalf("Parse() error = %v, want ErrArrayIndex", err)
+	}
+}
+
+type fileConfig struct {
+	Password   string `env_file:"true"`
+	ConfigFile string
+	Config     string `env_file:"true"`
+	Log        string
+	Nodes      []fileItem
+	Labels     map[string]fileItem
+}
+
+type fileItem struct {
+	Key1 string `env_file:"true"`
+	Key2 string `env_file:"true"`
+}
+
+func writeSecret(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "secret")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestEnvFileSuffix(t *testing.T) {
+	t.Setenv("XCONFIG_TEST_PASSWORD_FILE", writeSecret(t, "s3cret\n"))
+	t.Setenv("XCONFIG_TEST_NODES_1_KEY_1_FILE", writeSecret(t, "node-key\r\n"))
+	t.Setenv("XCONFIG_TEST_LABELS_EU_KEY_2_FILE", writeSecret(t, "label-key"))
+	// CONFIG_FILE is the env var of ConfigFile, not a file for Config.
+	t.Setenv("XCONFIG_TEST_CONFIG_FILE", "/etc/app.yaml")
+	// Log does not read a file, so LOG_FILE is left alone.
+	t.Setenv("XCONFIG_TEST_LOG", "debug")
+	t.Setenv("XCONFIG_TEST_LOG_FILE", "/var/log/app.log")
+
+	value := fileConfig{}
+	conf, err := xconfig.Custom(&value, env.New(testEnvPrefix))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, "s3cret", value.Password)
+	testutil.Equal(t, "/etc/app.yaml", value.ConfigFile)
+	testutil.Equal(t, "", value.Config)
+	testutil.Equal(t, "debug", value.Log)
+	testutil.Equal(t, []fileItem{{}, {Key1: "node-key"}}, value.Nodes)
+	testutil.Equal(t, map[string]fileItem{"EU": {Key2: "label-key"}}, value.Labels)
+	testutil.Equal(t, []plugins.Source{{Plugin: "env", Name: "XCONFIG_TEST_PASSWORD_FILE"}}, conf.Explain("Password"))
+
+	fields, err := flat.View(&fileConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := env.New(testEnvPrefix).(plugins.Visitor).Visit(fields); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range fields {
+		switch f.Name() {
+		case "Password":
+			testutil.Equal(t, "XCONFIG_TEST_PASSWORD_FILE", f.Meta()[env.FileMetaKey])
+		case "Config", "Log":
+			_, ok := f.Meta()[env.FileMetaKey]
+			testutil.Equal(t, false, ok)
+		}
+	}
+}
+
+func TestEnvFileSuffixPrimitiveMap(t *testing.T) {
+	t.Parallel()
+
+	source := env.Map{
+		"XCONFIG_TEST_LABELS_TEAM_FILE": writeSecret(t, "platform\n"),
+		"XCONFIG_TEST_LABELS_ENV":       "prod",
+		"XCONFIG_TEST_TAGS_LOG_FILE":    "/var/log/app.log",
+	}
+
+	type config struct {
+		Labels map[string]string `env_file:"true"`
+		Tags   map[string]string
+	}
+
+	value := config{}
+	conf, err := xconfig.Custom(&value, env.NewWithSource(testEnvPrefix, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, map[string]string{"TEAM": "platform", "ENV": "prod"}, value.Labels)
+	testutil.Equal(t, map[string]string{"LOG_FILE": "/var/log/app.log"}, value.Tags)
+	testutil.Equal(t, []plugins.Source{{Plugin: "env", Name: "XCONFIG_TEST_LABELS_TEAM_FILE"}}, conf.Explain("Labels.TEAM"))
+}
+
+func TestEnvFileSuffixErrors(t *testing.T) {
+	t.Run("both set", func(t *testing.T) {
+		t.Setenv("PASSWORD", "direct")
+		t.Setenv("PASSWORD_FILE", writeSecret(t, "from-file"))
+
+		conf, err := xconfig.Custom(&fileConfig{}, env.New(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conf.Parse()
+		if err == nil || !strings.Contains(err.Error(), "both PASSWORD and PASSWORD_FILE are set") {
+			t.Fatalf("Parse() error = %v, want both variables named", err)
+		}
+	})
+
+	t.Run("unreadable file", func(t *testing.T) {
+		t.Setenv("PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		conf, err := xconfig.Custom(&fileConfig{}, env.New(""))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := conf.Parse(); !errors.Is(err, fs.ErrNotExist) {
+			t.Fatalf("Parse() error = %v, want fs.ErrNotExist", err)
+		}
+	})
+}
+
+func TestEnvWithSource(t *testing.T) {
+	t.Parallel()
+
+	source := env.Map{
+		"XCONFIG_TEST_PASSWORD_FILE":       writeSecret(t, "from-file\n"),
+		"XCONFIG_TEST_CONFIG":              "tenant-a",
+		"XCONFIG_TEST_NODES_1_KEY_1":       "node",
+		"XCONFIG_TEST_LABELS_EU_KEY_2":     "label",
+		"XCONFIG_TEST_LABELS_US_KEY_1":     "other",
+		"UNRELATED_VARIABLE_OF_THE_TENANT": "ignored",
+	}
+
+	value := fileConfig{}
+	conf, err := xconfig.Custom(&value, env.NewWithSource(testEnvPrefix, source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	expect := fileConfig{
+		Password: "from-file",
+		Config:   "tenant-a",
+		Nodes:    []fileItem{{}, {Key1: "node"}},
+		Labels:   map[string]fileItem{"EU": {Key2: "label"}, "US": {Key1: "other"}},
+	}
+	testutil.Equal(t, expect, value)
+}
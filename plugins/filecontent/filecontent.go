@@ -0,0 +1,170 @@
+// Package filecontent reads the files of types.FileContent fields for xconfig.
+package filecontent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+const source = "filecontent"
+
+// New returns a plugin reading the file of every types.FileContent field
+// into its Content. Parse fails when a configured file cannot be read. Refresh
+// re-reads the files of the working copy, reports the fields whose content
+// changed, and keeps the previous content with a warning when a file cannot
+// be read. It must be registered after the plugins that may set the paths.
+// Both view the config again, so the entries of lists and maps added by
+// earlier plugins are read as well.
+func New() plugins.Plugin {
+	return &visitor{paths: map[string]string{}}
+}
+
+// Uses reports whether the type of conf holds a types.FileContent, so callers
+// register the plugin, which is refreshable, only when it has work to do.
+func Uses(conf any) bool {
+	return holdsFileContent(reflect.TypeOf(conf), map[reflect.Type]bool{})
+}
+
+var fileContentType = reflect.TypeFor[types.FileContent]()
+
+func holdsFileContent(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if t == nil || seen[t] {
+		return false
+	}
+	seen[t] = true
+	if t == fileContentType {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return holdsFileContent(t.Elem(), seen)
+	case reflect.Struct:
+		for i := range t.NumField() {
+			if holdsFileContent(t.Field(i).Type, seen) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type visitor struct {
+	conf any
+	// viewOptions configure the views of the configuration.
+	viewOptions []flat.Option
+	// paths holds the file each field was last read from, by flat name.
+	paths map[string]string
+}
+
+func (v *visitor) Walk(conf any) error {
+	v.conf = conf
+	return nil
+}
+
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *visitor) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+func (v *visitor) Parse() error {
+	fields, err := flat.View(v.conf, v.viewOptions...)
+	if err != nil {
+		return err
+	}
+	clear(v.paths)
+	for _, f := range fields {
+		if _, err := v.read(f); err != nil {
+			return fmt.Errorf("field %s: %w", f.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (v *visitor) Refresh(ctx context.Context, target any) (plugins.RefreshOutcome, error) {
+	var outcome plugins.RefreshOutcome
+	if err := ctx.Err(); err != nil {
+		return outcome, err
+	}
+
+	fields, err := flat.View(target, v.viewOptions...)
+	if err != nil {
+		return outcome, err
+	}
+	for _, f := range fields {
+		changed, err := v.read(f)
+		if err != nil {
+			outcome.Warnings = append(outcome.Warnings, fmt.Errorf("field %s: %w", f.Name(), err))
+			continue
+		}
+		if changed {
+			outcome.Changes = append(outcome.Changes, plugins.FieldChange{FieldName: f.Name()})
+		}
+	}
+	return outcome, nil
+}
+
+// SetFields returns the fields whose file the last Parse read.
+func (v *visitor) SetFields() []string {
+	return slices.Sorted(maps.Keys(v.paths))
+}
+
+// Source reports the file the content of a field was read from.
+func (v *visitor) Source(fieldName string) plugins.Source {
+	return plugins.Source{Plugin: source, Name: v.paths[fieldName]}
+}
+
+// read loads the file of f when f is a types.FileContent with a path, and
+// reports whether its content changed. Content is dropped when the path is
+// cleared.
+func (v *visitor) read(f flat.Field) (bool, error) {
+	fc, ok := fileContent(f)
+	if !ok {
+		return false, nil
+	}
+	if fc.Path == "" {
+		delete(v.paths, f.Name())
+		if fc.Content == nil {
+			return false, nil
+		}
+		fc.Content = nil
+		flat.Sync(f)
+		return true, nil
+	}
+
+	content, err := os.ReadFile(fc.Path)
+	if err != nil {
+		return false, err
+	}
+	v.paths[f.Name()] = fc.Path
+	if fc.Content != nil && bytes.Equal(fc.Content, content) {
+		return false, nil
+	}
+	fc.Content = content
+	flat.Sync(f)
+	return true, nil
+}
+
+// fileContent returns the types.FileContent f holds in place, if any.
+func fileContent(f flat.Field) (*types.FileContent, bool) {
+	rv := f.FieldValue()
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			return nil, false
+		}
+		rv = rv.Elem()
+	}
+	if rv.Type() != fileContentType || !rv.CanAddr() {
+		return nil, false
+	}
+	return rv.Addr().Interface().(*types.FileContent), true
+}
@@ -0,0 +1,211 @@
+package filecontent_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/filecontent"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+type tlsConfig struct {
+	Cert  types.FileContent
+	Key   types.FileContent `secret:""`
+	Token *types.FileContent
+	CA    types.FileContent
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileContentParseAndRefresh(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	writeFile(t, certPath, "cert-1")
+	writeFile(t, keyPath, "key-1")
+
+	t.Setenv("CERT", certPath)
+	t.Setenv("KEY", keyPath)
+
+	var cfg tlsConfig
+	manager, err := xconfig.Load(&cfg, xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, "cert-1", string(cfg.Cert.Content))
+	testutil.Equal(t, "key-1", string(cfg.Key.Content))
+	testutil.Equal(t, true, cfg.Token == nil)
+	testutil.Equal(t, true, cfg.CA.Content == nil)
+	testutil.Equal(t, []plugins.Source{
+		{Plugin: "env", Name: "CERT"},
+		{Plugin: "filecontent", Name: certPath},
+	}, manager.Explain("Cert"))
+
+	if result := manager.Refresh(t.Context()); result.Published || result.Err != nil {
+		t.Fatalf("Refresh() of unchanged files = %+v, want nothing published", result)
+	}
+
+	writeFile(t, certPath, "cert-2")
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Cert"}}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[tlsConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "cert-2", string(snapshot.Cert.Content))
+	testutil.Equal(t, "key-1", string(snapshot.Key.Content))
+
+	// A file missing during rotation keeps the published content.
+	if err := os.Remove(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	result = manager.Refresh(t.Context())
+	if result.Err != nil || result.Published || len(result.Warnings) != 1 {
+		t.Fatalf("Refresh() with a missing file = %+v, want one warning", result)
+	}
+	snapshot, err = xconfig.Snapshot[tlsConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "key-1", string(snapshot.Key.Content))
+}
+
+func TestFileContentReadsListAndMapEntries(t *testing.T) {
+	type server struct {
+		Cert types.FileContent
+	}
+	type config struct {
+		Servers []server
+		Files   map[string]types.FileContent
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	caPath := filepath.Join(dir, "ca.crt")
+	writeFile(t, certPath, "cert")
+	writeFile(t, caPath, "ca")
+
+	// The entries are created by env, after the config was first viewed.
+	t.Setenv("SERVERS_0_CERT", certPath)
+	t.Setenv("FILES", "ca="+caPath)
+
+	var cfg config
+	manager, err := xconfig.Load(&cfg, xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, 1, len(cfg.Servers))
+	testutil.Equal(t, "cert", string(cfg.Servers[0].Cert.Content))
+	testutil.Equal(t, "ca", string(cfg.Files["ca"].Content))
+
+	writeFile(t, caPath, "ca-2")
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err := xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "ca-2", string(snapshot.Files["ca"].Content))
+	testutil.Equal(t, "cert", string(snapshot.Servers[0].Cert.Content))
+}
+
+func TestFileContentParseFailsOnMissingFile(t *testing.T) {
+	t.Setenv("CERT", filepath.Join(t.TempDir(), "missing.crt"))
+
+	var cfg tlsConfig
+	_, err := xconfig.Load(&cfg, xconfig.WithSkipFlags())
+	if err == nil || !strings.Contains(err.Error(), "field Cert") {
+		t.Fatalf("Load() error = %v, want an error naming field Cert", err)
+	}
+}
+
+func TestFileContentRedactsSecrets(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.crt")
+	keyPath := filepath.Join(dir, "tls.key")
+	writeFile(t, certPath, "cert-content")
+	writeFile(t, keyPath, "key-content")
+
+	type config struct {
+		Cert types.FileContent
+		Key  types.FileContent `secret:""`
+	}
+	cfg := config{
+		Cert: types.FileContent{Path: certPath},
+		Key:  types.FileContent{Path: keyPath},
+	}
+	manager, err := xconfig.Load(&cfg, xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "key-content", string(cfg.Key.Content))
+
+	usage, err := manager.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Documentation does not read the files, which need not exist.
+	if err := os.Remove(certPath); err != nil {
+		t.Fatal(err)
+	}
+	markdown, err := xconfig.GenerateMarkdown(&config{
+		Cert: types.FileContent{Path: certPath},
+		Key:  types.FileContent{Path: keyPath},
+	}, xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(markdown, certPath) {
+		t.Errorf("markdown does not show the certificate path:\n%s", markdown)
+	}
+	for name, out := range map[string]string{"usage": usage, "markdown": markdown} {
+		for _, hidden := range []string{keyPath, "cert-content", "key-content"} {
+			if strings.Contains(out, hidden) {
+				t.Errorf("%s shows %q:\n%s", name, hidden, out)
+			}
+		}
+	}
+}
+
+func TestUses(t *testing.T) {
+	t.Parallel()
+
+	type nested struct {
+		Files []*types.FileContent
+	}
+	type withNested struct {
+		Nested *nested
+	}
+	type withMap struct {
+		Files map[string]types.FileContent
+	}
+	type without struct {
+		Path  string
+		Paths map[string]string
+	}
+
+	testutil.Equal(t, true, filecontent.Uses(&tlsConfig{}))
+	testutil.Equal(t, true, filecontent.Uses(&withNested{}))
+	testutil.Equal(t, true, filecontent.Uses(&withMap{}))
+	testutil.Equal(t, false, filecontent.Uses(&without{}))
+}
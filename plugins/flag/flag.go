@@ -47,8 +47,9 @@ func Standard() plugins.Plugin {
 var _ plugins.Visitor = (*visitor)(nil)
 
 type visitor struct {
-	fs   *flag.FlagSet
-	args []string
+	fs    *flag.FlagSet
+	args  []string
+	names map[string]string // flat field name -> flag name
 }
 
 func (v *visitor) Parse() error {
@@ -61,7 +62,26 @@ func (v *visitor) Parse() error {
 	return err
 }
 
+// SetFields returns the fields set on the command line.
+func (v *visitor) SetFields() []string {
+	fields := make(map[string]string, len(v.names))
+	for field, name := range v.names {
+		fields[name] = field
+	}
+	var set []string
+	v.fs.Visit(func(f *flag.Flag) {
+		set = append(set, fields["-"+f.Name])
+	})
+	return set
+}
+
+// Source reports the command-line flag a field was set from.
+func (v *visitor) Source(fieldName string) plugins.Source {
+	return plugins.Source{Plugin: tag, Name: v.names[fieldName]}
+}
+
 func (v *visitor) Visit(fields flat.Fields) error {
+	v.names = make(map[string]string, len(fields))
 	for _, f := range fields {
 		usage, _ := f.Tag("usage")
 
@@ -77,6 +97,7 @@ func (v *visitor) Visit(fields flat.Fields) error {
 		}
 
 		f.Meta()[tag] = "-" + name
+		v.names[f.Name()] = "-" + name
 		v.fs.Var(f, name, usage)
 	}
 
@@ -87,3 +87,88 @@ func TestFlagTag(t *testing.T) {
 
 	testutil.Equal(t, expect, value)
 }
+
+func TestFlagMap(t *testing.T) {
+	t.Parallel()
+
+	value := struct {
+		Labels map[string]string `flag:"labels"`
+	}{}
+
+	fs := flag.New("testing", flag.ContinueOnError, []string{"-labels=team=core,tier=1"})
+	conf, err := xconfig.Custom(&value, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, map[string]string{"team": "core", "tier": "1"}, value.Labels)
+}
+
+func TestFlagPointerScalars(t *testing.T) {
+	t.Parallel()
+
+	value := struct {
+		Debug   *bool `flag:"debug"`
+		Verbose *bool `flag:"verbose"`
+		Workers *int  `flag:"workers"`
+		Unset   *int  `flag:"unset"`
+	}{}
+
+	fs := flag.New("testing", flag.ContinueOnError, []string{"-debug", "-verbose=false", "-workers=0"})
+	conf, err := xconfig.Custom(&value, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if value.Debug == nil || !*value.Debug {
+		t.Errorf("Debug = %v, want true", value.Debug)
+	}
+	if value.Verbose == nil || *value.Verbose {
+		t.Errorf("Verbose = %v, want explicit false", value.Verbose)
+	}
+	if value.Workers == nil || *value.Workers != 0 {
+		t.Errorf("Workers = %v, want explicit 0", value.Workers)
+	}
+	if value.Unset != nil {
+		t.Errorf("Unset = %v, want nil", *value.Unset)
+	}
+}
+
+func TestFlagArrays(t *testing.T) {
+	t.Parallel()
+
+	type shard struct {
+		Host string
+	}
+	value := struct {
+		Shards [2]shard
+		Zones  [2]string `flag:"zones"`
+	}{}
+
+	fs := flag.New("testing", flag.ContinueOnError, []string{"-shards-1-host=db2", "-zones=a,b"})
+	conf, err := xconfig.Custom(&value, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "db2", value.Shards[1].Host)
+	testutil.Equal(t, [2]string{"a", "b"}, value.Zones)
+
+	// Flags only exist for the elements of the array.
+	fs = flag.New("testing", flag.ContinueOnError, []string{"-shards-2-host=db3"})
+	conf, err = xconfig.Custom(&value, fs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := conf.Parse(); err == nil {
+		t.Fatal("Parse() error = nil for an element past the end of the array")
+	}
+}
@@ -7,8 +7,13 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/utils"
 	"github.com/sxwebdev/xconfig/plugins"
 )
 
@@ -20,6 +25,9 @@ type File struct {
 	Path      string
 	Unmarshal Unmarshal
 	Optional  bool
+
+	// glob is set when Path is a pattern added with AddGlob or AddDir.
+	glob bool
 }
 
 // Loader represents a set of file paths and the appropriate
@@ -28,8 +36,36 @@ type Loader struct {
 	decoders              map[string]Unmarshal
 	files                 []File
 	disallowUnknownFields bool
-	unknownFields         map[string][]string            // filepath -> unknown fields
-	presentFields         map[string]map[string]struct{} // filepath -> present leaf field paths
+	watch                 bool
+	watchDebounce         time.Duration
+	resolver              Resolver
+	merge                 MergeStrategy
+	profiles              []string
+	withProfiles          bool
+	fallback              Fallback
+
+	// mu guards the per-file results below, which refreshing file plugins
+	// update while readers may call GetUnknownFields or PresentFields.
+	mu            sync.RWMutex
+	unknownFields map[string][]string            // filepath -> unknown fields
+	presentFields map[string]map[string]struct{} // filepath -> present leaf field paths
+	statuses      []FileStatus                   // files read by the last Plugins call
+}
+
+// LocalProfile is the profile of the overlay loaded after those of the
+// active profiles, such as config.local.yaml, for untracked developer
+// settings.
+const LocalProfile = "local"
+
+// FileStatus describes a file read by the plugins of a Loader.
+type FileStatus struct {
+	// Path is the path of the file.
+	Path string
+	// Profile is the profile of an overlay file, LocalProfile for the local
+	// overlay, or empty for a file added to the loader.
+	Profile string
+	// Found reports whether the file exists.
+	Found bool
 }
 
 func NewLoader(decoders map[string]Unmarshal) (*Loader, error) {
@@ -62,7 +98,7 @@ func (f *Loader) AddFile(path string, optional bool) error {
 		return fmt.Errorf("no decoder registered for format %q", fileExt)
 	}
 
-	f.files = append(f.files, File{path, decoder, optional})
+	f.files = append(f.files, File{Path: path, Unmarshal: decoder, Optional: optional})
 
 	return nil
 }
@@ -107,9 +143,89 @@ func (f *Loader) DisallowUnknownFields(disallow bool) {
 	f.disallowUnknownFields = disallow
 }
 
+// WatchFiles enables event-driven refresh of the loaded files. Their plugins
+// then notify Config.StartRefresh as soon as a file, its directory entry or
+// the target of a symlink pointing at it changes, instead of waiting for the
+// next tick. Events are debounced until no new one arrived for the given
+// period; a non-positive debounce uses DefaultWatchDebounce.
+func (f *Loader) WatchFiles(debounce time.Duration) {
+	f.watch = true
+	f.watchDebounce = debounce
+}
+
+// Interpolate makes the loaded files expand the ${VAR} references of their
+// string values with resolver, as described by Resolver. A nil resolver turns
+// interpolation off.
+func (f *Loader) Interpolate(resolver Resolver) {
+	f.resolver = resolver
+}
+
+// Merge sets the strategy combining the maps and lists of a file with those
+// of the files loaded before it, for the fields without a merge tag. It is
+// MergeDeep by default.
+func (f *Loader) Merge(strategy MergeStrategy) {
+	f.merge = strategy
+}
+
+// Profiles makes every file added to the loader, such as config.yaml, be
+// followed by its overlays config.<profile>.yaml for each of profiles in
+// order, and config.local.yaml. Overlays are optional and use the decoder of
+// their file; values of later files override those of earlier ones. Empty
+// profile names are ignored.
+func (f *Loader) Profiles(profiles ...string) {
+	f.withProfiles = true
+	f.profiles = nil
+	for _, profile := range profiles {
+		if profile = strings.TrimSpace(profile); profile != "" && !slices.Contains(f.profiles, profile) {
+			f.profiles = append(f.profiles, profile)
+		}
+	}
+}
+
+// Fallback is a function filling the fields of conf that no file holds, such
+// as their defaults. present holds the key paths the files hold, and opts are
+// those of the views of conf.
+type Fallback func(conf any, present map[string]struct{}, opts []flat.Option) error
+
+// Fallback sets the function filling the fields no file holds, such as
+// defaults.Fill, when the files are merged again on refresh. The files are
+// merged onto the configuration as it was before them, and fallback is only
+// applied to the result, so a file never merges into the values it fills and
+// a key removed from a file takes the value fallback gives it.
+func (f *Loader) Fallback(fallback Fallback) {
+	f.fallback = fallback
+}
+
+// Files returns the files read by the plugins of the last Plugins call, in
+// loading order and including profile overlays, and whether each was found.
+func (f *Loader) Files() []FileStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return slices.Clone(f.statuses)
+}
+
+// overlays returns file followed by its profile overlays, when profiles are
+// enabled.
+func (f *Loader) overlays(file File) []FileStatus {
+	files := []FileStatus{{Path: file.Path}}
+	if !f.withProfiles {
+		return files
+	}
+	ext := filepath.Ext(file.Path)
+	stem := strings.TrimSuffix(file.Path, ext)
+	for _, profile := range append(slices.Clone(f.profiles), LocalProfile) {
+		files = append(files, FileStatus{Path: stem + "." + profile + ext, Profile: profile})
+	}
+	return files
+}
+
 // GetUnknownFields returns all unknown fields found in configuration files.
 // Returns a map where keys are file paths and values are slices of unknown field paths.
 func (f *Loader) GetUnknownFields() map[string][]string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
 	if f.unknownFields == nil {
 		return make(map[string][]string)
 	}
@@ -125,8 +241,35 @@ func (f *Loader) GetUnknownFields() map[string][]string {
 	return result
 }
 
+func (f *Loader) setUnknownFields(path string, fields []string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(fields) == 0 {
+		delete(f.unknownFields, path)
+		return
+	}
+	if f.unknownFields == nil {
+		f.unknownFields = make(map[string][]string)
+	}
+	f.unknownFields[path] = fields
+}
+
+func (f *Loader) setPresentFields(path string, present map[string]struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.presentFields == nil {
+		f.presentFields = make(map[string]map[string]struct{})
+	}
+	f.presentFields[path] = present
+}
+
 // ClearUnknownFields clears the list of unknown fields.
 func (f *Loader) ClearUnknownFields() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
 	f.unknownFields = make(map[string][]string)
 }
 
@@ -134,7 +277,14 @@ func (f *Loader) ClearUnknownFields() {
 // in loaded configuration files.
 func (f *Loader) PresentFields() map[string]struct{} {
 	result := make(map[string]struct{})
-	if f == nil || f.presentFields == nil {
+	if f == nil {
+		return result
+	}
+
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if f.presentFields == nil {
 		return result
 	}
 	for _, perFile := range f.presentFields {
@@ -149,20 +299,56 @@ func (f *Loader) PresentFields() map[string]struct{} {
 // paths and unmarshal functions.
 func (f *Loader) Plugins() []plugins.Plugin {
 	ps := make([]plugins.Plugin, 0, len(f.files))
+	statuses := make([]FileStatus, 0, len(f.files))
+	group := &fileGroup{fallback: f.fallback}
 	for _, file := range f.files {
-		fp := NewPlugin(
-			file.Path,
-			file.Unmarshal,
-			Config{
+		if file.glob {
+			gp := newGlobWalker(file.Path, Config{
 				Optional:              file.Optional,
 				DisallowUnknownFields: f.disallowUnknownFields,
-			},
-			f,
-		)
+				Watch:                 f.watch,
+				WatchDebounce:         f.watchDebounce,
+				Resolver:              f.resolver,
+				Merge:                 f.merge,
+			}, f, group)
+			for _, w := range gp.walkers {
+				statuses = append(statuses, FileStatus{Path: w.filepath, Found: true})
+			}
+			if len(gp.walkers) == 0 {
+				statuses = append(statuses, FileStatus{Path: file.Path})
+			}
+			group.members = append(group.members, gp)
+			ps = append(ps, gp)
+			continue
+		}
 
-		ps = append(ps, fp)
+		for _, status := range f.overlays(file) {
+			fp := NewPlugin(
+				status.Path,
+				file.Unmarshal,
+				Config{
+					Optional:              file.Optional || status.Profile != "",
+					DisallowUnknownFields: f.disallowUnknownFields,
+					Watch:                 f.watch,
+					WatchDebounce:         f.watchDebounce,
+					Resolver:              f.resolver,
+					Merge:                 f.merge,
+				},
+				f,
+			).(*fileWalker)
+			fp.group = group
+
+			status.Found = fp.src != nil
+			statuses = append(statuses, status)
+			group.members = append(group.members, fp)
+			ps = append(ps, fp)
+		}
 	}
 
+	f.mu.Lock()
+	f.statuses = statuses
+	f.mu.Unlock()
+
 	return ps
 }
 
@@ -182,16 +368,34 @@ type Config struct {
 	Optional bool
 	// indicates if unknown fields should cause an error.
 	DisallowUnknownFields bool
+	// indicates if changes to the file should trigger a refresh.
+	Watch bool
+	// quiet period before a burst of file events triggers a refresh.
+	WatchDebounce time.Duration
+	// expands the ${VAR} references of the file when set.
+	Resolver Resolver
+	// combines the maps and lists of the file with earlier values, MergeDeep
+	// when empty.
+	Merge MergeStrategy
 }
 
 // NewPlugin returns a new file loader plugin for the given path and unmarshal function.
 func NewPlugin(path string, unmarshal Unmarshal, config Config, loader *Loader) plugins.Plugin {
-	plug := &walker{
+	if config.Resolver != nil {
+		unmarshal = expandedUnmarshal(unmarshal)
+	}
+	plug := &fileWalker{walker{
 		filepath:              path,
 		unmarshal:             unmarshal,
+		optional:              config.Optional,
 		disallowUnknownFields: config.DisallowUnknownFields,
+		watch:                 config.Watch,
+		watchDebounce:         config.WatchDebounce,
+		resolver:              config.Resolver,
+		merge:                 config.Merge,
 		loader:                loader,
-	}
+	}}
+	plug.group = &fileGroup{members: []groupMember{plug}}
 
 	src, err := os.Open(path)
 
@@ -213,8 +417,34 @@ type walker struct {
 	src                   io.Reader
 	conf                  any
 	unmarshal             Unmarshal
+	optional              bool
 	disallowUnknownFields bool
+	watch                 bool
+	watchDebounce         time.Duration
+	resolver              Resolver
+	merge                 MergeStrategy
 	loader                *Loader
+	group                 *fileGroup
+	viewOptions           []flat.Option
+
+	// last holds the file content that was most recently applied, so Refresh
+	// can tell which values the file itself changed. committed holds the
+	// content applied to the working copy kept by the last refresh cycle, so
+	// last can be rolled back when a cycle is discarded.
+	last      []byte
+	committed []byte
+
+	// present holds the key paths of the file recorded last, and pending
+	// those of the content applied by a refresh, recorded by EndRefresh when
+	// the working copy is kept.
+	present map[string]struct{}
+	pending *fileRecord
+
+	// set holds the flat names of the fields the last Parse set.
+	set []string
+	// overrides holds the fields sources after the file set, given before
+	// each refresh.
+	overrides map[string]struct{}
 
 	err error
 }
@@ -228,6 +458,16 @@ func (v *walker) Walk(conf any) error {
 	return v.err
 }
 
+// SetViewOptions sets the options the file views the configuration with.
+func (v *walker) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// Source reports the loaded file as the origin of the values it applied.
+func (v *walker) Source(string) plugins.Source {
+	return plugins.Source{Plugin: "file", Name: v.filepath}
+}
+
 func (v *walker) Parse() error {
 	if v.err != nil {
 		return v.err
@@ -242,46 +482,147 @@ func (v *walker) Parse() error {
 		return err
 	}
 
+	if err := v.close(); err != nil {
+		return err
+	}
+
+	src, err = v.interpolate(src, v.conf)
+	if err != nil {
+		return err
+	}
+
+	record, err := v.inspect(src, v.conf)
+	if err != nil {
+		return err
+	}
+	v.record(record)
+
+	set, err := v.setFields(src, v.conf, record.present)
+	if err != nil {
+		return err
+	}
+	v.set = set
+	v.last = src
+	v.committed = src
+	return nil
+}
+
+// close closes the source of the walker when it is an io.Closer.
+func (v *walker) close() error {
 	if closer, ok := v.src.(io.Closer); ok {
-		err := closer.Close()
+		return closer.Close()
+	}
+	return nil
+}
+
+// SetFields returns the fields the last Parse set, those the file holds.
+func (v *walker) SetFields() []string {
+	return v.set
+}
+
+// setFields decodes src into conf and returns the flat names of the fields
+// the file holds, given by the key paths present, or, for a format whose keys
+// cannot be read, of the fields whose value changed.
+func (v *walker) setFields(src []byte, conf any, present map[string]struct{}) ([]string, error) {
+	if present == nil {
+		before, err := fieldValues(conf, v.viewOptions)
+		if err != nil {
+			return nil, err
+		}
+		if err := v.decode(src, conf); err != nil {
+			return nil, err
+		}
+		changes, err := changedFields(before, conf, v.viewOptions)
 		if err != nil {
-			return err
+			return nil, err
 		}
+		names := make([]string, len(changes))
+		for i, change := range changes {
+			names[i] = change.FieldName
+		}
+		return names, nil
 	}
 
-	// Track which leaf fields were explicitly present in the config file.
-	if v.loader != nil {
-		present, err := findPresentFields(src, v.unmarshal)
-		if err == nil {
-			if v.loader.presentFields == nil {
-				v.loader.presentFields = make(map[string]map[string]struct{})
-			}
-			v.loader.presentFields[v.filepath] = present
+	if err := v.decode(src, conf); err != nil {
+		return nil, err
+	}
+	fields, err := flat.View(conf, v.viewOptions...)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, f := range fields {
+		if p, ok := utils.ConfigPath(conf, f.Name()); ok && utils.HoldsPath(present, p) {
+			names = append(names, f.Name())
 		}
 	}
+	return names, nil
+}
+
+// fileRecord holds what a file content tells about the configuration: the
+// key paths it holds, nil when its format cannot be read so, and its unknown
+// fields, when they could be checked.
+type fileRecord struct {
+	present map[string]struct{}
+	unknown []string
+	checked bool
+}
+
+// inspect finds the leaf fields present in src and the unknown fields it
+// contains, failing when unknown fields are disallowed.
+func (v *walker) inspect(src []byte, conf any) (fileRecord, error) {
+	var record fileRecord
+
+	// Track which leaf fields were explicitly present in the config file.
+	if present, err := findPresentFields(src, conf, v.unmarshal); err == nil {
+		record.present = present
+	}
 
 	// Check for unknown fields if validation is enabled
 	if v.disallowUnknownFields || v.loader != nil {
-		unknownFields, err := findUnknownFields(src, v.conf, v.unmarshal)
+		unknownFields, err := findUnknownFields(src, conf, v.unmarshal)
 		if err != nil {
 			// If we can't validate, just continue with unmarshaling
 			// This allows non-JSON formats to work
-		} else if len(unknownFields) > 0 {
-			// Store unknown fields in loader
-			if v.loader != nil {
-				v.loader.unknownFields[v.filepath] = unknownFields
-			}
-
-			// Return error if disallowed
-			if v.disallowUnknownFields {
-				return &UnknownFieldsError{
-					Fields: map[string][]string{
-						v.filepath: unknownFields,
-					},
-				}
+			return record, nil
+		}
+		record.unknown = unknownFields
+		record.checked = true
+
+		// Return error if disallowed
+		if len(unknownFields) > 0 && v.disallowUnknownFields {
+			return record, &UnknownFieldsError{
+				Fields: map[string][]string{
+					v.filepath: unknownFields,
+				},
 			}
 		}
 	}
 
-	return v.unmarshal(src, v.conf)
+	return record, nil
+}
+
+// record keeps the present and unknown fields of the applied file content,
+// storing them in the loader.
+func (v *walker) record(record fileRecord) {
+	v.present = record.present
+	if v.loader == nil {
+		return
+	}
+	if record.present != nil {
+		v.loader.setPresentFields(v.filepath, record.present)
+	}
+	if record.checked {
+		v.loader.setUnknownFields(v.filepath, record.unknown)
+	}
+}
+
+// holds reports whether the file content applied last, including by a
+// refresh not ended yet, holds the key path configPath or a key within it.
+func (v *walker) holds(configPath string) bool {
+	present := v.present
+	if v.pending != nil {
+		present = v.pending.present
+	}
+	return utils.HoldsPath(present, configPath)
 }
@@ -3,6 +3,7 @@ package loader_test
 import (
 	"encoding/json"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/sxwebdev/xconfig"
@@ -60,3 +61,46 @@ func TestFiles(t *testing.T) {
 
 	testutil.Equal(t, expect, value)
 }
+
+func TestFilesProfiles(t *testing.T) {
+	t.Parallel()
+
+	type profiled struct {
+		Host  string
+		Port  int
+		Debug bool
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"config.json":         `{"Host": "localhost", "Port": 8080, "Debug": true}`,
+		"config.staging.json": `{"Host": "staging.internal", "Debug": false}`,
+		"config.local.json":   `{"Port": 9090}`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(filepath.Join(dir, "config.json"), false); err != nil {
+		t.Fatal(err)
+	}
+	l.Profiles("base", " staging ", "base", "")
+
+	var value profiled
+	if _, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv()); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, profiled{Host: "staging.internal", Port: 9090}, value)
+
+	testutil.Equal(t, []loader.FileStatus{
+		{Path: filepath.Join(dir, "config.json"), Found: true},
+		{Path: filepath.Join(dir, "config.base.json"), Profile: "base"},
+		{Path: filepath.Join(dir, "config.staging.json"), Profile: "staging", Found: true},
+		{Path: filepath.Join(dir, "config.local.json"), Profile: loader.LocalProfile, Found: true},
+	}, l.Files())
+}
@@ -0,0 +1,339 @@
+package loader
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/utils"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+// AddGlob appends the files matching pattern, such as "conf.d/*.yaml", in
+// lexical order, so drop-in files can be numbered like 10-db.yaml. Each file
+// is decoded by the decoder registered for its extension; files without one
+// are skipped. Every file is tracked on its own by GetUnknownFields,
+// PresentFields and Files. The pattern is expanded again on every refresh:
+// added files are applied, and the values of removed ones are reverted and
+// they no longer count as present. A pattern that matches no file fails the load
+// unless optional is set. Profile overlays are not loaded for these files.
+func (f *Loader) AddGlob(pattern string, optional bool) error {
+	if pattern == "" {
+		return nil
+	}
+	if _, err := filepath.Match(pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	if ext := strings.TrimPrefix(filepath.Ext(pattern), "."); ext != "" && !hasMeta(ext) {
+		if _, ok := f.decoders[ext]; !ok {
+			return fmt.Errorf("no decoder registered for format %q", ext)
+		}
+	}
+
+	f.files = append(f.files, File{Path: pattern, Optional: optional, glob: true})
+
+	return nil
+}
+
+// AddDir appends the files of dir matching pattern, as AddGlob does. An empty
+// pattern matches every file with a registered decoder.
+func (f *Loader) AddDir(dir, pattern string, optional bool) error {
+	if pattern == "" {
+		pattern = "*"
+	}
+	return f.AddGlob(filepath.Join(dir, pattern), optional)
+}
+
+// hasMeta reports whether path holds characters special to filepath.Match.
+func hasMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
+
+// expand returns the regular files matching pattern that have a registered
+// decoder, in lexical order.
+func (f *Loader) expand(pattern string) ([]File, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(matches)
+
+	files := make([]File, 0, len(matches))
+	for _, path := range matches {
+		if info, err := os.Stat(path); err != nil || info.IsDir() {
+			continue
+		}
+		decoder, ok := f.decoders[strings.TrimPrefix(filepath.Ext(path), ".")]
+		if !ok {
+			continue
+		}
+		files = append(files, File{Path: path, Unmarshal: decoder, Optional: true})
+	}
+	return files, nil
+}
+
+// forget drops what was recorded for a file that no longer exists.
+func (f *Loader) forget(path string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.presentFields, path)
+	delete(f.unknownFields, path)
+}
+
+// globWalker loads the files matching a pattern, each with a file walker.
+type globWalker struct {
+	pattern  string
+	optional bool
+	config   Config
+	loader   *Loader
+	group    *fileGroup
+
+	conf    any
+	walkers []*fileWalker
+	err     error
+
+	// refreshing holds the files of a refresh not ended yet, those matching
+	// the pattern and the removed ones, in order.
+	refreshing []*fileWalker
+	removed    []*fileWalker
+	overrides  map[string]struct{}
+
+	viewOptions []flat.Option
+}
+
+var (
+	_ plugins.Notifier         = (*globWalker)(nil)
+	_ plugins.RefreshCommitter = (*globWalker)(nil)
+	_ plugins.OverrideReceiver = (*globWalker)(nil)
+	_ plugins.SourceReporter   = (*globWalker)(nil)
+)
+
+func newGlobWalker(pattern string, config Config, loader *Loader, group *fileGroup) *globWalker {
+	v := &globWalker{pattern: pattern, optional: config.Optional, config: config, loader: loader, group: group}
+
+	files, err := v.expand()
+	if err != nil {
+		v.err = err
+		return v
+	}
+	for _, file := range files {
+		v.walkers = append(v.walkers, v.newWalker(file))
+	}
+	return v
+}
+
+// expand returns the files matching the pattern, failing when there is none
+// and the pattern is not optional.
+func (v *globWalker) expand() ([]File, error) {
+	files, err := v.loader.expand(v.pattern)
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 && !v.optional {
+		return nil, fmt.Errorf("loader: no files match %q", v.pattern)
+	}
+	return files, nil
+}
+
+func (v *globWalker) newWalker(file File) *fileWalker {
+	config := v.config
+	config.Optional = true
+	config.Watch = false
+	w := NewPlugin(file.Path, file.Unmarshal, config, v.loader).(*fileWalker)
+	w.group = v.group
+	w.SetViewOptions(v.viewOptions)
+	return w
+}
+
+// current returns the files of the refresh under way, or else the files
+// matching the pattern.
+func (v *globWalker) current() []*fileWalker {
+	if v.refreshing != nil {
+		return v.refreshing
+	}
+	return v.walkers
+}
+
+func (v *globWalker) files() []*walker {
+	walkers := v.current()
+	files := make([]*walker, len(walkers))
+	for i, w := range walkers {
+		files[i] = &w.walker
+	}
+	return files
+}
+
+func (v *globWalker) Walk(conf any) error {
+	if v.err != nil {
+		return v.err
+	}
+
+	v.conf = conf
+	for _, w := range v.walkers {
+		if err := w.Walk(conf); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (v *globWalker) Parse() error {
+	if v.err != nil {
+		return v.err
+	}
+
+	v.group.begin(v, v.conf)
+
+	for _, w := range v.walkers {
+		if err := w.Parse(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetFields returns the fields the files set in the last Parse.
+func (v *globWalker) SetFields() []string {
+	var names []string
+	for _, w := range v.walkers {
+		for _, name := range w.SetFields() {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// Source reports the last file that holds the field as the origin of its
+// value.
+func (v *globWalker) Source(fieldName string) plugins.Source {
+	if p, ok := utils.ConfigPath(v.conf, fieldName); ok {
+		walkers := v.current()
+		for i := len(walkers) - 1; i >= 0; i-- {
+			if walkers[i].holds(p) {
+				return walkers[i].Source(fieldName)
+			}
+		}
+	}
+	return plugins.Source{Plugin: "file", Name: v.pattern}
+}
+
+// Refresh expands the pattern again and refreshes every matching file in
+// order. A file that appeared since is merged with the others, and the values
+// of one that disappeared are reverted like the keys removed from a file.
+// The files are only swapped when the refresh is kept.
+func (v *globWalker) Refresh(ctx context.Context, target any) (plugins.RefreshOutcome, error) {
+	if err := ctx.Err(); err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+
+	files, err := v.expand()
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+
+	current := make(map[string]*fileWalker, len(v.walkers))
+	for _, w := range v.walkers {
+		current[w.filepath] = w
+	}
+	refreshing := make([]*fileWalker, 0, len(files)+len(v.walkers))
+	for _, file := range files {
+		w, ok := current[file.Path]
+		if !ok {
+			w = v.newWalker(file)
+			_ = w.close()
+		}
+		delete(current, file.Path)
+		refreshing = append(refreshing, w)
+	}
+	var removed []*fileWalker
+	for _, w := range v.walkers {
+		if _, ok := current[w.filepath]; ok {
+			removed = append(removed, w)
+			refreshing = append(refreshing, w)
+		}
+	}
+	slices.SortFunc(refreshing, func(a, b *fileWalker) int {
+		return strings.Compare(a.filepath, b.filepath)
+	})
+	v.refreshing, v.removed = refreshing, removed
+
+	var (
+		outcome plugins.RefreshOutcome
+		seen    = map[string]struct{}{}
+	)
+	for _, w := range refreshing {
+		w.SetOverrides(v.overrides)
+		var fileOutcome plugins.RefreshOutcome
+		if slices.Contains(removed, w) {
+			fileOutcome, err = w.remove(target)
+		} else {
+			fileOutcome, err = w.Refresh(ctx, target)
+		}
+		if err != nil {
+			return plugins.RefreshOutcome{}, err
+		}
+		for _, change := range fileOutcome.Changes {
+			if _, ok := seen[change.FieldName]; !ok {
+				seen[change.FieldName] = struct{}{}
+				outcome.Changes = append(outcome.Changes, change)
+			}
+		}
+		outcome.Warnings = append(outcome.Warnings, fileOutcome.Warnings...)
+	}
+	return outcome, nil
+}
+
+// SetViewOptions gives every file the options of the views of the
+// configuration.
+func (v *globWalker) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+	for _, w := range v.walkers {
+		w.SetViewOptions(opts)
+	}
+}
+
+// SetOverrides gives every file the fields later sources set.
+func (v *globWalker) SetOverrides(fieldNames map[string]struct{}) {
+	v.overrides = fieldNames
+}
+
+// EndRefresh ends the refresh cycle of every file. When the working copy was
+// kept, the files matching the pattern replace the previous ones and the
+// removed files are forgotten.
+func (v *globWalker) EndRefresh(kept bool) {
+	for _, w := range v.current() {
+		w.EndRefresh(kept)
+	}
+	if kept && v.refreshing != nil {
+		walkers := make([]*fileWalker, 0, len(v.refreshing))
+		for _, w := range v.refreshing {
+			if slices.Contains(v.removed, w) {
+				v.loader.forget(w.filepath)
+				continue
+			}
+			walkers = append(walkers, w)
+		}
+		v.walkers = walkers
+	}
+	v.refreshing, v.removed = nil, nil
+}
+
+// Notify implements plugins.Notifier like the plugin of a single file,
+// reporting changes to the files of the directory that match the pattern. It
+// returns a nil channel when watching is disabled or the directory part of
+// the pattern holds wildcards.
+func (v *globWalker) Notify(ctx context.Context) (<-chan struct{}, error) {
+	if !v.config.Watch || hasMeta(filepath.Dir(v.pattern)) {
+		return nil, nil
+	}
+	return notify(ctx, v.pattern, v.config.WatchDebounce)
+}
@@ -0,0 +1,225 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/plugins/validate"
+)
+
+func newJSONLoader(t *testing.T) *loader.Loader {
+	t.Helper()
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestAddDir(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "20-port.json"), `{"Port": 2, "Extra": true}`)
+	writeFile(t, filepath.Join(dir, "10-base.json"), `{"Host": "a", "Port": 1}`)
+	writeFile(t, filepath.Join(dir, "README.md"), `# drop-in files`)
+	mustMkdir(t, filepath.Join(dir, "nested.json"))
+
+	base := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, base, `{"Host": "base", "Labels": {"team": "core"}}`)
+
+	l := newJSONLoader(t)
+	if err := l.AddFile(base, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddDir(dir, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	var value refreshFileConfig
+	c, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, refreshFileConfig{Host: "a", Port: 2, Labels: map[string]string{"team": "core"}}, value)
+
+	testutil.Equal(t, []loader.FileStatus{
+		{Path: base, Found: true},
+		{Path: filepath.Join(dir, "10-base.json"), Found: true},
+		{Path: filepath.Join(dir, "20-port.json"), Found: true},
+	}, l.Files())
+	testutil.Equal(t, map[string][]string{
+		filepath.Join(dir, "20-port.json"): {"Extra"},
+	}, l.GetUnknownFields())
+	testutil.Equal(t, []plugins.Source{
+		{Plugin: "file", Name: filepath.Join(dir, "20-port.json")},
+	}, c.Explain("Port"))
+}
+
+func TestAddGlobErrors(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	l := newJSONLoader(t)
+
+	if err := l.AddGlob(filepath.Join(dir, "*.yaml"), false); err == nil || !strings.Contains(err.Error(), `no decoder registered for format "yaml"`) {
+		t.Errorf("AddGlob() error = %v, want the missing yaml decoder", err)
+	}
+	if err := l.AddGlob(filepath.Join(dir, "[.json"), false); err == nil || !strings.Contains(err.Error(), "invalid pattern") {
+		t.Errorf("AddGlob() error = %v, want an invalid pattern", err)
+	}
+
+	if err := l.AddDir(filepath.Join(dir, "conf.d"), "*.json", true); err != nil {
+		t.Fatal(err)
+	}
+	var value refreshFileConfig
+	if _, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv()); err != nil {
+		t.Fatalf("Load() of an optional empty directory error = %v", err)
+	}
+	testutil.Equal(t, []loader.FileStatus{{Path: filepath.Join(dir, "conf.d", "*.json")}}, l.Files())
+
+	required := newJSONLoader(t)
+	if err := required.AddDir(filepath.Join(dir, "conf.d"), "*.json", false); err != nil {
+		t.Fatal(err)
+	}
+	_, err := xconfig.Load(&value, xconfig.WithLoader(required), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err == nil || !strings.Contains(err.Error(), "no files match") {
+		t.Errorf("Load() error = %v, want no files match", err)
+	}
+}
+
+func TestAddDirRefresh(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "10-base.json"), `{"Host": "a", "Port": 1}`)
+	writeFile(t, filepath.Join(dir, "20-labels.json"), `{"Labels": {"team": "core"}}`)
+
+	l := newJSONLoader(t)
+	if err := l.AddDir(dir, "*.json", false); err != nil {
+		t.Fatal(err)
+	}
+	var value refreshFileConfig
+	c, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new drop-in file is applied and the values of a removed one are
+	// reverted.
+	writeFile(t, filepath.Join(dir, "30-port.json"), `{"Port": 3}`)
+	if err := os.Remove(filepath.Join(dir, "20-labels.json")); err != nil {
+		t.Fatal(err)
+	}
+	result := c.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Labels"}, {FieldName: "Labels.team"}, {FieldName: "Port"}}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[refreshFileConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, refreshFileConfig{Host: "a", Port: 3}, snapshot)
+	testutil.Equal(t, map[string]struct{}{"Host": {}, "Port": {}}, l.PresentFields())
+
+	// A required pattern left without files fails the refresh.
+	for _, name := range []string{"10-base.json", "30-port.json"} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if result := c.Refresh(t.Context()); result.Err == nil {
+		t.Fatalf("Refresh() = %+v, want no files match", result)
+	}
+}
+
+func TestAddDirRefreshRemovedFile(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Host string
+		Port int `validate:"max=10000"`
+	}
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "10-a.json")
+	writeFile(t, base, `{"Host": "a", "Port": 1}`)
+	writeFile(t, filepath.Join(dir, "20-b.json"), `{"Port": 9000}`)
+
+	l := newJSONLoader(t)
+	if err := l.AddDir(dir, "*.json", false); err != nil {
+		t.Fatal(err)
+	}
+	var value config
+	c, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv(), xconfig.WithPlugins(validate.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, 9000, value.Port)
+
+	// A rejected cycle keeps the previous files.
+	if err := os.Remove(filepath.Join(dir, "20-b.json")); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(dir, "30-c.json"), `{"Port": 20000, "Extra": true}`)
+	if result := c.Refresh(t.Context()); result.Err == nil {
+		t.Fatalf("Refresh() = %+v, want a validation error", result)
+	}
+	testutil.Equal(t, map[string][]string{}, l.GetUnknownFields())
+	testutil.Equal(t, map[string]struct{}{"Host": {}, "Port": {}}, l.PresentFields())
+
+	// Once the new file is fixed, the port of the removed one is reverted.
+	writeFile(t, filepath.Join(dir, "30-c.json"), `{"Host": "c"}`)
+	result := c.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Host"}, {FieldName: "Port"}}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[config](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Host: "c", Port: 1}, snapshot)
+	testutil.Equal(t, []plugins.Source{
+		{Plugin: "file", Name: filepath.Join(dir, "20-b.json")},
+		{Plugin: "file", Name: base},
+	}, c.Explain("Port"))
+}
+
+func TestWatchRefreshesOnDropInFile(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "10-base.json"), `{"Port": 1}`)
+
+	l := newJSONLoader(t)
+	if err := l.AddDir(dir, "*.json", false); err != nil {
+		t.Fatal(err)
+	}
+	l.WatchFiles(10 * time.Millisecond)
+
+	var value refreshFileConfig
+	c, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	results, err := c.StartRefresh(t.Context(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.StopRefresh)
+
+	writeFile(t, filepath.Join(dir, "20-port.json"), `{"Port": 2}`)
+	waitForPort(t, c, results, 2)
+}
@@ -0,0 +1,322 @@
+package loader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/interpolate"
+)
+
+// Resolver supplies the variables referenced by the files of a Loader with
+// interpolation enabled. The string values of a file are expanded with the
+// shell-style references:
+//
+//	${VAR}          the value of VAR, an error when VAR is not set
+//	${VAR:-word}    word when VAR is not set or empty
+//	${VAR-word}     word when VAR is not set
+//	${VAR:?message} an error with message when VAR is not set or empty
+//	${VAR?message}  an error with message when VAR is not set
+//	$$              a literal $, so $${VAR} is kept as ${VAR}
+//
+// Words and messages may hold references themselves. Names consist of
+// letters, digits, underscores and dots. A $ not followed by { or $ is kept
+// as is. Keys and comments are not expanded, and an expanded value stays a
+// single value whatever characters it holds. A value expanded into a number
+// or boolean field is decoded as one, so `port: "${PORT}"` sets an int.
+type Resolver interface {
+	// Resolve returns the value of the named variable and whether it is set.
+	// conf is the configuration the file is decoded into.
+	Resolve(name string, conf any) (string, bool)
+}
+
+// ResolverFunc is a function implementing Resolver.
+type ResolverFunc func(name string, conf any) (string, bool)
+
+// Resolve calls f.
+func (f ResolverFunc) Resolve(name string, conf any) (string, bool) {
+	return f(name, conf)
+}
+
+// EnvResolver resolves variables from the process environment.
+func EnvResolver() Resolver {
+	return ResolverFunc(func(name string, _ any) (string, bool) {
+		return os.LookupEnv(name)
+	})
+}
+
+// MapResolver resolves variables from values.
+func MapResolver(values map[string]string) Resolver {
+	return ResolverFunc(func(name string, _ any) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	})
+}
+
+// FieldResolver resolves variables from the fields of the configuration,
+// named by their flat field names such as ${Database.Host} and matched
+// case-insensitively. A field resolves to its value, or to its default tag
+// while it holds the zero value; a zero field without a default, or whose
+// default is computed from other fields, is not set.
+//
+// The file being expanded is not decoded yet, so its own values are not
+// visible: on load a field holds the values of custom defaults and earlier
+// files, on refresh the values of the current configuration. The fields are
+// viewed with opts, the options of the views of the configuration, such as
+// the converters given to xconfig.WithConverter.
+func FieldResolver(opts ...flat.Option) Resolver {
+	return ResolverFunc(func(name string, conf any) (string, bool) {
+		fields, err := flat.View(conf, opts...)
+		if err != nil {
+			return "", false
+		}
+		for _, f := range fields {
+			if !strings.EqualFold(f.Name(), name) {
+				continue
+			}
+			if f.IsZero() {
+				value, ok := f.Tag("default")
+				if !ok || flat.ComputedDefaults(opts) && interpolate.HasReferences(value) {
+					return "", false
+				}
+				return value, true
+			}
+			return interpolate.Format(f.FieldValue()), true
+		}
+		return "", false
+	})
+}
+
+// ChainResolver resolves a variable with the first of resolvers that has it
+// set.
+func ChainResolver(resolvers ...Resolver) Resolver {
+	return ResolverFunc(func(name string, conf any) (string, bool) {
+		for _, r := range resolvers {
+			if value, ok := r.Resolve(name, conf); ok {
+				return value, true
+			}
+		}
+		return "", false
+	})
+}
+
+// InterpolationError reports a reference of a file that could not be
+// expanded. Loading fails with the errors of all such references joined.
+type InterpolationError struct {
+	// File is the path of the file.
+	File string
+	// Path is the key path of the value holding the reference, such as
+	// "database.dsn" or "hosts.0".
+	Path string
+	// Name is the referenced variable, or the reference itself when it is
+	// malformed.
+	Name string
+	// Reason is the message of a ${VAR:?message} reference, or why the
+	// reference could not be expanded.
+	Reason string
+}
+
+func (e *InterpolationError) Error() string {
+	where := e.File
+	if e.Path != "" {
+		where += ": " + e.Path
+	}
+	return fmt.Sprintf("loader: %s: %s: %s", where, e.Name, e.Reason)
+}
+
+// interpolate expands the references of the string values of src when the
+// walker has a resolver, and returns the file encoded as JSON when a value
+// changed. A value expanded into a number or boolean field is encoded as
+// such, so `port: "${PORT}"` decodes into an int. src is returned unchanged
+// when it cannot be decoded into a map, for the decoder to report why.
+func (v *walker) interpolate(src []byte, conf any) ([]byte, error) {
+	if v.resolver == nil {
+		return src, nil
+	}
+
+	raw, err := decodeRaw(src, conf, v.unmarshal)
+	if err != nil {
+		return src, nil
+	}
+
+	x := expander{file: v.filepath, lookup: func(name string) (string, bool) {
+		return v.resolver.Resolve(name, conf)
+	}}
+	expanded := x.expand(raw, reflect.TypeOf(conf), "")
+	if len(x.errs) > 0 {
+		return nil, errors.Join(x.errs...)
+	}
+	if !x.changed {
+		return src, nil
+	}
+
+	out, err := json.Marshal(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("loader: %s: %w", v.filepath, err)
+	}
+	return out, nil
+}
+
+// expander expands the references of the string values of a decoded file.
+type expander struct {
+	file    string
+	lookup  interpolate.Lookup
+	changed bool
+	errs    []error
+}
+
+// expand returns data, decoded into generic maps and lists, with the
+// references of its strings expanded. t is the type data is decoded into, or
+// nil when unknown. The keys of maps are expanded in sorted order, so the
+// errors are reported in that order.
+func (x *expander) expand(data any, t reflect.Type, path string) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch value := data.(type) {
+	case map[string]any:
+		for _, key := range slices.Sorted(maps.Keys(value)) {
+			value[key] = x.expand(value[key], keyType(t, key), joinPath(path, key))
+		}
+	case []any:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		for i, item := range value {
+			value[i] = x.expand(item, elem, joinPath(path, strconv.Itoa(i)))
+		}
+	case string:
+		expanded, failures := interpolate.Expand(value, x.lookup)
+		for _, failure := range failures {
+			x.errs = append(x.errs, &InterpolationError{
+				File:   x.file,
+				Path:   path,
+				Name:   failure.Name,
+				Reason: failure.Reason,
+			})
+		}
+		if expanded == value {
+			return value
+		}
+		x.changed = true
+		return scalar(expanded, t)
+	}
+	return data
+}
+
+// keyType returns the type of the value of key in a value of type t.
+func keyType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if field, ok := fieldForKey(t, key); ok {
+			return field.Type
+		}
+	case reflect.Map:
+		return t.Elem()
+	}
+	return nil
+}
+
+// scalar returns the expanded string s as the number or boolean a field of
+// type t holds, or s itself.
+func scalar(s string, t reflect.Type) any {
+	if t == nil || reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return s
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+			return json.Number(s)
+		}
+	case reflect.Bool:
+		if b, err := strconv.ParseBool(s); err == nil {
+			return b
+		}
+	}
+	return s
+}
+
+// expandedUnmarshal returns unmarshal, also decoding the JSON that
+// interpolate encodes the expanded files into when the format cannot read
+// JSON itself, such as TOML or HCL.
+func expandedUnmarshal(unmarshal Unmarshal) Unmarshal {
+	var probe map[string]any
+	if unmarshal([]byte(`{"a": [1]}`), &probe) == nil {
+		return unmarshal
+	}
+	return func(data []byte, v any) error {
+		if !json.Valid(data) {
+			return unmarshal(data, v)
+		}
+		var raw any
+		if err := json.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		// The keys follow the tags of the format, while encoding/json only
+		// reads json tags and Go names.
+		out, err := json.Marshal(jsonKeys(raw, reflect.TypeOf(v)))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(out, v)
+	}
+}
+
+// jsonKeys renames the keys of data, decoded into generic maps and lists, to
+// the names encoding/json matches against the fields of t.
+func jsonKeys(data any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return data
+	}
+
+	switch value := data.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(value))
+		for key, item := range value {
+			if t.Kind() == reflect.Struct {
+				if field, ok := fieldForKey(t, key); ok {
+					out[jsonName(field)] = jsonKeys(item, field.Type)
+					continue
+				}
+			}
+			var elem reflect.Type
+			if t.Kind() == reflect.Map {
+				elem = t.Elem()
+			}
+			out[key] = jsonKeys(item, elem)
+		}
+		return out
+	case []any:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for i, item := range value {
+				value[i] = jsonKeys(item, t.Elem())
+			}
+		}
+	}
+	return data
+}
+
+// jsonName returns the key encoding/json decodes into field.
+func jsonName(field reflect.StructField) string {
+	if name, _, _ := strings.Cut(field.Tag.Get("json"), ","); name != "" && name != "-" {
+		return name
+	}
+	return field.Name
+}
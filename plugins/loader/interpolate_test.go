@@ -0,0 +1,203 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+type interpolateDatabase struct {
+	DSN      string `json:"dsn"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+}
+
+type interpolateConfig struct {
+	Name     string              `json:"name"`
+	Port     int                 `json:"port"`
+	Template string              `json:"template"`
+	Database interpolateDatabase `json:"database"`
+	Tags     []string            `json:"tags"`
+}
+
+func loadInterpolated(t *testing.T, path string, resolver loader.Resolver, conf any) (*loader.Loader, xconfig.Config, error) {
+	t.Helper()
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+	l.Interpolate(resolver)
+	c, err := xconfig.Load(conf, xconfig.WithLoader(l), xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	return l, c, err
+}
+
+func TestInterpolate(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{
+		"name": "${APP_NAME}",
+		"port": "${PORT:-8080}",
+		"template": "$${NOT_EXPANDED} costs $$5 or $1",
+		"database": {
+			"dsn": "postgres://${DB_USER}@${DB_HOST:-localhost}/app",
+			"user": "${DB_USER-nobody}",
+			"password": "${DB_PASSWORD:-${FALLBACK_PASSWORD:-secret}}"
+		},
+		"tags": ["${EMPTY-unused}", "${EMPTY:-default}"]
+	}`)
+
+	var cfg interpolateConfig
+	l, _, err := loadInterpolated(t, path, loader.MapResolver(map[string]string{
+		"APP_NAME":          "api",
+		"DB_USER":           `"admin"`,
+		"DB_HOST":           "",
+		"EMPTY":             "",
+		"FALLBACK_PASSWORD": "fallback",
+	}), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, interpolateConfig{
+		Name:     "api",
+		Port:     8080,
+		Template: "${NOT_EXPANDED} costs $5 or $1",
+		Database: interpolateDatabase{
+			DSN:      "postgres://\"admin\"@localhost/app",
+			User:     `"admin"`,
+			Password: "fallback",
+		},
+		Tags: []string{"", "default"},
+	}, cfg)
+
+	// The expanded port is decoded as a number.
+	if _, ok := l.PresentFields()["port"]; !ok {
+		t.Errorf("PresentFields() = %v, want port", l.PresentFields())
+	}
+	testutil.Equal(t, map[string][]string{}, l.GetUnknownFields())
+}
+
+func TestInterpolateErrors(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{
+		"name": "${APP_NAME}",
+		"database": {
+			"dsn": "postgres://${DB_USER}@${DB_HOST}/app",
+			"password": "${DB_PASSWORD:?database password required}"
+		},
+		"tags": ["${-bad}"]
+	}`)
+
+	var cfg interpolateConfig
+	_, _, err := loadInterpolated(t, path, loader.MapResolver(map[string]string{"DB_HOST": "db"}), &cfg)
+	if err == nil {
+		t.Fatal("Load() succeeded with unset variables")
+	}
+
+	var first *loader.InterpolationError
+	if !errors.As(err, &first) {
+		t.Fatalf("Load() error = %v, want *loader.InterpolationError", err)
+	}
+	testutil.Equal(t, path, first.File)
+	testutil.Equal(t, "database.dsn", first.Path)
+	testutil.Equal(t, "DB_USER", first.Name)
+
+	for _, want := range []string{
+		path + ": name: APP_NAME: not set",
+		path + ": database.dsn: DB_USER: not set",
+		path + ": database.password: DB_PASSWORD: database password required",
+		path + ": tags.0: ${-bad}: invalid reference",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Load() error = %v, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "DB_HOST") {
+		t.Errorf("Load() error = %v, reports the set DB_HOST", err)
+	}
+
+	writeFile(t, path, `{"name": "${APP_NAME"}`)
+	_, _, err = loadInterpolated(t, path, loader.MapResolver(nil), &cfg)
+	if err == nil || !strings.Contains(err.Error(), "unterminated reference") {
+		t.Fatalf("Load() error = %v, want an unterminated reference", err)
+	}
+}
+
+func TestInterpolateRefreshesChangedVariables(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"name": "${APP_NAME}", "port": 1}`)
+
+	values := map[string]string{"APP_NAME": "one"}
+	var cfg interpolateConfig
+	_, manager, err := loadInterpolated(t, path, loader.ResolverFunc(func(name string, _ any) (string, bool) {
+		value, ok := values[name]
+		return value, ok
+	}), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "one", cfg.Name)
+
+	values["APP_NAME"] = "two"
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Name"}}, result.Changes)
+
+	delete(values, "APP_NAME")
+	result = manager.Refresh(t.Context())
+	var interpolationErr *loader.InterpolationError
+	if result.Published || !errors.As(result.Err, &interpolationErr) {
+		t.Fatalf("Refresh() = %+v, want an interpolation error", result)
+	}
+	snapshot, err := xconfig.Snapshot[interpolateConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "two", snapshot.Name)
+}
+
+type fieldReferenceConfig struct {
+	Host  string `default:"localhost"`
+	Port  int
+	Ports []int
+	URL   string
+}
+
+func (c *fieldReferenceConfig) SetDefaults() {
+	c.Port = 5432
+	c.Ports = []int{1, 2}
+}
+
+func TestInterpolateFieldResolver(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"URL": "postgres://${host}:${Port}/${Missing:-app}?ports=${Ports}&env=${ENV}"}`)
+
+	var cfg fieldReferenceConfig
+	_, _, err := loadInterpolated(t, path, loader.ChainResolver(
+		loader.MapResolver(map[string]string{"ENV": "prod"}),
+		loader.FieldResolver(),
+	), &cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, "postgres://localhost:5432/app?ports=1,2&env=prod", cfg.URL)
+}
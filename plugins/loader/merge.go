@@ -0,0 +1,326 @@
+package loader
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+const mergeTag = "merge"
+
+func init() {
+	plugins.RegisterTag(mergeTag)
+}
+
+// MergeStrategy tells how a map or list of a file is combined with the value
+// the field holds from the files loaded before it and from SetDefaults.
+// Structs are always merged field by field, so a file only sets the fields it
+// holds, and other values are replaced. A field selects its strategy with the
+// merge tag, such as `merge:"append"`; the other fields, and the entries of
+// maps and lists, use the strategy of the Loader, MergeDeep unless set with
+// Merge.
+type MergeStrategy string
+
+const (
+	// MergeDeep merges maps entry by entry, recursively, and replaces lists.
+	MergeDeep MergeStrategy = "deep"
+	// MergeReplace replaces maps and lists with those of the file.
+	MergeReplace MergeStrategy = "replace"
+	// MergeAppend appends the items of a list to the earlier ones and adds
+	// the entries of a map, replacing existing entries as a whole.
+	MergeAppend MergeStrategy = "append"
+)
+
+const mergeByKeyPrefix = "merge-by-key:"
+
+// MergeByKey returns the strategy of a list of structs merging every item of
+// the file into the earlier item whose field key, such as Name, holds the
+// same value, and appending the others. The tag `merge:"merge-by-key:Name"`
+// selects it for a field.
+func MergeByKey(key string) MergeStrategy {
+	return MergeStrategy(mergeByKeyPrefix + key)
+}
+
+// byKey returns the key field of a MergeByKey strategy.
+func (s MergeStrategy) byKey() (string, bool) {
+	key, ok := strings.CutPrefix(string(s), mergeByKeyPrefix)
+	return key, ok && key != ""
+}
+
+func (s MergeStrategy) valid() bool {
+	if _, ok := s.byKey(); ok {
+		return true
+	}
+	switch s {
+	case MergeDeep, MergeReplace, MergeAppend:
+		return true
+	}
+	return false
+}
+
+// fieldStrategy returns the strategy of field, given by its merge tag, or def.
+func fieldStrategy(field reflect.StructField, def MergeStrategy) MergeStrategy {
+	if value := field.Tag.Get(mergeTag); value != "" {
+		return MergeStrategy(value)
+	}
+	return def
+}
+
+// strategy returns the merge strategy of the fields without a merge tag.
+func (v *walker) strategy() MergeStrategy {
+	if v.merge == "" {
+		return MergeDeep
+	}
+	return v.merge
+}
+
+// decode decodes the file content src into conf, merging it with the values
+// conf holds according to the merge strategies.
+func (v *walker) decode(src []byte, conf any) error {
+	next := reflect.New(reflect.TypeOf(conf).Elem())
+	if err := v.unmarshal(src, next.Interface()); err != nil {
+		return err
+	}
+	return v.mergeDecoded(src, next.Interface(), conf)
+}
+
+// mergeDecoded merges next, the file content src decoded on its own, into
+// conf. The keys of src decoded into generic maps tell which fields it holds;
+// a format that cannot be decoded so is decoded into conf directly.
+func (v *walker) mergeDecoded(src []byte, next, conf any) error {
+	def := v.strategy()
+	if !def.valid() {
+		return fmt.Errorf("loader: unknown merge strategy %q", def)
+	}
+
+	raw, err := decodeRaw(src, conf, v.unmarshal)
+	if err != nil {
+		return v.unmarshal(src, conf)
+	}
+	return mergeValue(reflect.ValueOf(conf).Elem(), reflect.ValueOf(next).Elem(), raw, def, def, "")
+}
+
+// mergeValue merges next, a value decoded from a file, into dst with
+// strategy. raw is the same value decoded into generic maps and lists, and
+// def the strategy of the values nested in it.
+func mergeValue(dst, next reflect.Value, raw any, strategy, def MergeStrategy, path string) error {
+	if raw == nil {
+		// A null value does not set anything.
+		return nil
+	}
+
+	switch {
+	case next.Kind() == reflect.Struct && isMergeableStruct(next.Type()):
+		if m, ok := raw.(map[string]any); ok {
+			return mergeStruct(dst, next, m, def, path)
+		}
+
+	case next.Kind() == reflect.Pointer && !dst.IsNil() && !next.IsNil() &&
+		next.Type().Elem().Kind() == reflect.Struct && isMergeableStruct(next.Type().Elem()):
+		return mergeValue(dst.Elem(), next.Elem(), raw, strategy, def, path)
+
+	case next.Kind() == reflect.Map:
+		return mergeMap(dst, next, raw, strategy, def, path)
+
+	case next.Kind() == reflect.Slice:
+		return mergeSlice(dst, next, raw, strategy, def, path)
+	}
+
+	dst.Set(next)
+	return nil
+}
+
+// mergeStruct merges the fields of next that the file holds into dst.
+func mergeStruct(dst, next reflect.Value, raw map[string]any, def MergeStrategy, path string) error {
+	t := next.Type()
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			// The keys of an embedded struct are promoted.
+			d, n := dst.Field(i), next.Field(i)
+			if n.Kind() == reflect.Pointer {
+				if n.IsNil() {
+					continue
+				}
+				if d.IsNil() {
+					d.Set(reflect.New(fieldType))
+				}
+				d, n = d.Elem(), n.Elem()
+			}
+			if err := mergeStruct(d, n, raw, def, path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		name, ok := FileKey(field)
+		if !ok {
+			continue
+		}
+		value, ok := rawKey(raw, name)
+		if !ok {
+			continue
+		}
+
+		fieldPath := joinPath(path, field.Name)
+		strategy := fieldStrategy(field, def)
+		if !strategy.valid() {
+			return fmt.Errorf("loader: field %s: unknown merge strategy %q", fieldPath, strategy)
+		}
+		if err := mergeValue(dst.Field(i), next.Field(i), value, strategy, def, fieldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mergeMap merges the entries of the map next into dst.
+func mergeMap(dst, next reflect.Value, raw any, strategy, def MergeStrategy, path string) error {
+	if _, ok := strategy.byKey(); ok {
+		return fmt.Errorf("loader: field %s: %s only applies to lists of structs", path, strategy)
+	}
+	entries, ok := raw.(map[string]any)
+	if !ok || strategy == MergeReplace || dst.IsNil() || next.IsNil() {
+		dst.Set(next)
+		return nil
+	}
+
+	iter := next.MapRange()
+	for iter.Next() {
+		key, value := iter.Key(), iter.Value()
+		current := dst.MapIndex(key)
+		if strategy == MergeAppend || !current.IsValid() {
+			dst.SetMapIndex(key, value)
+			continue
+		}
+
+		entry := reflect.New(value.Type()).Elem()
+		entry.Set(current)
+		name := fmt.Sprint(key.Interface())
+		if err := mergeValue(entry, value, entries[name], def, def, joinPath(path, name)); err != nil {
+			return err
+		}
+		dst.SetMapIndex(key, entry)
+	}
+	return nil
+}
+
+// mergeSlice merges the list next into dst.
+func mergeSlice(dst, next reflect.Value, raw any, strategy, def MergeStrategy, path string) error {
+	if strategy == MergeAppend {
+		merged := reflect.MakeSlice(dst.Type(), 0, dst.Len()+next.Len())
+		merged = reflect.AppendSlice(merged, dst)
+		dst.Set(reflect.AppendSlice(merged, next))
+		return nil
+	}
+
+	key, ok := strategy.byKey()
+	if !ok {
+		dst.Set(next)
+		return nil
+	}
+	index, err := keyField(next.Type().Elem(), key)
+	if err != nil {
+		return fmt.Errorf("loader: field %s: %w", path, err)
+	}
+
+	items, _ := raw.([]any)
+	merged := reflect.MakeSlice(dst.Type(), dst.Len(), dst.Len()+next.Len())
+	reflect.Copy(merged, dst)
+	for i := range next.Len() {
+		item := next.Index(i)
+		j := indexOfKey(merged, index, item)
+		if j < 0 {
+			merged = reflect.Append(merged, item)
+			continue
+		}
+		var itemRaw any
+		if i < len(items) {
+			itemRaw = items[i]
+		}
+		if err := mergeValue(merged.Index(j), item, itemRaw, def, def, joinPath(path, strconv.Itoa(j))); err != nil {
+			return err
+		}
+	}
+	dst.Set(merged)
+	return nil
+}
+
+// keyField returns the index of the field named key of the items of a list
+// merged by key, matched by Go name or file key.
+func keyField(elem reflect.Type, key string) ([]int, error) {
+	if elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() == reflect.Struct {
+		if field, ok := elem.FieldByName(key); ok && field.IsExported() {
+			return field.Index, nil
+		}
+		for i := range elem.NumField() {
+			field := elem.Field(i)
+			if name, ok := FileKey(field); ok && field.IsExported() && strings.EqualFold(name, key) {
+				return field.Index, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("%s%s needs a list of structs with a field %s", mergeByKeyPrefix, key, key)
+}
+
+// keyValue returns the key field of the list item item, or an invalid value
+// when item is a nil pointer.
+func keyValue(item reflect.Value, index []int) reflect.Value {
+	if item.Kind() == reflect.Pointer {
+		if item.IsNil() {
+			return reflect.Value{}
+		}
+		item = item.Elem()
+	}
+	return item.FieldByIndex(index)
+}
+
+// indexOfKey returns the index of the item of list whose key field equals the
+// one of item, or -1.
+func indexOfKey(list reflect.Value, index []int, item reflect.Value) int {
+	key := keyValue(item, index)
+	if !key.IsValid() {
+		return -1
+	}
+	for i := range list.Len() {
+		if other := keyValue(list.Index(i), index); other.IsValid() && reflect.DeepEqual(other.Interface(), key.Interface()) {
+			return i
+		}
+	}
+	return -1
+}
+
+// rawKey returns the value of the key name of m, matched exactly or else
+// case-insensitively.
+func rawKey(m map[string]any, name string) (any, bool) {
+	if value, ok := m[name]; ok {
+		return value, true
+	}
+	for key, value := range m {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
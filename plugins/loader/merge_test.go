@@ -0,0 +1,261 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+type mergeUpstream struct {
+	Name    string
+	Host    string
+	Weight  int
+	Headers map[string]string
+}
+
+type mergeRoute struct {
+	Path    string
+	Timeout int
+}
+
+type mergeConfig struct {
+	Upstreams []mergeUpstream `merge:"merge-by-key:Name"`
+	Hosts     []string        `merge:"append"`
+	Tags      []string
+	Routes    map[string]mergeRoute
+	Labels    map[string]string `merge:"replace"`
+	Server    struct {
+		Host string
+		Port int
+	}
+}
+
+// loadMerged loads the files, given as JSON contents, in order.
+func loadMerged(t *testing.T, conf any, strategy loader.MergeStrategy, contents ...string) (*loader.Loader, xconfig.Config, error) {
+	t.Helper()
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	for i, content := range contents {
+		path := filepath.Join(dir, string(rune('a'+i))+".json")
+		writeFile(t, path, content)
+		if err := l.AddFile(path, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.Merge(strategy)
+
+	c, err := xconfig.Load(conf, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	return l, c, err
+}
+
+func TestMergeStrategies(t *testing.T) {
+	t.Parallel()
+
+	var value mergeConfig
+	_, _, err := loadMerged(t, &value, "",
+		`{
+			"Upstreams": [{"Name": "api", "Host": "api.internal", "Weight": 1, "Headers": {"X-Team": "core"}}],
+			"Hosts": ["a"],
+			"Tags": ["base"],
+			"Routes": {"users": {"Path": "/users", "Timeout": 5}},
+			"Labels": {"team": "core", "tier": "1"},
+			"Server": {"Host": "localhost", "Port": 8080}
+		}`,
+		`{
+			"Upstreams": [{"Name": "billing", "Host": "billing.internal"}, {"Name": "api", "Weight": 3, "Headers": {"X-Env": "prod"}}],
+			"Hosts": ["b", "c"],
+			"Tags": ["overlay"],
+			"Routes": {"users": {"Timeout": 10}, "orders": {"Path": "/orders"}},
+			"Labels": {"team": "billing"},
+			"Server": {"Port": 9090}
+		}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var want mergeConfig
+	want.Upstreams = []mergeUpstream{
+		{Name: "api", Host: "api.internal", Weight: 3, Headers: map[string]string{"X-Team": "core", "X-Env": "prod"}},
+		{Name: "billing", Host: "billing.internal"},
+	}
+	want.Hosts = []string{"a", "b", "c"}
+	want.Tags = []string{"overlay"}
+	want.Routes = map[string]mergeRoute{
+		"users":  {Path: "/users", Timeout: 10},
+		"orders": {Path: "/orders"},
+	}
+	want.Labels = map[string]string{"team": "billing"}
+	want.Server.Host = "localhost"
+	want.Server.Port = 9090
+	testutil.Equal(t, want, value)
+}
+
+func TestMergeLoaderStrategy(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Hosts  []string
+		Tags   []string `merge:"replace"`
+		Routes map[string]mergeRoute
+	}
+
+	var value config
+	_, _, err := loadMerged(t, &value, loader.MergeAppend,
+		`{"Hosts": ["a"], "Tags": ["base"], "Routes": {"users": {"Path": "/users", "Timeout": 5}}}`,
+		`{"Hosts": ["b"], "Tags": ["overlay"], "Routes": {"users": {"Timeout": 10}}}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{
+		Hosts: []string{"a", "b"},
+		Tags:  []string{"overlay"},
+		// Appended map entries replace earlier ones as a whole.
+		Routes: map[string]mergeRoute{"users": {Timeout: 10}},
+	}, value)
+}
+
+func TestMergeErrors(t *testing.T) {
+	t.Parallel()
+
+	type unknown struct {
+		Hosts []string `merge:"concat"`
+	}
+	type noKey struct {
+		Upstreams []mergeUpstream `merge:"merge-by-key:ID"`
+	}
+	type mapByKey struct {
+		Routes map[string]mergeRoute `merge:"merge-by-key:Path"`
+	}
+
+	for _, tt := range []struct {
+		conf any
+		file string
+		want string
+	}{
+		{&unknown{}, `{"Hosts": ["a"]}`, `field Hosts: unknown merge strategy "concat"`},
+		{&noKey{}, `{"Upstreams": [{"Name": "api"}]}`, "field Upstreams: merge-by-key:ID needs a list of structs with a field ID"},
+		{&mapByKey{}, `{"Routes": {"users": {}}}`, "field Routes: merge-by-key:Path only applies to lists of structs"},
+	} {
+		_, _, err := loadMerged(t, tt.conf, "", tt.file)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Load(%T) error = %v, want %q", tt.conf, err, tt.want)
+		}
+	}
+
+	_, _, err := loadMerged(t, &mergeConfig{}, "shallow", `{}`)
+	if err == nil || !strings.Contains(err.Error(), `unknown merge strategy "shallow"`) {
+		t.Errorf("Load() error = %v, want the unknown loader strategy", err)
+	}
+}
+
+func TestMergeRefresh(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	overlay := filepath.Join(dir, "overlay.json")
+	writeFile(t, base, `{"Hosts": ["a"], "Upstreams": [{"Name": "api", "Host": "api.internal", "Weight": 1}]}`)
+	writeFile(t, overlay, `{"Hosts": ["b"], "Upstreams": [{"Name": "api", "Weight": 2}]}`)
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFiles([]string{base, overlay}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var value mergeConfig
+	c, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, overlay, `{"Hosts": ["b", "c"], "Upstreams": [{"Name": "api", "Weight": 5}, {"Name": "billing"}]}`)
+	if result := c.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+
+	snapshot, err := xconfig.Snapshot[mergeConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []string{"a", "b", "c"}, snapshot.Hosts)
+	testutil.Equal(t, []mergeUpstream{
+		{Name: "api", Host: "api.internal", Weight: 5},
+		{Name: "billing"},
+	}, snapshot.Upstreams)
+}
+
+func TestMergeRefreshAppend(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Hosts []string `merge:"append"`
+	}
+
+	var value config
+	l, c, err := loadMerged(t, &value, "", `{"Hosts": ["a", "b"]}`, `{"Hosts": ["c"]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []string{"a", "b", "c"}, value.Hosts)
+
+	// The items of an earlier file stay before those of later files.
+	writeFile(t, l.Files()[0].Path, `{"Hosts": ["a", "b", "x"]}`)
+	if result := c.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err := xconfig.Snapshot[config](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []string{"a", "b", "x", "c"}, snapshot.Hosts)
+
+	writeFile(t, l.Files()[1].Path, `{}`)
+	if result := c.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err = xconfig.Snapshot[config](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []string{"a", "b", "x"}, snapshot.Hosts)
+}
+
+func TestMergeRefreshByKey(t *testing.T) {
+	t.Parallel()
+
+	var value mergeConfig
+	l, c, err := loadMerged(t, &value, "",
+		`{"Upstreams": [{"Name": "api", "Host": "api.internal", "Weight": 1}, {"Name": "billing", "Host": "billing.internal"}]}`,
+		`{"Upstreams": [{"Name": "api", "Weight": 2}, {"Name": "search"}]}`,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Items removed from a file are removed from the list, and the items of
+	// the other files are merged again.
+	writeFile(t, l.Files()[0].Path, `{"Upstreams": [{"Name": "api", "Host": "api.v2.internal"}]}`)
+	writeFile(t, l.Files()[1].Path, `{"Upstreams": [{"Name": "api", "Weight": 3}]}`)
+	if result := c.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err := xconfig.Snapshot[mergeConfig](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []mergeUpstream{{Name: "api", Host: "api.v2.internal", Weight: 3}}, snapshot.Upstreams)
+}
@@ -0,0 +1,526 @@
+package loader
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/utils"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+// ErrInvalidFieldValue is wrapped by FieldDecodeError.
+var ErrInvalidFieldValue = errors.New("loader: invalid field value")
+
+// FieldDecodeError is reported as a refresh warning when a reloaded file holds
+// a value its field cannot decode. The field keeps its last-known-good value
+// while valid sibling values are still applied. The decoder's message is not
+// included because it may quote file contents such as secrets.
+type FieldDecodeError struct {
+	// File is the path of the reloaded file.
+	File string
+	// FieldName is the flat field name, or empty when the decoder rejected
+	// the file without the value being attributable to a single field.
+	FieldName string
+}
+
+func (e *FieldDecodeError) Error() string {
+	if e.FieldName == "" {
+		return fmt.Sprintf("loader: %s: some values were rejected and keep their previous value", e.File)
+	}
+	return fmt.Sprintf("loader: %s: field %s rejected its value and keeps its previous value", e.File, e.FieldName)
+}
+
+func (e *FieldDecodeError) Unwrap() error { return ErrInvalidFieldValue }
+
+// fileWalker is a walker reading a named file, which it can read again.
+type fileWalker struct {
+	walker
+}
+
+var (
+	_ plugins.Notifier         = (*fileWalker)(nil)
+	_ plugins.RefreshCommitter = (*fileWalker)(nil)
+	_ plugins.OverrideReceiver = (*fileWalker)(nil)
+)
+
+func (v *fileWalker) Parse() error {
+	v.group.begin(v, v.conf)
+	return v.walker.Parse()
+}
+
+// SetOverrides keeps the fields later sources set for the next Refresh.
+func (v *fileWalker) SetOverrides(fieldNames map[string]struct{}) {
+	v.overrides = fieldNames
+}
+
+func (v *fileWalker) files() []*walker {
+	return []*walker{&v.walker}
+}
+
+// fileGroup holds the files loaded together, in order: the files of a Loader,
+// or a single file. A refresh merges their contents again onto the values
+// the configuration held before the first of them, so the lists they append
+// to each other and the values a file no longer holds are computed like a
+// new Load would.
+type fileGroup struct {
+	members []groupMember
+	// base is a copy of the configuration taken before the first member
+	// was parsed.
+	base reflect.Value
+	// fallback fills the fields the merged files do not hold.
+	fallback Fallback
+}
+
+// groupMember is a plugin of a fileGroup, loading one or several files.
+type groupMember interface {
+	files() []*walker
+}
+
+// begin takes the copy of conf the files are merged onto when m is the
+// first member of the group.
+func (g *fileGroup) begin(m groupMember, conf any) {
+	if len(g.members) > 0 && g.members[0] == m && conf != nil {
+		g.base = copyValue(reflect.ValueOf(conf).Elem())
+	}
+}
+
+// merged returns the file contents of the group merged in order onto its
+// base, with the content of w replaced by src, or left out when src is
+// empty, and the fields they do not hold filled by the fallback of the group.
+// next, when set, is src already decoded by w, which is then merged without
+// being decoded again.
+func (g *fileGroup) merged(w *walker, src []byte, next any) (reflect.Value, error) {
+	if !g.base.IsValid() {
+		return reflect.Value{}, errors.New("loader: refresh before parse")
+	}
+
+	conf := reflect.New(g.base.Type())
+	conf.Elem().Set(copyValue(g.base))
+	present := map[string]struct{}{}
+	for _, m := range g.members {
+		for _, f := range m.files() {
+			content := f.last
+			if f == w {
+				content = src
+			}
+			if len(content) == 0 {
+				continue
+			}
+			if paths, err := findPresentFields(content, conf.Interface(), f.unmarshal); err == nil {
+				maps.Copy(present, paths)
+			}
+			if f != w || next == nil {
+				if err := f.decode(content, conf.Interface()); err != nil {
+					return reflect.Value{}, fmt.Errorf("decode %s: %w", f.filepath, err)
+				}
+				continue
+			}
+			// The values next rejected were reported as warnings already.
+			_ = f.mergeDecoded(src, next, conf.Interface())
+		}
+	}
+	if g.fallback != nil {
+		if err := g.fallback(conf.Interface(), present, w.viewOptions); err != nil {
+			return reflect.Value{}, err
+		}
+	}
+	return conf, nil
+}
+
+// Refresh re-reads the file and applies to target every value that changed in
+// the configuration the files of its group produce, merged again with the new
+// content as a new Load would merge them. A changed value is written while
+// the field still holds what the files produced before, or when no later
+// source but defaults set it, so env vars, flags and other sources that
+// override the files keep their values. A key removed from the file takes the
+// value it has without the file, such as the one of an earlier file or its
+// default.
+//
+// A file that cannot be read, expanded or parsed fails the refresh, keeping
+// the last published configuration. Individual values the decoder rejects are reported
+// as *FieldDecodeError warnings and keep their previous value.
+func (v *fileWalker) Refresh(ctx context.Context, target any) (plugins.RefreshOutcome, error) {
+	if err := ctx.Err(); err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+
+	src, err := os.ReadFile(v.filepath)
+	if err != nil {
+		if v.optional && os.IsNotExist(err) {
+			return plugins.RefreshOutcome{}, nil
+		}
+		return plugins.RefreshOutcome{}, err
+	}
+	// Expanding first lets a changed variable refresh an unchanged file.
+	src, err = v.interpolate(src, target)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	if bytes.Equal(src, v.last) {
+		return plugins.RefreshOutcome{}, nil
+	}
+
+	record, err := v.inspect(src, target)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+
+	targetType := reflect.TypeOf(target).Elem()
+
+	// next only holds the new content, so the keys the file no longer holds
+	// are left out when the files are merged again.
+	next := reflect.New(targetType)
+	decodeErr := v.unmarshal(src, next.Interface())
+
+	outcome := plugins.RefreshOutcome{}
+	rejected := map[string]struct{}{}
+	if decodeErr != nil {
+		if record.present == nil {
+			// Not even the structure can be read: the file is broken.
+			return plugins.RefreshOutcome{}, fmt.Errorf("decode %s: %w", v.filepath, decodeErr)
+		}
+		// layered is the previous content with the new one decoded on top,
+		// so it still holds the previous value of every rejected field.
+		layered := reflect.New(targetType)
+		if len(v.last) > 0 {
+			if err := v.unmarshal(v.last, layered.Interface()); err != nil {
+				return plugins.RefreshOutcome{}, fmt.Errorf("decode previous %s: %w", v.filepath, err)
+			}
+		}
+		_ = v.unmarshal(src, layered.Interface())
+		outcome.Warnings = v.rejectedFields(layered.Interface(), next.Interface(), record.present)
+		for _, warning := range outcome.Warnings {
+			var fieldErr *FieldDecodeError
+			if errors.As(warning, &fieldErr) && fieldErr.FieldName != "" {
+				rejected[fieldErr.FieldName] = struct{}{}
+				continue
+			}
+			// The rejected values are unknown: keep all previous ones.
+			next = layered
+		}
+	}
+
+	outcome.Changes, err = v.apply(target, src, next.Interface(), rejected)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	v.last = src
+	v.pending = &record
+	return outcome, nil
+}
+
+// remove reverts the values the file contributed once it no longer exists, as
+// if all its keys were removed.
+func (v *fileWalker) remove(target any) (plugins.RefreshOutcome, error) {
+	if len(v.last) == 0 {
+		return plugins.RefreshOutcome{}, nil
+	}
+	changes, err := v.apply(target, nil, nil, nil)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	v.last = nil
+	v.pending = &fileRecord{}
+	return plugins.RefreshOutcome{Changes: changes}, nil
+}
+
+// apply writes into target the values that change when the content of the
+// file becomes src, decoded as next when set, and returns the changed fields.
+// The fields named in rejected keep their value.
+func (v *walker) apply(target any, src []byte, next any, rejected map[string]struct{}) ([]plugins.FieldChange, error) {
+	prev, err := v.group.merged(v, v.last, nil)
+	if err != nil {
+		return nil, err
+	}
+	merged, err := v.group.merged(v, src, next)
+	if err != nil {
+		return nil, err
+	}
+
+	before, err := fieldValues(target, v.viewOptions)
+	if err != nil {
+		return nil, err
+	}
+	keep := func(fieldName string) bool {
+		_, ok := rejected[fieldName]
+		return ok
+	}
+	applyFileChanges(reflect.ValueOf(target).Elem(), prev.Elem(), merged.Elem(), "", v.overridden, keep)
+	return changedFields(before, target, v.viewOptions)
+}
+
+// overridden reports whether a source registered after the file set the
+// field named fieldName, a field within it or the field holding it. Without
+// the overrides given by SetOverrides, every field counts as overridden.
+func (v *walker) overridden(fieldName string) bool {
+	if v.overrides == nil {
+		return true
+	}
+	for name := range v.overrides {
+		if name == fieldName || strings.HasPrefix(name, fieldName+".") || strings.HasPrefix(fieldName, name+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// EndRefresh keeps the content applied by Refresh when the working copy was
+// kept, recording its present and unknown fields, and otherwise rolls back to
+// the content of the kept working copy, so the next cycle applies the file
+// again instead of treating the discarded values as overrides.
+func (v *fileWalker) EndRefresh(kept bool) {
+	if kept {
+		v.committed = v.last
+		if v.pending != nil {
+			v.record(*v.pending)
+		}
+	} else {
+		v.last = v.committed
+	}
+	v.pending = nil
+}
+
+// rejectedFields reports the fields the file sets but whose value could not be
+// decoded: next kept the previous value there, while fresh was left without.
+func (v *walker) rejectedFields(next, fresh any, present map[string]struct{}) []error {
+	presentPaths := make(map[string]struct{}, len(present))
+	for p := range present {
+		presentPaths[strings.ToLower(p)] = struct{}{}
+	}
+
+	nextFields, err := flat.View(next, v.viewOptions...)
+	if err != nil {
+		return []error{&FieldDecodeError{File: v.filepath}}
+	}
+	freshValues, err := fieldValues(fresh, v.viewOptions)
+	if err != nil {
+		return []error{&FieldDecodeError{File: v.filepath}}
+	}
+
+	var warnings []error
+	for _, f := range nextFields {
+		p, ok := utils.ConfigPath(next, f.Name())
+		if !ok {
+			continue
+		}
+		if _, ok := presentPaths[strings.ToLower(p)]; !ok {
+			continue
+		}
+		freshValue, ok := freshValues[f.Name()]
+		if ok && reflect.DeepEqual(freshValue.Interface(), f.FieldValue().Interface()) {
+			continue
+		}
+		warnings = append(warnings, &FieldDecodeError{File: v.filepath, FieldName: f.Name()})
+	}
+	if len(warnings) == 0 {
+		warnings = append(warnings, &FieldDecodeError{File: v.filepath})
+	}
+	return warnings
+}
+
+// applyFileChanges writes into dst every value that differs between prev and
+// next, the configuration the files produced before and after a refresh,
+// while dst still holds the value of prev or overridden reports that no
+// later source set the field, named path. Structs, optional sections, maps,
+// arrays of structs and equally long slices of structs are merged member by
+// member, so values that did not change in the files are never overwritten;
+// other lists are written as a whole. The values keep reports, such as those
+// the decoder rejected, are left as they are.
+func applyFileChanges(dst, prev, next reflect.Value, path string, overridden, keep func(string) bool) {
+	if reflect.DeepEqual(prev.Interface(), next.Interface()) || keep(path) {
+		return
+	}
+
+	switch {
+	case next.Kind() == reflect.Struct && isMergeableStruct(next.Type()):
+		for i := range next.NumField() {
+			field := next.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldPath := path
+			if !field.Anonymous {
+				fieldPath = joinPath(path, field.Name)
+			}
+			applyFileChanges(dst.Field(i), prev.Field(i), next.Field(i), fieldPath, overridden, keep)
+		}
+
+	case next.Kind() == reflect.Pointer && !dst.IsNil() && !next.IsNil() &&
+		next.Type().Elem().Kind() == reflect.Struct && isMergeableStruct(next.Type().Elem()):
+		// An optional section present on both sides is merged like a struct.
+		old := prev
+		if old.IsNil() {
+			old = reflect.New(next.Type().Elem())
+		}
+		applyFileChanges(dst.Elem(), old.Elem(), next.Elem(), path, overridden, keep)
+
+	case next.Kind() == reflect.Map && !dst.IsNil() && !next.IsNil():
+		iter := next.MapRange()
+		for iter.Next() {
+			key, value := iter.Key(), iter.Value()
+			old := prev.MapIndex(key)
+			if !old.IsValid() {
+				old = reflect.Zero(value.Type())
+			}
+			entry := reflect.New(value.Type()).Elem()
+			if current := dst.MapIndex(key); current.IsValid() {
+				entry.Set(current)
+			}
+			applyFileChanges(entry, old, value, joinPath(path, fmt.Sprint(key.Interface())), overridden, keep)
+			dst.SetMapIndex(key, entry)
+		}
+		if prev.IsNil() {
+			return
+		}
+		// An entry the files no longer hold is removed unless overridden.
+		iter = prev.MapRange()
+		for iter.Next() {
+			key := iter.Key()
+			if next.MapIndex(key).IsValid() {
+				continue
+			}
+			current := dst.MapIndex(key)
+			entryPath := joinPath(path, fmt.Sprint(key.Interface()))
+			if !current.IsValid() || keep(entryPath) {
+				continue
+			}
+			if reflect.DeepEqual(current.Interface(), iter.Value().Interface()) || !overridden(entryPath) {
+				dst.SetMapIndex(key, reflect.Value{})
+			}
+		}
+
+	case (next.Kind() == reflect.Array || next.Kind() == reflect.Slice && next.Len() == prev.Len() && next.Len() == dst.Len()) &&
+		next.Type().Elem().Kind() == reflect.Struct && isMergeableStruct(next.Type().Elem()):
+		for i := range next.Len() {
+			applyFileChanges(dst.Index(i), prev.Index(i), next.Index(i), joinPath(path, strconv.Itoa(i)), overridden, keep)
+		}
+
+	default:
+		if reflect.DeepEqual(dst.Interface(), prev.Interface()) || !overridden(path) {
+			dst.Set(next)
+		}
+	}
+}
+
+// copyValue returns a copy of v sharing none of its maps, lists and pointers
+// to configuration structs, so files merged into the copy leave v untouched.
+func copyValue(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Type()).Elem()
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() || v.Type().Elem().Kind() != reflect.Struct || !isMergeableStruct(v.Type().Elem()) {
+			c.Set(v)
+			break
+		}
+		c.Set(reflect.New(v.Type().Elem()))
+		c.Elem().Set(copyValue(v.Elem()))
+
+	case reflect.Struct:
+		c.Set(v)
+		for i := range v.NumField() {
+			if v.Type().Field(i).IsExported() {
+				c.Field(i).Set(copyValue(v.Field(i)))
+			}
+		}
+
+	case reflect.Slice:
+		if v.IsNil() {
+			break
+		}
+		c.Set(reflect.MakeSlice(v.Type(), v.Len(), v.Len()))
+		for i := range v.Len() {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+
+	case reflect.Array:
+		for i := range v.Len() {
+			c.Index(i).Set(copyValue(v.Index(i)))
+		}
+
+	case reflect.Map:
+		if v.IsNil() {
+			break
+		}
+		c.Set(reflect.MakeMapWithSize(v.Type(), v.Len()))
+		iter := v.MapRange()
+		for iter.Next() {
+			c.SetMapIndex(iter.Key(), copyValue(iter.Value()))
+		}
+
+	default:
+		c.Set(v)
+	}
+	return c
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+// isMergeableStruct reports whether t is a struct whose exported fields are
+// configuration fields of their own, rather than a value such as time.Time or
+// a text-decoded type that must be replaced as a whole.
+func isMergeableStruct(t reflect.Type) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return false
+	}
+	for i := range t.NumField() {
+		if t.Field(i).IsExported() {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldValues captures the current value of every flat field of conf. Values
+// are copied with their maps and lists, so later in-place writes to conf do
+// not affect them. opts are those of the views of conf.
+func fieldValues(conf any, opts []flat.Option) (map[string]reflect.Value, error) {
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]reflect.Value, len(fields))
+	for _, f := range fields {
+		values[f.Name()] = copyValue(f.FieldValue())
+	}
+	return values, nil
+}
+
+// changedFields lists the flat fields of conf whose value differs from before.
+// A field that did not exist before, such as a new map entry, is reported when
+// it is not zero, and so is a field that no longer exists, such as a removed
+// map entry, when it was not zero.
+func changedFields(before map[string]reflect.Value, conf any, opts []flat.Option) ([]plugins.FieldChange, error) {
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return nil, err
+	}
+	var changes []plugins.FieldChange
+	seen := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		seen[f.Name()] = struct{}{}
+		old, ok := before[f.Name()]
+		if ok && reflect.DeepEqual(old.Interface(), f.FieldValue().Interface()) {
+			continue
+		}
+		if !ok && f.IsZero() {
+			continue
+		}
+		changes = append(changes, plugins.FieldChange{FieldName: f.Name()})
+	}
+	for _, name := range slices.Sorted(maps.Keys(before)) {
+		if _, ok := seen[name]; !ok && !before[name].IsZero() {
+			changes = append(changes, plugins.FieldChange{FieldName: name})
+		}
+	}
+	return changes, nil
+}
@@ -0,0 +1,430 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/env"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/plugins/validate"
+)
+
+type refreshServer struct {
+	Name string
+	Port int
+}
+
+type refreshFileConfig struct {
+	Host    string
+	Port    int
+	Labels  map[string]string
+	Servers []refreshServer
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileRefreshAppliesChangedValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Host": "a", "Port": 1, "Labels": {"team": "core"}, "Servers": [{"Name": "one", "Port": 1}]}`)
+	t.Setenv("HOST", "from-env")
+
+	value := refreshFileConfig{}
+	manager, err := xconfig.Custom(&value,
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+		env.New(""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if result := manager.Refresh(t.Context()); result.Published || result.Err != nil {
+		t.Fatalf("Refresh() of unchanged file = %+v, want nothing published", result)
+	}
+
+	writeFile(t, path, `{"Host": "b", "Port": 2, "Labels": {"team": "core", "tier": "1"}, "Servers": [{"Name": "one", "Port": 2}]}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil {
+		t.Fatalf("Refresh() error = %v", result.Err)
+	}
+
+	// Host changed in the file too, but the env override keeps winning.
+	wantChanges := []plugins.FieldChange{
+		{FieldName: "Labels"},
+		{FieldName: "Labels.tier"},
+		{FieldName: "Port"},
+		{FieldName: "Servers.0.Port"},
+	}
+	testutil.Equal(t, wantChanges, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[refreshFileConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := refreshFileConfig{
+		Host:    "from-env",
+		Port:    2,
+		Labels:  map[string]string{"team": "core", "tier": "1"},
+		Servers: []refreshServer{{Name: "one", Port: 2}},
+	}
+	testutil.Equal(t, want, snapshot)
+}
+
+func TestFileRefreshRemovesMapKeys(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Labels": {"team": "a", "tier": "b"}}`)
+
+	value := refreshFileConfig{}
+	manager, err := xconfig.Custom(&value, loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A key removed from the file is removed from the map.
+	writeFile(t, path, `{"Labels": {"team": "a"}}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Labels"}, {FieldName: "Labels.tier"}}, result.Changes)
+	snapshot, err := xconfig.Snapshot[refreshFileConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, map[string]string{"team": "a"}, snapshot.Labels)
+
+	// A map replaced as a whole only holds the new keys.
+	writeFile(t, path, `{"Labels": {"env": "prod"}}`)
+	result = manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Labels"}, {FieldName: "Labels.env"}, {FieldName: "Labels.team"}}, result.Changes)
+	snapshot, err = xconfig.Snapshot[refreshFileConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, map[string]string{"env": "prod"}, snapshot.Labels)
+}
+
+func TestFileRefreshKeepsLastKnownGoodOnBrokenFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Host": "a", "Port": 1}`)
+
+	value := refreshFileConfig{}
+	manager, err := xconfig.Custom(&value, loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, `{"Host": "b", `)
+	if result := manager.Refresh(t.Context()); result.Err == nil || result.Published {
+		t.Fatalf("Refresh() of broken file = %+v, want error without publication", result)
+	}
+
+	writeFile(t, path, `{"Host": "b", "Port": "not-a-number"}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil {
+		t.Fatalf("Refresh() error = %v", result.Err)
+	}
+	if len(result.Warnings) != 1 {
+		t.Fatalf("Refresh() warnings = %v, want one", result.Warnings)
+	}
+	var decodeErr *loader.FieldDecodeError
+	if !errors.As(result.Warnings[0], &decodeErr) || decodeErr.FieldName != "Port" || decodeErr.File != path {
+		t.Fatalf("Refresh() warning = %v, want FieldDecodeError for Port", result.Warnings[0])
+	}
+	if !errors.Is(result.Warnings[0], loader.ErrInvalidFieldValue) {
+		t.Fatalf("Refresh() warning = %v, want ErrInvalidFieldValue", result.Warnings[0])
+	}
+
+	snapshot, err := xconfig.Snapshot[refreshFileConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, refreshFileConfig{Host: "b", Port: 1}, snapshot)
+}
+
+func TestFileRefreshReappliesAfterRejectedCycle(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Host string
+		Port int `validate:"max=100"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Host": "a", "Port": 1}`)
+
+	value := config{}
+	manager, err := xconfig.Custom(&value,
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+		validate.New(),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, `{"Host": "b", "Port": 500}`)
+	var rulesErr *validate.RulesError
+	if result := manager.Refresh(t.Context()); !errors.As(result.Err, &rulesErr) || result.Published {
+		t.Fatalf("Refresh() of invalid file = %+v, want validation error without publication", result)
+	}
+
+	// The rejected values were never published, so the fixed file applies
+	// in full instead of looking overridden.
+	writeFile(t, path, `{"Host": "b", "Port": 2}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() of fixed file = %+v, want publication", result)
+	}
+
+	snapshot, err := xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Host: "b", Port: 2}, snapshot)
+}
+
+type refreshSection struct {
+	DSN  string
+	Rate float64
+}
+
+func TestFileRefreshMergesOptionalSections(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Sentry": {"DSN": "a", "Rate": 0.1}}`)
+	t.Setenv("SENTRY_DSN", "from-env")
+
+	value := struct{ Sentry *refreshSection }{}
+	manager, err := xconfig.Custom(&value,
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+		env.New(""),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, `{"Sentry": {"DSN": "b", "Rate": 0.5}}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil {
+		t.Fatalf("Refresh() error = %v", result.Err)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Sentry.Rate"}}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[struct{ Sentry *refreshSection }](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, refreshSection{DSN: "from-env", Rate: 0.5}, *snapshot.Sentry)
+}
+
+func TestFileRefreshKeepsOverridesOfAddedKeys(t *testing.T) {
+	type config struct {
+		Host  string `default:"localhost"`
+		Port  int    `default:"80"`
+		Debug bool
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	writeFile(t, path, `{"Debug": true}`)
+	t.Setenv("PORT", "9000")
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	value := config{}
+	manager, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Host and Port are new in the file: Host replaces its default while
+	// Port keeps the env value.
+	writeFile(t, path, `{"Debug": true, "Host": "file", "Port": 1}`)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Host"}}, result.Changes)
+
+	snapshot, err := xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Host: "file", Port: 9000, Debug: true}, snapshot)
+
+	// A key removed from the file takes its default again.
+	writeFile(t, path, `{"Debug": true, "Port": 1}`)
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err = xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Host: "localhost", Port: 9000, Debug: true}, snapshot)
+}
+
+func TestFileReplacesMapDefaults(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Labels map[string]string `default:"env=dev,team=core"`
+		Hosts  []string          `default:"a,b"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Labels": {"tier": "1"}}`)
+
+	l := newJSONLoader(t)
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+	value := config{}
+	manager, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A default only applies to a field no file set, so it is never merged
+	// with the value of a file.
+	testutil.Equal(t, config{Labels: map[string]string{"tier": "1"}, Hosts: []string{"a", "b"}}, value)
+
+	writeFile(t, path, `{"Labels": {"tier": "2"}, "Hosts": ["c"]}`)
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err := xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Labels: map[string]string{"tier": "2"}, Hosts: []string{"c"}}, snapshot)
+
+	// Once the file no longer holds them, the fields take their defaults.
+	writeFile(t, path, `{}`)
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err = xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Labels: map[string]string{"env": "dev", "team": "core"}, Hosts: []string{"a", "b"}}, snapshot)
+}
+
+func TestFileRefreshRecordsFieldsOfKeptCycles(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Host string
+		Port int `validate:"max=100"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Host": "a"}`)
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	value := config{}
+	manager, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv(), xconfig.WithPlugins(validate.New()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A rejected cycle leaves the recorded fields of the file unchanged.
+	writeFile(t, path, `{"Host": "a", "Port": 500, "Extra": 1}`)
+	if result := manager.Refresh(t.Context()); result.Err == nil {
+		t.Fatalf("Refresh() = %+v, want a validation error", result)
+	}
+	testutil.Equal(t, map[string]struct{}{"Host": {}}, l.PresentFields())
+	testutil.Equal(t, map[string][]string{}, l.GetUnknownFields())
+
+	writeFile(t, path, `{"Host": "a", "Port": 5, "Extra": 1}`)
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, map[string]struct{}{"Extra": {}, "Host": {}, "Port": {}}, l.PresentFields())
+	testutil.Equal(t, map[string][]string{path: {"Extra"}}, l.GetUnknownFields())
+}
+
+func TestFileRefreshReplacesDefaultsOfMapEntries(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Port int `default:"80"`
+	}
+	type config struct {
+		Servers map[string]server
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Servers": {"a": {}}}`)
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{"json": json.Unmarshal})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	value := config{}
+	manager, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Servers: map[string]server{"a": {Port: 80}}}, value)
+
+	// The default filling the entry is no override of the file.
+	writeFile(t, path, `{"Servers": {"a": {"Port": 1}}}`)
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	snapshot, err := xconfig.Snapshot[config](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, config{Servers: map[string]server{"a": {Port: 1}}}, snapshot)
+}
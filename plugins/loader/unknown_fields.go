@@ -7,6 +7,8 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/sxwebdev/xconfig/internal/utils"
 )
 
 // UnknownFieldsError represents an error when unknown fields are found in configuration files.
@@ -53,6 +55,10 @@ func findUnknownFields(data []byte, v any, unmarshal Unmarshal) ([]string, error
 		}
 	}
 
+	if aligned, ok := alignWithType(raw, reflect.TypeOf(v)).(map[string]any); ok {
+		raw = aligned
+	}
+
 	// Get valid field names from struct
 	validFields := getValidFields(reflect.TypeOf(v))
 
@@ -67,7 +73,25 @@ func findUnknownFields(data []byte, v any, unmarshal Unmarshal) ([]string, error
 //
 // Example: for {"indexers": {"bsc": {"parser": {"enabled": false}}}}
 // it will include: "indexers.bsc.parser.enabled".
-func findPresentFields(data []byte, unmarshal Unmarshal) (map[string]struct{}, error) {
+//
+// conf is the configuration the file is decoded into; it is only used to align
+// the raw data with its type (see alignWithType), so that paths match the flat
+// field names of conf.
+func findPresentFields(data []byte, conf any, unmarshal Unmarshal) (map[string]struct{}, error) {
+	raw, err := decodeRaw(data, conf, unmarshal)
+	if err != nil {
+		// If we can't parse, we can't track presence.
+		return nil, err
+	}
+
+	present := make(map[string]struct{})
+	collectLeafPaths("", raw, present)
+	return present, nil
+}
+
+// decodeRaw decodes data into generic maps and slices, falling back to JSON,
+// and aligns the result with the type of conf (see alignWithType).
+func decodeRaw(data []byte, conf any, unmarshal Unmarshal) (map[string]any, error) {
 	var raw map[string]any
 
 	err := unmarshal(data, &raw)
@@ -75,14 +99,89 @@ func findPresentFields(data []byte, unmarshal Unmarshal) (map[string]struct{}, e
 		// Also try JSON as fallback
 		err = json.Unmarshal(data, &raw)
 		if err != nil {
-			// If we can't parse, we can't track presence.
 			return nil, err
 		}
 	}
 
-	present := make(map[string]struct{})
-	collectLeafPaths("", raw, present)
-	return present, nil
+	if aligned, ok := alignWithType(raw, reflect.TypeOf(conf)).(map[string]any); ok {
+		raw = aligned
+	}
+	return raw, nil
+}
+
+// alignWithType rewrites data, decoded into generic maps and slices, so that
+// its shape follows t: an object found where t expects a list of structs or
+// maps is wrapped into a one-element list. Formats such as HCL cannot tell a
+// single block from a list of blocks without the target type, while the
+// decoder resolves it against the struct, so the paths of the raw data must
+// do the same to match the flat field names.
+func alignWithType(data any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return data
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		m, ok := data.(map[string]any)
+		if !ok {
+			return data
+		}
+		for key, value := range m {
+			if field, ok := fieldForKey(t, key); ok {
+				m[key] = alignWithType(value, field.Type)
+			}
+		}
+	case reflect.Map:
+		if m, ok := data.(map[string]any); ok {
+			for key, value := range m {
+				m[key] = alignWithType(value, t.Elem())
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		switch v := data.(type) {
+		case []any:
+			for i, item := range v {
+				v[i] = alignWithType(item, t.Elem())
+			}
+		case map[string]any:
+			elem := t.Elem()
+			for elem.Kind() == reflect.Pointer {
+				elem = elem.Elem()
+			}
+			if elem.Kind() == reflect.Struct || elem.Kind() == reflect.Map {
+				return []any{alignWithType(v, t.Elem())}
+			}
+		}
+	}
+	return data
+}
+
+// fieldForKey finds the field of struct type t, including promoted fields of
+// embedded structs, that a file key refers to. Keys match case-insensitively.
+func fieldForKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && fieldType.Kind() == reflect.Struct {
+			if promoted, ok := fieldForKey(fieldType, key); ok {
+				return promoted, true
+			}
+			continue
+		}
+		if name, ok := FileKey(field); ok && strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
 }
 
 func collectLeafPaths(prefix string, data any, out map[string]struct{}) {
@@ -136,6 +235,15 @@ func getValidFields(t reflect.Type) map[string]bool {
 	return fields
 }
 
+// FileKey returns the key a configuration file uses for field: the name in
+// its yaml, json, toml or hcl tag, the first of them that names it, or else
+// its Go name, matched case-insensitively. It reports false for fields tagged
+// "-". Unknown and present fields are detected with these keys, so decoders
+// should match keys the same way.
+func FileKey(field reflect.StructField) (string, bool) {
+	return utils.FileKey(field)
+}
+
 // collectStructFields recursively collects all valid field paths from a struct.
 func collectStructFields(t reflect.Type, fields map[string]bool) {
 	for i := 0; i < t.NumField(); i++ {
@@ -146,38 +254,10 @@ func collectStructFields(t reflect.Type, fields map[string]bool) {
 			continue
 		}
 
-		// Get field name from tags (try yaml, json, then use struct field name)
-		fieldName := ""
-
-		// Try YAML tag first (most common in your case)
-		if yamlTag := field.Tag.Get("yaml"); yamlTag != "" {
-			parts := strings.Split(yamlTag, ",")
-			if parts[0] == "-" {
-				// Skip this field if tagged with "-"
-				continue
-			}
-			if parts[0] != "" {
-				fieldName = parts[0]
-			}
-		}
-
-		// Try JSON tag if yaml tag not found
-		if fieldName == "" {
-			if jsonTag := field.Tag.Get("json"); jsonTag != "" {
-				parts := strings.Split(jsonTag, ",")
-				if parts[0] == "-" {
-					// Skip this field if tagged with "-"
-					continue
-				}
-				if parts[0] != "" {
-					fieldName = parts[0]
-				}
-			}
-		}
-
-		// If no tag found, use field name
-		if fieldName == "" {
-			fieldName = field.Name
+		fieldName, ok := FileKey(field)
+		if !ok {
+			// Skip this field if tagged with "-"
+			continue
 		}
 
 		// Get field type and dereference if pointer
@@ -206,8 +286,8 @@ func collectStructFields(t reflect.Type, fields map[string]bool) {
 			}
 		}
 
-		// Handle slices of structs
-		if fieldType.Kind() == reflect.Slice {
+		// Handle slices and arrays of structs
+		if fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
 			elemType := fieldType.Elem()
 			if elemType.Kind() == reflect.Pointer {
 				elemType = elemType.Elem()
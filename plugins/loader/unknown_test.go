@@ -4,9 +4,12 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
+	"reflect"
 	"testing"
 
 	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
 	"github.com/sxwebdev/xconfig/plugins/loader"
 )
 
@@ -388,3 +391,67 @@ func TestArraysWithUnknownFields(t *testing.T) {
 		t.Error("expected to find unknown fields in array elements")
 	}
 }
+
+func TestFixedArraysWithDisallowUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `json:"host"`
+	}
+	type config struct {
+		Servers [2]server  `json:"servers"`
+		Backups [1]*server `json:"backups"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"servers": [{"host": "a"}, {"host": "b"}], "backups": [{"host": "c"}]}`)
+	l := newJSONLoader(t)
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var value config
+	if _, err := xconfig.Load(&value, xconfig.WithLoader(l), xconfig.WithDisallowUnknownFields(), xconfig.WithSkipFlags(), xconfig.WithSkipEnv()); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	testutil.Equal(t, config{Servers: [2]server{{Host: "a"}, {Host: "b"}}, Backups: [1]*server{{Host: "c"}}}, value)
+
+	writeFile(t, path, `{"servers": [{"host": "a", "port": 1}]}`)
+	_, err := xconfig.Load(&config{}, xconfig.WithLoader(l), xconfig.WithDisallowUnknownFields(), xconfig.WithSkipFlags(), xconfig.WithSkipEnv())
+	var unknownErr *loader.UnknownFieldsError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Load() error = %v, want an UnknownFieldsError", err)
+	}
+	testutil.Equal(t, map[string][]string{path: {"servers[].port"}}, unknownErr.Fields)
+}
+
+func TestFileKey(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Plain   string
+		YAML    string `yaml:"yaml_key" json:"other_key"`
+		JSON    string `yaml:",omitempty" json:"json_key"`
+		TOML    string `toml:"toml_key" hcl:"hcl_key"`
+		HCL     string `hcl:"hcl_key,block"`
+		Skipped string `json:"-" toml:"toml_key"`
+	}
+
+	type key struct {
+		Name string
+		OK   bool
+	}
+	var got []key
+	for _, field := range reflect.VisibleFields(reflect.TypeFor[config]()) {
+		name, ok := loader.FileKey(field)
+		got = append(got, key{name, ok})
+	}
+	testutil.Equal(t, []key{
+		{"Plain", true},
+		{"yaml_key", true},
+		{"json_key", true},
+		{"toml_key", true},
+		{"hcl_key", true},
+		{"", false},
+	}, got)
+}
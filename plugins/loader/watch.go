@@ -0,0 +1,68 @@
+package loader
+
+import (
+	"context"
+	"time"
+)
+
+// DefaultWatchDebounce is the quiet period used when file watching is enabled
+// without an explicit debounce.
+const DefaultWatchDebounce = 100 * time.Millisecond
+
+// Notify implements plugins.Notifier. When watching is enabled it reports
+// changes to the file, to its directory entry, and to the target of a symlink
+// pointing at it, including the atomic "..data" symlink swap Kubernetes uses
+// for mounted ConfigMaps and Secrets. Bursts of events are debounced into one
+// notification. It returns a nil channel when watching is disabled.
+func (v *fileWalker) Notify(ctx context.Context) (<-chan struct{}, error) {
+	if !v.watch {
+		return nil, nil
+	}
+	return notify(ctx, v.filepath, v.watchDebounce)
+}
+
+// notify reports the debounced changes of path, a file or a pattern matching
+// the files of a directory.
+func notify(ctx context.Context, path string, debounce time.Duration) (<-chan struct{}, error) {
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+
+	events, err := watchFile(ctx, path, debounce)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make(chan struct{}, 1)
+	go func() {
+		defer close(notifications)
+		debounceNotifications(ctx, events, notifications, debounce)
+	}()
+	return notifications, nil
+}
+
+// debounceNotifications forwards one notification once events has been quiet
+// for the debounce period. A pending notification is never duplicated, so a
+// slow consumer sees at most one.
+func debounceNotifications(ctx context.Context, events <-chan struct{}, out chan<- struct{}, debounce time.Duration) {
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case _, ok := <-events:
+			if !ok {
+				return
+			}
+			timer.Reset(debounce)
+		case <-timer.C:
+			select {
+			case out <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_TO | syscall.IN_MOVED_FROM | syscall.IN_DELETE | syscall.IN_ATTRIB
+
+// watchFile reports raw change events for path through inotify. It watches
+// the directory holding path, so that replacing the file or swapping a
+// symlink is seen, and the directory of the symlink target, which is
+// re-resolved after every event.
+func watchFile(ctx context.Context, path string, _ time.Duration) (<-chan struct{}, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	// A non-blocking descriptor is served by the runtime poller, so closing
+	// the file unblocks a pending Read.
+	file := os.NewFile(uintptr(fd), "inotify")
+
+	w := &inotifyWatch{fd: fd, path: path, watches: make(map[int32]string)}
+	if err := w.add(filepath.Dir(path)); err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+	w.followTarget()
+
+	events := make(chan struct{}, 1)
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+	go func() {
+		defer close(events)
+		buf := make([]byte, 64*(syscall.SizeofInotifyEvent+syscall.NAME_MAX+1))
+		for {
+			n, err := file.Read(buf)
+			if err != nil {
+				return
+			}
+			if !w.relevant(buf[:n]) {
+				continue
+			}
+			w.followTarget()
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return events, nil
+}
+
+type inotifyWatch struct {
+	fd      int
+	path    string
+	target  string
+	watches map[int32]string // watch descriptor -> watched directory
+}
+
+func (w *inotifyWatch) add(dir string) error {
+	wd, err := syscall.InotifyAddWatch(w.fd, dir, inotifyMask)
+	if err != nil {
+		return &os.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	w.watches[int32(wd)] = dir
+	return nil
+}
+
+// followTarget watches the directory of the file path currently resolves to.
+// Watches on earlier targets are kept: inotify drops them on its own once a
+// directory is removed, as happens to old Kubernetes ConfigMap revisions.
+func (w *inotifyWatch) followTarget() {
+	target, err := filepath.EvalSymlinks(w.path)
+	if err != nil || target == w.target {
+		return
+	}
+	w.target = target
+	dir := filepath.Dir(target)
+	for _, watched := range w.watches {
+		if watched == dir {
+			return
+		}
+	}
+	_ = w.add(dir)
+}
+
+// relevant reports whether a batch of inotify events concerns the watched
+// file, a file matching it when it is a pattern, its symlink target, or a
+// Kubernetes "..data"-style entry.
+func (w *inotifyWatch) relevant(buf []byte) bool {
+	names := map[string]struct{}{
+		filepath.Base(w.path): {},
+	}
+	if w.target != "" {
+		names[filepath.Base(w.target)] = struct{}{}
+	}
+
+	for offset := 0; offset+syscall.SizeofInotifyEvent <= len(buf); {
+		event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+		nameStart := offset + syscall.SizeofInotifyEvent
+		nameEnd := nameStart + int(event.Len)
+		if nameEnd > len(buf) {
+			return true
+		}
+		name := strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00")
+		offset = nameEnd
+
+		if event.Mask&syscall.IN_IGNORED != 0 {
+			delete(w.watches, event.Wd)
+			continue
+		}
+		if _, ok := names[name]; ok || strings.HasPrefix(name, "..") {
+			return true
+		}
+		if matched, _ := filepath.Match(filepath.Base(w.path), name); matched {
+			return true
+		}
+	}
+	return false
+}
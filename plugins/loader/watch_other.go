@@ -0,0 +1,88 @@
+//go:build !linux
+
+package loader
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// watchFile reports raw change events for path by polling it, for platforms
+// without inotify. It compares the resolved symlink target, size and
+// modification time every debounce period, of every matching file when path
+// is a pattern.
+func watchFile(ctx context.Context, path string, debounce time.Duration) (<-chan struct{}, error) {
+	last, err := statPath(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	events := make(chan struct{}, 1)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(debounce)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				current, _ := statPath(path)
+				if current == last {
+					continue
+				}
+				last = current
+				select {
+				case events <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+type fileState struct {
+	target  string
+	size    int64
+	modTime time.Time
+}
+
+// statPath returns the state of path, or the combined state of the files
+// matching it when it is a pattern.
+func statPath(path string) (fileState, error) {
+	if !hasMeta(path) {
+		return statFile(path)
+	}
+	matches, err := filepath.Glob(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	var state fileState
+	for _, match := range matches {
+		file, err := statFile(match)
+		if err != nil {
+			continue
+		}
+		state.target += file.target + string(filepath.ListSeparator)
+		state.size += file.size
+		if file.modTime.After(state.modTime) {
+			state.modTime = file.modTime
+		}
+	}
+	return state, nil
+}
+
+func statFile(path string) (fileState, error) {
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return fileState{}, err
+	}
+	info, err := os.Stat(target)
+	if err != nil {
+		return fileState{}, err
+	}
+	return fileState{target: target, size: info.Size(), modTime: info.ModTime()}, nil
+}
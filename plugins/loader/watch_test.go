@@ -0,0 +1,130 @@
+package loader_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+func startWatchedRefresh(t *testing.T, path string, value *refreshFileConfig) (xconfig.Config, <-chan xconfig.RefreshResult) {
+	t.Helper()
+	manager, err := xconfig.Custom(value,
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{Watch: true, WatchDebounce: 10 * time.Millisecond}, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	// The interval is far beyond the test timeout, so only the watcher can
+	// trigger the refresh.
+	results, err := manager.StartRefresh(t.Context(), time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(manager.StopRefresh)
+	return manager, results
+}
+
+func waitForPort(t *testing.T, manager xconfig.Config, results <-chan xconfig.RefreshResult, port int) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case result := <-results:
+			if result.Err != nil {
+				t.Fatalf("refresh error = %v", result.Err)
+			}
+			snapshot, err := xconfig.Snapshot[refreshFileConfig](manager)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if snapshot.Port == port {
+				testutil.Equal(t, []plugins.FieldChange{{FieldName: "Port"}}, result.Changes)
+				return
+			}
+		case <-timeout:
+			t.Fatalf("no refresh published Port = %d", port)
+		}
+	}
+}
+
+func TestWatchRefreshesOnFileWrite(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Port": 1}`)
+
+	value := refreshFileConfig{}
+	manager, results := startWatchedRefresh(t, path, &value)
+
+	writeFile(t, path, `{"Port": 2}`)
+	waitForPort(t, manager, results, 2)
+}
+
+func TestWatchRefreshesOnConfigMapSwap(t *testing.T) {
+	t.Parallel()
+
+	// Kubernetes mounts config.json -> ..data/config.json, where ..data is a
+	// symlink to a timestamped directory that is swapped atomically.
+	dir := t.TempDir()
+	mustMkdir(t, filepath.Join(dir, "..v1"))
+	writeFile(t, filepath.Join(dir, "..v1", "config.json"), `{"Port": 1}`)
+	mustSymlink(t, "..v1", filepath.Join(dir, "..data"))
+	mustSymlink(t, filepath.Join("..data", "config.json"), filepath.Join(dir, "config.json"))
+
+	value := refreshFileConfig{}
+	manager, results := startWatchedRefresh(t, filepath.Join(dir, "config.json"), &value)
+
+	mustMkdir(t, filepath.Join(dir, "..v2"))
+	writeFile(t, filepath.Join(dir, "..v2", "config.json"), `{"Port": 2}`)
+	mustSymlink(t, "..v2", filepath.Join(dir, "..data_tmp"))
+	if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.RemoveAll(filepath.Join(dir, "..v1")); err != nil {
+		t.Fatal(err)
+	}
+	waitForPort(t, manager, results, 2)
+}
+
+func TestWatchDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	writeFile(t, path, `{"Port": 1}`)
+
+	notifier, ok := loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil).(plugins.Notifier)
+	if !ok {
+		t.Fatal("file plugin does not implement plugins.Notifier")
+	}
+	notifications, err := notifier.Notify(t.Context())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notifications != nil {
+		t.Fatal("Notify() returned a channel while watching is disabled")
+	}
+}
+
+func mustMkdir(t *testing.T, path string) {
+	t.Helper()
+	if err := os.Mkdir(path, 0o700); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func mustSymlink(t *testing.T, oldname, newname string) {
+	t.Helper()
+	if err := os.Symlink(oldname, newname); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -64,13 +64,109 @@ type RefreshOutcome struct {
 }
 
 // Refreshable is implemented by plugins that support background config refresh.
-// Examples: vault, consul, etcd, AWS SSM sourcers.
+// Examples: file loaders, vault, consul, etcd, AWS SSM sourcers.
 type Refreshable interface {
 	Plugin
 	// Refresh re-fetches values and applies them to target. target is a private
 	// working copy owned by xconfig and is discarded when Refresh returns an
 	// error. Changes must describe mutations made during this call. Plugins
-	// should compare against target instead of advancing a private baseline,
-	// because failed cycles are discarded without a commit callback.
+	// should compare against target instead of advancing a private baseline;
+	// a plugin that keeps one implements RefreshCommitter.
 	Refresh(ctx context.Context, target any) (RefreshOutcome, error)
 }
+
+// RefreshCommitter is implemented by refreshable plugins that keep a private
+// baseline of what they applied, such as the file loader. After a refresh
+// cycle that called Refresh, xconfig calls EndRefresh with kept set when the
+// working copy is kept for later cycles, and unset when it was discarded
+// because a plugin or a validator failed.
+type RefreshCommitter interface {
+	Refreshable
+	EndRefresh(kept bool)
+}
+
+// OverrideReceiver is implemented by refreshable plugins that leave alone the
+// values set by the sources registered after them, such as the file loader.
+// Before every Refresh, xconfig calls SetOverrides with the flat names of the
+// fields whose value was last set by such a source, unless it is a Fallback.
+type OverrideReceiver interface {
+	Refreshable
+	SetOverrides(fieldNames map[string]struct{})
+}
+
+// Fallback is implemented by plugins that only set the fields other sources
+// left unset, such as defaults. The values they set are not overrides, so a
+// refreshing source registered before them may replace them.
+type Fallback interface {
+	Plugin
+	// Fallback marks the plugin; xconfig never calls it.
+	Fallback()
+}
+
+// ViewReceiver is implemented by plugins that view the configuration
+// themselves with flat.View. Before Walk and Visit, xconfig calls
+// SetViewOptions with the options its own views use, which the plugin passes
+// to flat.View so all views behave alike.
+type ViewReceiver interface {
+	Plugin
+	SetViewOptions(opts []flat.Option)
+}
+
+// ViewOptionProvider is implemented by plugins that configure every view of
+// the configuration, such as defaults applying the defaults of the active
+// profiles to optional sections allocated by other plugins. xconfig collects
+// the options before viewing the configuration.
+type ViewOptionProvider interface {
+	Plugin
+	ViewOptions() []flat.Option
+}
+
+// Validator is implemented by plugins that check the configuration, such as
+// the validate plugin. Refresh runs every Validator against the refreshed
+// working copy and keeps the previous snapshot when one fails.
+type Validator interface {
+	Plugin
+	Validate(target any) error
+}
+
+// Notifier is implemented by refreshable plugins that can tell when their
+// source changed, such as watched files. Config.StartRefresh refreshes as soon
+// as a notification arrives instead of waiting for its next tick.
+type Notifier interface {
+	Refreshable
+	// Notify returns a channel that receives a value after the source changed,
+	// or a nil channel when the plugin has nothing to watch. The channel is
+	// closed once ctx is done.
+	Notify(ctx context.Context) (<-chan struct{}, error)
+}
+
+// Source describes where a field value came from. It never contains the value
+// itself, so it is safe to log or expose on a debug endpoint.
+type Source struct {
+	// Plugin is the kind of source, e.g. "default", "file", "env" or "flag".
+	Plugin string
+	// Name identifies the origin within the plugin, e.g. an env var name, a
+	// flag name or a file path. It is empty when the plugin has nothing more
+	// specific to report.
+	Name string
+}
+
+// SourceReporter is implemented by plugins that can describe the origin of the
+// values they apply. After every Parse and Refresh, xconfig attributes each
+// field whose value the plugin changed to it and calls Source with the field's
+// flat name. Plugins that do not implement it are reported by their type.
+type SourceReporter interface {
+	Plugin
+	Source(fieldName string) Source
+}
+
+// SetReporter is implemented by plugins that know which fields their Parse
+// set. xconfig attributes each of them to the plugin, even when the value it
+// set equals the one the field held, such as PORT=80 over `default:"80"`.
+// The fields other plugins set are found by comparing the configuration
+// before and after their Parse, which misses such values.
+type SetReporter interface {
+	Plugin
+	// SetFields returns the flat names of the fields the last Parse set.
+	SetFields() []string
+}
@@ -0,0 +1,177 @@
+// Package required enforces the required tag for xconfig
+package required
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+const tag = "required"
+
+func init() {
+	plugins.RegisterTag(tag)
+}
+
+// MissingField describes a required field that no source has set.
+type MissingField struct {
+	// Name is the flat field name (e.g. "Nodes.0.Password").
+	Name string
+	// Env is the environment variable the field can be set through, if any.
+	Env string
+	// Flag is the command-line flag the field can be set through, if any.
+	Flag string
+}
+
+// MissingFieldsError is returned by Parse when required fields are unset.
+type MissingFieldsError struct {
+	// Fields lists every unset required field in flat field order.
+	Fields []MissingField
+}
+
+// Error implements the error interface.
+func (e *MissingFieldsError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		var via []string
+		if f.Env != "" {
+			via = append(via, "env "+f.Env)
+		}
+		if f.Flag != "" {
+			via = append(via, "flag "+f.Flag)
+		}
+		if len(via) == 0 {
+			parts = append(parts, f.Name)
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s (%s)", f.Name, strings.Join(via, ", ")))
+	}
+	return "required fields are not set: " + strings.Join(parts, "; ")
+}
+
+// IsRequired reports whether f is tagged as required, either with a required
+// tag other than required:"false" or with a required rule in its validate tag.
+func IsRequired(f flat.Field) bool {
+	if value, ok := f.Tag(tag); ok && value != "false" {
+		return true
+	}
+	if rules, ok := f.Tag("validate"); ok {
+		for rule := range strings.SplitSeq(rules, ",") {
+			if strings.TrimSpace(rule) == tag {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// New returns a plugin failing Parse with a *MissingFieldsError when a
+// required field still holds its zero value. It must be registered after the
+// plugins that may set those fields.
+//
+// Fields inside slice and map entries are checked too. Their env var names are
+// derived like the env plugin does with the same envPrefix; entries created
+// while parsing cannot be set through flags.
+func New(envPrefix string) plugins.Plugin {
+	return &visitor{envPrefix: envPrefix}
+}
+
+var _ plugins.Validator = (*visitor)(nil)
+
+type visitor struct {
+	conf      any
+	fields    flat.Fields
+	envPrefix string
+	// viewOptions configure the views of the configuration.
+	viewOptions []flat.Option
+}
+
+// Walk captures the conf reference so Parse sees slice and map entries
+// created by earlier plugins.
+func (v *visitor) Walk(conf any) error {
+	v.conf = conf
+	return nil
+}
+
+// SetViewOptions sets the options the plugin views the configuration with.
+func (v *visitor) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// Visit captures the fields whose Meta holds the env and flag names stamped
+// by the env and flag plugins.
+func (v *visitor) Visit(fields flat.Fields) error {
+	v.fields = fields
+	return nil
+}
+
+func (v *visitor) Parse() error {
+	if v.conf == nil {
+		return nil
+	}
+	return v.check(v.conf)
+}
+
+// SetFields returns no fields: the plugin only checks them.
+func (v *visitor) SetFields() []string {
+	return nil
+}
+
+// Validate checks a refreshed working copy of the configuration, so a
+// refresh that clears a required field is not published.
+func (v *visitor) Validate(target any) error {
+	return v.check(target)
+}
+
+// check reports the required fields of conf that hold their zero value.
+func (v *visitor) check(conf any) error {
+	fields, err := flat.View(conf, v.viewOptions...)
+	if err != nil {
+		return err
+	}
+
+	visited := make(map[string]flat.Field, len(v.fields))
+	envEnabled := false
+	for _, f := range v.fields {
+		visited[f.Name()] = f
+		if _, ok := f.Meta()["env"]; ok {
+			envEnabled = true
+		}
+	}
+
+	var envNames map[string]string
+	var missing []MissingField
+	for _, f := range fields {
+		if !IsRequired(f) || !f.IsZero() || flat.Unallocated(f) {
+			// Fields of an absent optional section are not required.
+			continue
+		}
+
+		field := MissingField{Name: f.Name()}
+		if known, ok := visited[f.Name()]; ok {
+			field.Env = known.Meta()["env"]
+			field.Flag = known.Meta()["flag"]
+		} else if envEnabled {
+			// An entry created while parsing: name it the way the env plugin
+			// names existing slice and map entries.
+			if envNames == nil {
+				envNames, err = flat.ExpandContainersFromKeys(conf, v.envPrefix, nil, v.viewOptions...)
+				if err != nil {
+					return err
+				}
+			}
+			field.Env = envNames[f.Name()]
+		}
+		if field.Env == "-" {
+			field.Env = ""
+		}
+		missing = append(missing, field)
+	}
+
+	if len(missing) > 0 {
+		return &MissingFieldsError{Fields: missing}
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package required_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/env"
+	"github.com/sxwebdev/xconfig/plugins/flag"
+	"github.com/sxwebdev/xconfig/plugins/required"
+)
+
+type node struct {
+	Host     string
+	Password string `required:"true"`
+}
+
+type database struct {
+	Host     string `validate:"required,hostname"`
+	Password string `required:"" env:"DB_PASSWORD"`
+	User     string `required:"false"`
+}
+
+type config struct {
+	Name     string `required:"true" flag:"name"`
+	Database database
+	Nodes    []node
+	Replicas map[string]node
+}
+
+func parse(t *testing.T, args []string) error {
+	t.Helper()
+	value := config{}
+	manager, err := xconfig.Custom(&value,
+		env.New("APP"),
+		flag.New("test", flag.ContinueOnError, args),
+		required.New("APP"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return manager.Parse()
+}
+
+func TestRequiredReportsMissingFields(t *testing.T) {
+	t.Setenv("APP_NODES_0_HOST", "node-0")
+	t.Setenv("APP_REPLICAS_EU_HOST", "replica-eu")
+
+	err := parse(t, nil)
+
+	var missingErr *required.MissingFieldsError
+	if !errors.As(err, &missingErr) {
+		t.Fatalf("Parse() error = %v, want *required.MissingFieldsError", err)
+	}
+	want := []required.MissingField{
+		{Name: "Name", Env: "APP_NAME", Flag: "-name"},
+		{Name: "Database.Host", Env: "APP_DATABASE_HOST", Flag: "-database-host"},
+		{Name: "Database.Password", Env: "APP_DB_PASSWORD", Flag: "-database-password"},
+		{Name: "Nodes.0.Password", Env: "APP_NODES_0_PASSWORD"},
+		{Name: "Replicas.EU.Password", Env: "APP_REPLICAS_EU_PASSWORD"},
+	}
+	testutil.Equal(t, want, missingErr.Fields)
+}
+
+func TestRequiredPassesWhenSet(t *testing.T) {
+	t.Setenv("APP_DATABASE_HOST", "db")
+	t.Setenv("APP_DB_PASSWORD", "secret")
+	t.Setenv("APP_NODES_0_PASSWORD", "secret")
+
+	if err := parse(t, []string{"-name", "api"}); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+}
+
+func TestMissingFieldsErrorMessage(t *testing.T) {
+	t.Parallel()
+
+	err := &required.MissingFieldsError{Fields: []required.MissingField{
+		{Name: "Name", Env: "NAME", Flag: "-name"},
+		{Name: "Nodes.0.Password"},
+	}}
+	testutil.Equal(t, "required fields are not set: Name (env NAME, flag -name); Nodes.0.Password", err.Error())
+}
@@ -25,6 +25,7 @@ func New(source Sourcer) plugins.Plugin {
 type secret struct {
 	fields flat.Fields
 	source Sourcer
+	set    []string
 }
 
 func makeSecretName(name string) string {
@@ -55,7 +56,23 @@ func (v *secret) Visit(f flat.Fields) error {
 	return nil
 }
 
+// Source reports the secret name a field was resolved from.
+func (v *secret) Source(fieldName string) plugins.Source {
+	for _, f := range v.fields {
+		if f.Name() == fieldName {
+			return plugins.Source{Plugin: tag, Name: f.Meta()[tag]}
+		}
+	}
+	return plugins.Source{Plugin: tag}
+}
+
+// SetFields returns the fields the last Parse read from the sourcer.
+func (v *secret) SetFields() []string {
+	return v.set
+}
+
 func (v *secret) Parse() error {
+	v.set = nil
 	for _, f := range v.fields {
 		name, ok := f.Meta()[tag]
 
@@ -77,6 +94,7 @@ func (v *secret) Parse() error {
 		if err != nil {
 			return err
 		}
+		v.set = append(v.set, f.Name())
 	}
 
 	return nil
@@ -0,0 +1,384 @@
+package validate
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+const tag = "validate"
+
+func init() {
+	plugins.RegisterTag(tag)
+}
+
+var durationType = reflect.TypeFor[time.Duration]()
+
+// FieldError describes a field whose value breaks one of its validate rules.
+// It never contains the value itself, which may be a secret.
+type FieldError struct {
+	// Field is the flat field name (e.g. "Servers.2.Port").
+	Field string
+	// Rule is the failing rule as written in the tag (e.g. "max=65535").
+	Rule string
+	// Reason describes the expected value, or why the rule is invalid.
+	Reason string
+	// Err is set when the rule itself is invalid for the field: a malformed
+	// argument or a rule that cannot apply to its type.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+}
+
+// Unwrap returns the error of an invalid rule.
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// RulesError is returned when fields break their validate tag rules or hold
+// invalid ones. It holds one entry per failing field, in flat field order.
+type RulesError struct {
+	Fields []*FieldError
+}
+
+// Error implements the error interface.
+func (e *RulesError) Error() string {
+	parts := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		parts = append(parts, f.Error())
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the field errors.
+func (e *RulesError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// Rules evaluates the validate tag of every flat field of conf and returns a
+// *RulesError listing the fields that break a rule. Rules are separated by
+// commas:
+//
+//	min=N, max=N  value bounds for numbers and durations (min=1s), length
+//	              bounds for strings and slices
+//	len=N         exact length of a string or slice
+//	oneof=a b c   value is one of the space separated options
+//	nonzero       value is not the zero value
+//	url           absolute URL with a scheme and a host
+//	hostport      host:port with a numeric port, the host may be empty
+//	cidr          CIDR prefix such as 10.0.0.0/8
+//	regexp=EXPR   string matches EXPR; it must be the last rule because the
+//	              expression may contain commas
+//	omitempty     skip the other rules while the value is zero
+//
+// Rules are evaluated against flat.View fields: they apply to the fields of
+// every slice and map element, and a slice or map of scalars is checked as a
+// whole. The entries of such a map carry no rules. Pointer fields are checked
+// through the value they point to; a nil pointer only fails nonzero.
+//
+// Rules it does not know, such as required (enforced by the required plugin)
+// or rules meant for an external validator, are ignored, so the tag can be
+// shared with github.com/go-playground/validator. A malformed rule argument or
+// a rule that cannot apply to its field is reported in the *RulesError with
+// FieldError.Err set; an invalid expression is reported even when the value
+// is empty or its section absent. opts are those of the views of conf, such
+// as its converters.
+func Rules(conf any, opts ...flat.Option) error {
+	fields, err := flat.View(conf, opts...)
+	if err != nil {
+		return err
+	}
+
+	var failed []*FieldError
+	for _, f := range fields {
+		tagValue, ok := f.Tag(tag)
+		if !ok || tagValue == "" {
+			continue
+		}
+		rules, fieldErr := parseRules(f, tagValue)
+		if fieldErr == nil && !flat.Unallocated(f) {
+			fieldErr = checkField(f, rules)
+		}
+		if fieldErr != nil {
+			failed = append(failed, fieldErr)
+		}
+	}
+
+	if len(failed) > 0 {
+		return &RulesError{Fields: failed}
+	}
+	return nil
+}
+
+// parseRules splits the validate tag of f into rules and reports an invalid
+// regular expression.
+func parseRules(f flat.Field, tagValue string) ([]string, *FieldError) {
+	rules := splitRules(tagValue)
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		if name == "regexp" {
+			if _, err := regexp.Compile(arg); err != nil {
+				return nil, invalidRule(f, rule, err)
+			}
+		}
+	}
+	return rules, nil
+}
+
+// invalidRule reports a rule that is malformed or cannot apply to f.
+func invalidRule(f flat.Field, rule string, err error) *FieldError {
+	return &FieldError{Field: f.Name(), Rule: rule, Reason: fmt.Sprintf("invalid rule %q: %v", rule, err), Err: err}
+}
+
+// checkField evaluates rules against f and reports the first rule it breaks
+// or that is invalid for it.
+func checkField(f flat.Field, rules []string) *FieldError {
+	if slices.Contains(rules, "omitempty") && f.IsZero() {
+		return nil
+	}
+
+	// A pointer is checked through its value. A nil pointer is unset, which
+	// only the nonzero rule rejects.
+	value := f.FieldValue()
+	unset := false
+	if value.Kind() == reflect.Pointer {
+		unset = value.IsNil()
+		value = value.Elem()
+	}
+	for _, rule := range rules {
+		name, arg, _ := strings.Cut(rule, "=")
+		if unset && name != "nonzero" {
+			continue
+		}
+		reason, err := checkRule(value, f.IsZero(), name, arg)
+		if err != nil {
+			return invalidRule(f, rule, err)
+		}
+		if reason != "" {
+			return &FieldError{Field: f.Name(), Rule: rule, Reason: reason}
+		}
+	}
+	return nil
+}
+
+// splitRules splits a tag into rules. A regexp rule takes the rest of the tag.
+func splitRules(rules string) []string {
+	var out []string
+	for rules != "" {
+		if strings.HasPrefix(rules, "regexp=") {
+			out = append(out, rules)
+			break
+		}
+		rule, rest, _ := strings.Cut(rules, ",")
+		if rule = strings.TrimSpace(rule); rule != "" {
+			out = append(out, rule)
+		}
+		rules = rest
+	}
+	return out
+}
+
+// checkRule returns the reason value breaks the rule, or an empty string when
+// it satisfies it or the rule is unknown.
+func checkRule(value reflect.Value, isZero bool, name, arg string) (string, error) {
+	switch name {
+	case "nonzero":
+		if isZero {
+			return "must be set to a non-zero value", nil
+		}
+		return "", nil
+	case "min", "max":
+		return checkBound(value, name, arg)
+	case "len":
+		n, err := strconv.Atoi(arg)
+		if err != nil {
+			return "", err
+		}
+		length, ok := lengthOf(value)
+		if !ok {
+			return "", fmt.Errorf("does not apply to %s", value.Type())
+		}
+		if length != n {
+			return fmt.Sprintf("must have length %d", n), nil
+		}
+		return "", nil
+	case "oneof":
+		options := strings.Fields(arg)
+		s, ok := scalarString(value)
+		if !ok {
+			return "", fmt.Errorf("does not apply to %s", value.Type())
+		}
+		if !slices.Contains(options, s) {
+			return fmt.Sprintf("must be one of [%s]", strings.Join(options, " ")), nil
+		}
+		return "", nil
+	case "regexp":
+		re, err := regexp.Compile(arg)
+		if err != nil {
+			return "", err
+		}
+		s, err := stringOf(value)
+		if err != nil {
+			return "", err
+		}
+		if !re.MatchString(s) {
+			return fmt.Sprintf("must match %s", arg), nil
+		}
+		return "", nil
+	case "url":
+		s, err := stringOf(value)
+		if err != nil {
+			return "", err
+		}
+		u, parseErr := url.Parse(s)
+		if parseErr != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URL", nil
+		}
+		return "", nil
+	case "hostport":
+		s, err := stringOf(value)
+		if err != nil {
+			return "", err
+		}
+		_, port, splitErr := net.SplitHostPort(s)
+		if splitErr != nil {
+			return "must be a host:port address", nil
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return "must be a host:port address", nil
+		}
+		return "", nil
+	case "cidr":
+		s, err := stringOf(value)
+		if err != nil {
+			return "", err
+		}
+		if _, err := netip.ParsePrefix(s); err != nil {
+			return "must be a CIDR prefix", nil
+		}
+		return "", nil
+	default:
+		return "", nil
+	}
+}
+
+// checkBound evaluates a min or max rule: a value bound for numbers and
+// durations, a length bound for strings, slices and maps.
+func checkBound(value reflect.Value, name, arg string) (string, error) {
+	atMost := name == "max"
+	describe := func(what string) string {
+		if atMost {
+			return fmt.Sprintf("must be at most %s", what)
+		}
+		return fmt.Sprintf("must be at least %s", what)
+	}
+
+	if value.Type() == durationType {
+		bound, err := time.ParseDuration(arg)
+		if err != nil {
+			return "", err
+		}
+		d := time.Duration(value.Int())
+		if (atMost && d > bound) || (!atMost && d < bound) {
+			return describe(bound.String()), nil
+		}
+		return "", nil
+	}
+
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bound, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if (atMost && value.Int() > bound) || (!atMost && value.Int() < bound) {
+			return describe(arg), nil
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		bound, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		if (atMost && value.Uint() > bound) || (!atMost && value.Uint() < bound) {
+			return describe(arg), nil
+		}
+	case reflect.Float32, reflect.Float64:
+		bound, err := strconv.ParseFloat(arg, 64)
+		if err != nil {
+			return "", err
+		}
+		if (atMost && value.Float() > bound) || (!atMost && value.Float() < bound) {
+			return describe(arg), nil
+		}
+	default:
+		bound, err := strconv.Atoi(arg)
+		if err != nil {
+			return "", err
+		}
+		length, ok := lengthOf(value)
+		if !ok {
+			return "", fmt.Errorf("does not apply to %s", value.Type())
+		}
+		if (atMost && length > bound) || (!atMost && length < bound) {
+			return describe("length " + arg), nil
+		}
+	}
+	return "", nil
+}
+
+func lengthOf(value reflect.Value) (int, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return len([]rune(value.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return value.Len(), true
+	default:
+		return 0, false
+	}
+}
+
+func stringOf(value reflect.Value) (string, error) {
+	if value.Kind() != reflect.String {
+		return "", fmt.Errorf("does not apply to %s", value.Type())
+	}
+	return value.String(), nil
+}
+
+// scalarString formats strings, booleans and numbers the way they are written
+// in a oneof rule.
+func scalarString(value reflect.Value) (string, bool) {
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), true
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if value.Type() == durationType {
+			return time.Duration(value.Int()).String(), true
+		}
+		return strconv.FormatInt(value.Int(), 10), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(value.Uint(), 10), true
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, 64), true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,183 @@
+package validate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/validate"
+)
+
+type ruleServer struct {
+	Addr string `validate:"hostport"`
+	Port int    `validate:"min=1,max=65535"`
+}
+
+type ruleConfig struct {
+	Name     string        `validate:"nonzero,max=8"`
+	Mode     string        `validate:"oneof=dev prod"`
+	Level    int           `validate:"oneof=1 2 3"`
+	Endpoint string        `validate:"url"`
+	Subnet   string        `validate:"cidr"`
+	Code     string        `validate:"regexp=^[a-z]{2,3}$"`
+	Timeout  time.Duration `validate:"min=1s,max=1m"`
+	Tags     []string      `validate:"len=2"`
+	Optional string        `validate:"omitempty,url"`
+	Email    string        `validate:"required,email"`
+	Servers  []ruleServer
+}
+
+func validRuleConfig() ruleConfig {
+	return ruleConfig{
+		Name:     "api",
+		Mode:     "prod",
+		Level:    2,
+		Endpoint: "https://example.com/path",
+		Subnet:   "10.0.0.0/8",
+		Code:     "eu",
+		Timeout:  30 * time.Second,
+		Tags:     []string{"a", "b"},
+		Servers:  []ruleServer{{Addr: ":8080", Port: 8080}},
+	}
+}
+
+func TestRulesAcceptValidConfig(t *testing.T) {
+	t.Parallel()
+
+	value := validRuleConfig()
+	if err := validate.Rules(&value); err != nil {
+		t.Fatalf("Rules() error = %v", err)
+	}
+}
+
+func TestRulesReportEveryFailingField(t *testing.T) {
+	t.Parallel()
+
+	value := ruleConfig{
+		Name:     "far-too-long",
+		Mode:     "staging",
+		Level:    4,
+		Endpoint: "example.com",
+		Subnet:   "10.0.0.0",
+		Code:     "e,u",
+		Timeout:  time.Hour,
+		Tags:     []string{"a"},
+		Optional: "not a url",
+		Servers:  []ruleServer{{Addr: "host", Port: 0}},
+	}
+
+	err := validate.Rules(&value)
+	var rulesErr *validate.RulesError
+	if !errors.As(err, &rulesErr) {
+		t.Fatalf("Rules() error = %v, want *validate.RulesError", err)
+	}
+
+	got := make(map[string]string, len(rulesErr.Fields))
+	for _, f := range rulesErr.Fields {
+		got[f.Field] = f.Rule
+	}
+	want := map[string]string{
+		"Name":           "max=8",
+		"Mode":           "oneof=dev prod",
+		"Level":          "oneof=1 2 3",
+		"Endpoint":       "url",
+		"Subnet":         "cidr",
+		"Code":           "regexp=^[a-z]{2,3}$",
+		"Timeout":        "max=1m",
+		"Tags":           "len=2",
+		"Optional":       "url",
+		"Servers.0.Addr": "hostport",
+		"Servers.0.Port": "min=1",
+	}
+	testutil.Equal(t, want, got)
+
+	// Messages describe the rule, never the rejected value.
+	if strings.Contains(err.Error(), "far-too-long") {
+		t.Fatalf("error %q contains the field value", err)
+	}
+}
+
+func TestRulesReportInvalidRules(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Enabled bool   `validate:"min=1"`
+		Workers int    `validate:"max=many"`
+		Code    string `validate:"regexp=^[a-z"`
+		Port    int    `validate:"max=10"`
+	}
+
+	err := validate.Rules(&config{Port: 80})
+	var rulesErr *validate.RulesError
+	if !errors.As(err, &rulesErr) {
+		t.Fatalf("Rules() error = %v, want *validate.RulesError", err)
+	}
+
+	got := make(map[string]string, len(rulesErr.Fields))
+	for _, f := range rulesErr.Fields {
+		got[f.Field] = f.Reason
+		if invalid := f.Err != nil; invalid != (f.Field != "Port") {
+			t.Errorf("field %s: Err = %v", f.Field, f.Err)
+		}
+	}
+	testutil.Equal(t, map[string]string{
+		"Enabled": `invalid rule "min=1": does not apply to bool`,
+		"Workers": `invalid rule "max=many": strconv.ParseInt: parsing "many": invalid syntax`,
+		"Code":    "invalid rule \"regexp=^[a-z\": error parsing regexp: missing closing ]: `[a-z`",
+		"Port":    "must be at most 10",
+	}, got)
+}
+
+func TestRulesIgnoreUnknownRules(t *testing.T) {
+	t.Parallel()
+
+	// Rules of go-playground/validator sharing the tag are left to it.
+	type config struct {
+		Email string `validate:"required,email"`
+		Age   int    `validate:"gte=0,lte=130"`
+	}
+	if err := validate.Rules(&config{Age: 200}); err != nil {
+		t.Fatalf("Rules() error = %v, want nil", err)
+	}
+}
+
+func TestValidatePluginEvaluatesRules(t *testing.T) {
+	t.Parallel()
+
+	value := validRuleConfig()
+	value.Servers[0].Port = 70000
+
+	_, err := xconfig.Load(&value, xconfig.WithSkipEnv(), xconfig.WithSkipFlags(), xconfig.WithPlugins(validate.New()))
+	var rulesErr *validate.RulesError
+	if !errors.As(err, &rulesErr) {
+		t.Fatalf("Load() error = %v, want *validate.RulesError", err)
+	}
+	testutil.Equal(t, "validation failed: Servers.0.Port: must be at most 65535", err.Error())
+}
+
+func TestRulesCheckPointerValues(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Workers *int           `validate:"min=1"`
+		Timeout *time.Duration `validate:"max=1m"`
+		Token   *string        `validate:"nonzero"`
+	}
+
+	workers, timeout := 0, time.Hour
+	err := validate.Rules(&config{Workers: &workers, Timeout: &timeout})
+	var rulesErr *validate.RulesError
+	if !errors.As(err, &rulesErr) {
+		t.Fatalf("Rules() error = %v, want *validate.RulesError", err)
+	}
+	testutil.Equal(t, "validation failed: Workers: must be at least 1; Timeout: must be at most 1m0s; Token: must be set to a non-zero value", err.Error())
+
+	// Nil pointers are unset and only fail nonzero.
+	token := "t"
+	if err := validate.Rules(&config{Token: &token}); err != nil {
+		t.Fatalf("Rules() error = %v", err)
+	}
+}
@@ -1,8 +1,13 @@
 package validate
 
 import (
+	"fmt"
 	"reflect"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/plugins"
 )
 
@@ -12,15 +17,19 @@ type validate interface {
 	Validate() error
 }
 
+var _ plugins.Validator = (*validator)(nil)
+
 type validator struct {
 	config          any
 	customValidator []CustomValidator
+	viewOptions     []flat.Option
 }
 
 // New returns an validator plugin.
 // It accepts a list of CustomValidator functions.
 //
-// By default, it will validate the struct with the Validate() method.
+// It first evaluates the validate tag rules of every field (see Rules), then
+// validates the struct with the Validate() method.
 // If the struct does not have a Validate() method, it will be skipped.
 //
 // If you want to add custom validation, you can pass a list of CustomValidator functions.
@@ -54,19 +63,33 @@ func (v *validator) Parse() error {
 		return nil
 	}
 
-	if err := validateElem(v.config); err != nil {
+	return v.check(v.config)
+}
+
+// SetFields returns no fields: the plugin only checks them.
+func (v *validator) SetFields() []string {
+	return nil
+}
+
+// Validate runs the same checks as Parse against a refreshed working copy of
+// the configuration, so Config.Refresh does not publish an invalid snapshot.
+func (v *validator) Validate(target any) error {
+	return v.check(target)
+}
+
+// check runs the tag rules, every Validate() method and the custom validators
+// against conf.
+func (v *validator) check(conf any) error {
+	if err := Rules(conf, v.viewOptions...); err != nil {
 		return err
 	}
 
-	val := reflect.ValueOf(v.config).Elem()
-	for i := range val.NumField() {
-		if err := validateElem(val.Field(i).Addr().Interface()); err != nil {
-			return err
-		}
+	if err := Tree(conf); err != nil {
+		return err
 	}
 
 	for _, validator := range v.customValidator {
-		if err := validator(v.config); err != nil {
+		if err := validator(conf); err != nil {
 			return err
 		}
 	}
@@ -79,6 +102,183 @@ func (v *validator) Walk(config any) error {
 	return nil
 }
 
+// SetViewOptions sets the options the tag rules view the configuration with.
+func (v *validator) SetViewOptions(opts []flat.Option) {
+	v.viewOptions = opts
+}
+
+// PathError is a Validate() failure of the value at Path.
+type PathError struct {
+	// Path is the flat name of the value (e.g. "Servers.2.TLS"), empty for
+	// the root configuration.
+	Path string
+	Err  error
+}
+
+// Error implements the error interface.
+func (e *PathError) Error() string {
+	if e.Path == "" {
+		return e.Err.Error()
+	}
+	return e.Path + ": " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by Validate().
+func (e *PathError) Unwrap() error { return e.Err }
+
+// TreeError is returned when Validate() methods fail. It holds one entry per
+// failing value, parents before their children.
+type TreeError struct {
+	Errors []*PathError
+}
+
+// Error implements the error interface.
+func (e *TreeError) Error() string {
+	if len(e.Errors) == 1 {
+		return e.Errors[0].Error()
+	}
+	parts := make([]string, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		parts = append(parts, err.Error())
+	}
+	return "validation failed: " + strings.Join(parts, "; ")
+}
+
+// Unwrap returns the path errors.
+func (e *TreeError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// Tree calls Validate() on conf and on every value below it that implements
+// it: struct fields, pointed-to structs, slice and array elements and map
+// values, including pointer-to-struct map values, following the traversal of
+// flat.View. Failures are returned as a *TreeError naming each value by its
+// flat path. Validate() of an embedded struct is not called separately, since
+// it is promoted to, or overridden by, its parent.
+func Tree(conf any) error {
+	rv := reflect.ValueOf(conf)
+	if !rv.IsValid() {
+		return nil
+	}
+
+	w := &treeWalker{seen: make(map[uintptr]struct{})}
+	w.walk("", rv, true)
+	if len(w.errs) > 0 {
+		return &TreeError{Errors: w.errs}
+	}
+	return nil
+}
+
+type treeWalker struct {
+	errs []*PathError
+	seen map[uintptr]struct{} // structs reached through pointers, against cycles
+}
+
+// walk calls Validate() on v when callSelf is set and descends into it.
+func (w *treeWalker) walk(path string, v reflect.Value, callSelf bool) {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			if _, ok := w.seen[v.Pointer()]; ok {
+				return
+			}
+			w.seen[v.Pointer()] = struct{}{}
+		}
+		if callSelf {
+			w.call(path, v)
+		}
+		w.descend(path, v.Elem())
+		return
+	}
+
+	if !v.CanAddr() {
+		// Map values are not addressable: validate a copy so pointer
+		// receivers are found too.
+		tmp := reflect.New(v.Type())
+		tmp.Elem().Set(v)
+		v = tmp.Elem()
+	}
+	if callSelf {
+		w.call(path, v.Addr())
+	}
+	w.descend(path, v)
+}
+
+func (w *treeWalker) call(path string, ptr reflect.Value) {
+	if !ptr.CanInterface() {
+		return
+	}
+	if err := validateElem(ptr.Interface()); err != nil {
+		w.errs = append(w.errs, &PathError{Path: path, Err: err})
+	}
+}
+
+// descend walks the children of v.
+func (w *treeWalker) descend(path string, v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := range v.NumField() {
+			ft := t.Field(i)
+			if !ft.IsExported() {
+				continue
+			}
+			childPath := path
+			if !ft.Anonymous {
+				childPath = joinPath(path, ft.Name)
+			}
+			w.walk(childPath, v.Field(i), !ft.Anonymous)
+		}
+	case reflect.Slice, reflect.Array:
+		if !containsStructs(v.Type().Elem()) {
+			return
+		}
+		for i := range v.Len() {
+			w.walk(joinPath(path, strconv.Itoa(i)), v.Index(i), true)
+		}
+	case reflect.Map:
+		if !containsStructs(v.Type().Elem()) {
+			return
+		}
+		// Sorted keys keep the reported errors in a stable order.
+		type entry struct {
+			name  string
+			value reflect.Value
+		}
+		entries := make([]entry, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			entries = append(entries, entry{name: fmt.Sprint(iter.Key().Interface()), value: iter.Value()})
+		}
+		slices.SortFunc(entries, func(a, b entry) int { return strings.Compare(a.name, b.name) })
+		for _, e := range entries {
+			w.walk(joinPath(path, e.name), e.value, true)
+		}
+	}
+}
+
+// containsStructs reports whether elements of type t are walked: structs and
+// pointers to structs, like flat.View.
+func containsStructs(t reflect.Type) bool {
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
 func validateElem(elem any) error {
 	// try to validate with Validate() error
 	if tmp, ok := elem.(validate); ok {
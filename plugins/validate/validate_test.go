@@ -1,11 +1,13 @@
 package validate_test
 
 import (
+	"errors"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
 	"github.com/sxwebdev/xconfig/plugins/validate"
 )
 
@@ -48,13 +50,13 @@ func TestValidate(t *testing.T) {
 				Bases:   []string{"list", "blah"},
 				Timeout: 5 * time.Second,
 			},
-			expectedErr: "ignored field is empty",
+			expectedErr: "validation failed: ignored field is empty; Nested: nested struct is empty",
 		},
 		{
 			in: fDefaults{
 				Ignored: "not empty",
 			},
-			expectedErr: "nested struct is empty",
+			expectedErr: "Nested: nested struct is empty",
 		},
 		{
 			in: fDefaults{
@@ -86,3 +88,69 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+type tlsConfig struct {
+	Cert string
+}
+
+func (c *tlsConfig) Validate() error {
+	if c.Cert == "" {
+		return errors.New("cert is empty")
+	}
+	return nil
+}
+
+type treeServer struct {
+	TLS tlsConfig
+}
+
+type treeDB struct {
+	DSN string
+}
+
+func (d treeDB) Validate() error {
+	if d.DSN == "" {
+		return errors.New("dsn is empty")
+	}
+	return nil
+}
+
+type treeConfig struct {
+	Servers  []treeServer
+	DBs      map[string]treeDB
+	Replicas map[string]*treeDB
+	Primary  *treeDB
+}
+
+func TestValidateWalksTree(t *testing.T) {
+	t.Parallel()
+
+	value := treeConfig{
+		Servers: []treeServer{
+			{TLS: tlsConfig{Cert: "a"}},
+			{TLS: tlsConfig{Cert: "b"}},
+			{},
+		},
+		DBs:      map[string]treeDB{"main": {}},
+		Replicas: map[string]*treeDB{"eu": {}, "us": {DSN: "x"}},
+		Primary:  &treeDB{},
+	}
+
+	err := validate.Tree(&value)
+	var treeErr *validate.TreeError
+	if !errors.As(err, &treeErr) {
+		t.Fatalf("Tree() error = %v, want *validate.TreeError", err)
+	}
+
+	got := make([]string, 0, len(treeErr.Errors))
+	for _, pathErr := range treeErr.Errors {
+		got = append(got, pathErr.Error())
+	}
+	want := []string{
+		"Servers.2.TLS: cert is empty",
+		"DBs.main: dsn is empty",
+		"Replicas.eu: dsn is empty",
+		"Primary: dsn is empty",
+	}
+	testutil.Equal(t, want, got)
+}
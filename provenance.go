@@ -0,0 +1,121 @@
+package xconfig
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+// Provenance returns, per flat field name, the chain of sources that set the
+// field in the order they were applied; the last entry produced the current
+// value. Parse records every field a plugin set, and each published refresh
+// appends the sources of its changes. The built-in plugins report the fields
+// they set through plugins.SetReporter, so a source setting a field to the
+// value it already holds is listed; for other plugins only the fields whose
+// value changed are.
+func (c *config) Provenance() map[string][]plugins.Source {
+	if c == nil {
+		return make(map[string][]plugins.Source)
+	}
+
+	c.dataMu.RLock()
+	defer c.dataMu.RUnlock()
+
+	result := make(map[string][]plugins.Source, len(c.provenance))
+	for name, chain := range c.provenance {
+		result[name] = slices.Clone(chain)
+	}
+	return result
+}
+
+// Explain returns the chain of sources that set fieldName, oldest first, or nil
+// when no source has set it.
+func (c *config) Explain(fieldName string) []plugins.Source {
+	if c == nil {
+		return nil
+	}
+
+	c.dataMu.RLock()
+	defer c.dataMu.RUnlock()
+
+	return slices.Clone(c.provenance[fieldName])
+}
+
+// provenanceLog collects the sources recorded during one Parse or Refresh, so
+// that a failed cycle never leaks into the published provenance.
+type provenanceLog map[string][]plugins.Source
+
+func (l provenanceLog) add(fieldName string, source plugins.Source) {
+	chain := l[fieldName]
+	// A refresh loop re-applying the same source would otherwise grow the chain
+	// on every rotation without adding information.
+	if len(chain) > 0 && chain[len(chain)-1] == source {
+		return
+	}
+	l[fieldName] = append(chain, source)
+}
+
+// commitTo appends the collected sources to the published provenance. Callers
+// must hold c.dataMu for writing.
+func (l provenanceLog) commitTo(c *config) {
+	if c.provenance == nil {
+		c.provenance = make(map[string][]plugins.Source, len(l))
+	}
+	for name, sources := range l {
+		for _, source := range sources {
+			chain := c.provenance[name]
+			if len(chain) > 0 && chain[len(chain)-1] == source {
+				continue
+			}
+			c.provenance[name] = append(chain, source)
+		}
+	}
+}
+
+// pluginSource asks p for the origin of fieldName, falling back to the
+// plugin's type for plugins that do not implement plugins.SourceReporter.
+func pluginSource(p plugins.Plugin, fieldName string) plugins.Source {
+	if reporter, ok := p.(plugins.SourceReporter); ok {
+		return reporter.Source(fieldName)
+	}
+	return plugins.Source{Plugin: fmt.Sprintf("%T", p)}
+}
+
+// changedFieldNames compares the flat views of two copies of the same
+// configuration and returns the names of the fields whose value differs. A
+// field that only exists in after, such as a map entry created by a file, is
+// reported when it holds a non-zero value. opts are those of the views of the
+// configuration.
+func changedFieldNames(before, after any, opts []flat.Option) ([]string, error) {
+	beforeFields, err := flat.View(before, opts...)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := flat.View(after, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]reflect.Value, len(beforeFields))
+	for _, f := range beforeFields {
+		previous[f.Name()] = f.FieldValue()
+	}
+
+	var changed []string
+	for _, f := range afterFields {
+		old, ok := previous[f.Name()]
+		if !ok {
+			if !f.IsZero() {
+				changed = append(changed, f.Name())
+			}
+			continue
+		}
+		if !sameValue(old, f.FieldValue(), make(map[comparePair]struct{})) {
+			changed = append(changed, f.Name())
+		}
+	}
+	return changed, nil
+}
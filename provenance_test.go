@@ -0,0 +1,262 @@
+package xconfig_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/customdefaults"
+	"github.com/sxwebdev/xconfig/plugins/defaults"
+	"github.com/sxwebdev/xconfig/plugins/env"
+	"github.com/sxwebdev/xconfig/plugins/filecontent"
+	"github.com/sxwebdev/xconfig/plugins/flag"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/plugins/required"
+	"github.com/sxwebdev/xconfig/plugins/secret"
+	"github.com/sxwebdev/xconfig/plugins/validate"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+type provenanceConfig struct {
+	Host    string `default:"localhost"`
+	Port    int    `default:"8080"`
+	Name    string
+	Debug   bool
+	Workers int
+	Unset   string
+}
+
+func (c *provenanceConfig) SetDefaults() {
+	c.Workers = 4
+}
+
+func TestProvenanceRecordsOverrideChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"Port": 9090, "Name": "from-file"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("NAME", "from-env")
+
+	value := provenanceConfig{}
+	manager, err := xconfig.Custom(&value,
+		defaults.New(),
+		customdefaults.New(),
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+		env.New(""),
+		flag.New("test", flag.ContinueOnError, []string{"-debug"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string][]plugins.Source{
+		"Host":    {{Plugin: "default"}},
+		"Port":    {{Plugin: "default"}, {Plugin: "file", Name: path}},
+		"Name":    {{Plugin: "file", Name: path}, {Plugin: "env", Name: "NAME"}},
+		"Debug":   {{Plugin: "flag", Name: "-debug"}},
+		"Workers": {{Plugin: "SetDefaults"}},
+	}
+	testutil.Equal(t, want, manager.Provenance())
+
+	if chain := manager.Explain("Unset"); chain != nil {
+		t.Fatalf("Explain(Unset) = %+v, want nil", chain)
+	}
+}
+
+func TestProvenanceRecordsUnchangedValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"Host": "localhost"}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PORT", "8080")
+
+	value := provenanceConfig{}
+	manager, err := xconfig.Custom(&value,
+		defaults.New(),
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+		env.New(""),
+		flag.New("test", flag.ContinueOnError, []string{"-debug=false"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Sources setting the value a field already holds are recorded too.
+	testutil.Equal(t, []plugins.Source{{Plugin: "default"}, {Plugin: "file", Name: path}}, manager.Explain("Host"))
+	testutil.Equal(t, []plugins.Source{{Plugin: "default"}, {Plugin: "env", Name: "PORT"}}, manager.Explain("Port"))
+	testutil.Equal(t, []plugins.Source{{Plugin: "flag", Name: "-debug"}}, manager.Explain("Debug"))
+}
+
+func TestProvenanceRecordsWholeValueFields(t *testing.T) {
+	type config struct {
+		Hosts  []string          `default:"a"`
+		Labels map[string]string `default:"team=core"`
+		Ports  []int
+	}
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(`{"Hosts": ["b", "c"], "Labels": {"tier": "1"}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	value := config{}
+	manager, err := xconfig.Custom(&value,
+		defaults.New(),
+		loader.NewPlugin(path, json.Unmarshal, loader.Config{}, nil),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The file sets a list of scalars and a map through the keys within them.
+	file := plugins.Source{Plugin: "file", Name: path}
+	testutil.Equal(t, []plugins.Source{{Plugin: "default"}, file}, manager.Explain("Hosts"))
+	testutil.Equal(t, []plugins.Source{{Plugin: "default"}, file}, manager.Explain("Labels"))
+	testutil.Equal(t, []plugins.Source{file}, manager.Explain("Labels.tier"))
+	if chain := manager.Explain("Ports"); chain != nil {
+		t.Fatalf("Explain(Ports) = %+v, want nil", chain)
+	}
+}
+
+func TestProvenanceAppendsRefreshSources(t *testing.T) {
+	t.Parallel()
+
+	initial := refreshConfig{Version: 1}
+	plugin := &refreshPlugin{
+		refresh: func(_ context.Context, config *refreshConfig) (plugins.RefreshOutcome, error) {
+			config.Version++
+			return plugins.RefreshOutcome{Changes: []plugins.FieldChange{{FieldName: "Version"}}}, nil
+		},
+	}
+
+	manager, err := xconfig.Custom(&initial, plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+	if chain := manager.Explain("Version"); chain != nil {
+		t.Fatalf("Explain(Version) before refresh = %+v, want nil", chain)
+	}
+
+	for range 2 {
+		if result := manager.Refresh(t.Context()); result.Err != nil {
+			t.Fatalf("Refresh() error = %v", result.Err)
+		}
+	}
+
+	want := []plugins.Source{{Plugin: "*xconfig_test.refreshPlugin"}}
+	testutil.Equal(t, want, manager.Explain("Version"))
+}
+
+type reporterConfig struct {
+	Host    string `default:"localhost"`
+	Port    int
+	Workers int
+	Token   string `secret:""`
+	Cert    types.FileContent
+	Email   string `required:"true" validate:"nonzero"`
+}
+
+func (c *reporterConfig) SetDefaults() {
+	c.Workers = 4
+}
+
+func TestBuiltinPluginsReportSetFields(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(configPath, []byte(`{"Port": 9090}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	certPath := filepath.Join(dir, "tls.crt")
+	if err := os.WriteFile(certPath, []byte("cert"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		plugin plugins.Plugin
+		want   map[string][]plugins.Source
+	}{
+		{
+			name:   "defaults",
+			plugin: defaults.New(),
+			want:   map[string][]plugins.Source{"Host": {{Plugin: "default"}}},
+		},
+		{
+			name:   "customdefaults",
+			plugin: customdefaults.New(),
+			want:   map[string][]plugins.Source{"Workers": {{Plugin: "SetDefaults"}}},
+		},
+		{
+			name:   "loader",
+			plugin: loader.NewPlugin(configPath, json.Unmarshal, loader.Config{}, nil),
+			want:   map[string][]plugins.Source{"Port": {{Plugin: "file", Name: configPath}}},
+		},
+		{
+			name:   "env",
+			plugin: env.NewWithSource("", env.Map{"PORT": "80"}),
+			want:   map[string][]plugins.Source{"Port": {{Plugin: "env", Name: "PORT"}}},
+		},
+		{
+			name:   "flag",
+			plugin: flag.New("test", flag.ContinueOnError, []string{"-port=81"}),
+			want:   map[string][]plugins.Source{"Port": {{Plugin: "flag", Name: "-port"}}},
+		},
+		{
+			name:   "secret",
+			plugin: secret.New(func(string) (string, error) { return "s3cret", nil }),
+			want:   map[string][]plugins.Source{"Token": {{Plugin: "secret", Name: "TOKEN"}}},
+		},
+		{
+			name:   "filecontent",
+			plugin: filecontent.New(),
+			want:   map[string][]plugins.Source{"Cert": {{Plugin: "filecontent", Name: certPath}}},
+		},
+		{
+			name:   "required",
+			plugin: required.New(""),
+			want:   map[string][]plugins.Source{},
+		},
+		{
+			name:   "validate",
+			plugin: validate.New(),
+			want:   map[string][]plugins.Source{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, ok := tt.plugin.(plugins.SetReporter); !ok {
+				t.Fatalf("%T does not implement plugins.SetReporter", tt.plugin)
+			}
+			value := reporterConfig{Email: "admin@example.com", Cert: types.FileContent{Path: certPath}}
+			manager, err := xconfig.Custom(&value, tt.plugin)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := manager.Parse(); err != nil {
+				t.Fatal(err)
+			}
+			testutil.Equal(t, tt.want, manager.Provenance())
+		})
+	}
+}
@@ -17,6 +17,7 @@ import (
 
 	"github.com/sxwebdev/xconfig"
 	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/validate"
 )
 
 type refreshConfig struct {
@@ -187,6 +188,52 @@ func TestRefreshDoesNotPublishPartialFailure(t *testing.T) {
 	}
 }
 
+func TestRefreshDoesNotPublishInvalidSnapshot(t *testing.T) {
+	t.Parallel()
+
+	sentinel := errors.New("version 2 is broken")
+	initial := refreshConfig{Version: 1}
+	change := &refreshPlugin{
+		refresh: func(_ context.Context, config *refreshConfig) (plugins.RefreshOutcome, error) {
+			config.Version++
+			return plugins.RefreshOutcome{Changes: []plugins.FieldChange{{FieldName: "Version"}}}, nil
+		},
+	}
+	check := validate.New(func(conf any) error {
+		if conf.(*refreshConfig).Version == 2 {
+			return sentinel
+		}
+		return nil
+	})
+
+	manager, err := xconfig.Custom(&initial, change, check)
+	if err != nil {
+		t.Fatalf("Custom() error = %v", err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	result := manager.Refresh(t.Context())
+	if !errors.Is(result.Err, sentinel) || result.Published {
+		t.Fatalf("first Refresh() = %+v, want %v without publication", result, sentinel)
+	}
+	snapshot, err := xconfig.Snapshot[refreshConfig](manager)
+	if err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	if snapshot.Version != 1 {
+		t.Fatalf("invalid Refresh() published Version = %d, want 1", snapshot.Version)
+	}
+
+	// The rejected working copy is discarded, so the next cycle starts from
+	// the published snapshot again.
+	result = manager.Refresh(t.Context())
+	if !errors.Is(result.Err, sentinel) {
+		t.Fatalf("second Refresh() error = %v, want %v", result.Err, sentinel)
+	}
+}
+
 func TestConcurrentRefreshAndSnapshot(t *testing.T) {
 	t.Parallel()
 
@@ -3,10 +3,14 @@ package xconfig
 import (
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"regexp"
+	"slices"
 	"time"
 	"unsafe"
+
+	"github.com/sxwebdev/xconfig/flat"
 )
 
 const sharedSnapshotTag = "xconfig_shared"
@@ -260,6 +264,39 @@ func sameConfigData(a, b any) bool {
 	return sameValue(reflect.ValueOf(a), reflect.ValueOf(b), make(map[comparePair]struct{}))
 }
 
+// differingFieldNames returns the sorted names of the flat fields of two
+// configurations that sameConfigData tells apart: the fields whose value
+// differs, and those of map entries and slice elements present in only one of
+// them, even when zero. opts are those of the views of the configuration.
+func differingFieldNames(before, after any, opts []flat.Option) ([]string, error) {
+	beforeFields, err := flat.View(before, opts...)
+	if err != nil {
+		return nil, err
+	}
+	afterFields, err := flat.View(after, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := make(map[string]reflect.Value, len(beforeFields))
+	for _, f := range beforeFields {
+		previous[f.Name()] = f.FieldValue()
+	}
+
+	changed := make(map[string]struct{})
+	for _, f := range afterFields {
+		old, ok := previous[f.Name()]
+		delete(previous, f.Name())
+		if !ok || !sameValue(old, f.FieldValue(), make(map[comparePair]struct{})) {
+			changed[f.Name()] = struct{}{}
+		}
+	}
+	for name := range previous {
+		changed[name] = struct{}{}
+	}
+	return slices.Sorted(maps.Keys(changed)), nil
+}
+
 func sameValue(a, b reflect.Value, seen map[comparePair]struct{}) bool { //nolint:gocyclo
 	if !a.IsValid() || !b.IsValid() {
 		return a.IsValid() == b.IsValid()
@@ -22,7 +22,7 @@ func TestParsePublishesSnapshotWithoutRefreshablePlugins(t *testing.T) {
 	t.Parallel()
 
 	config := struct{ Value int }{Value: 1}
-	manager, err := newConfig(&config)
+	manager, err := newConfig(&config, nil)
 	if err != nil {
 		t.Fatalf("newConfig() error = %v", err)
 	}
@@ -46,7 +46,7 @@ func TestRefreshCloneFailureDiscardsStaging(t *testing.T) {
 	t.Parallel()
 
 	config := struct{ Value int }{Value: 1}
-	manager, err := newConfig(&config, &internalRefreshPlugin{})
+	manager, err := newConfig(&config, nil, &internalRefreshPlugin{})
 	if err != nil {
 		t.Fatalf("newConfig() error = %v", err)
 	}
@@ -67,7 +67,7 @@ func TestStopRefreshReleasesStagingWithoutRunningLoop(t *testing.T) {
 	t.Parallel()
 
 	config := struct{ Value int }{Value: 1}
-	manager, err := newConfig(&config, &internalRefreshPlugin{})
+	manager, err := newConfig(&config, nil, &internalRefreshPlugin{})
 	if err != nil {
 		t.Fatalf("newConfig() error = %v", err)
 	}
@@ -81,11 +81,13 @@ func WithEnvPrefix(prefix string) PluginOption {
 // in the Vault secret like ITEMS_0_HOST, SERVERS_PRIMARY_PASSWORD grow the
 // containers and create entries the same way the env plugin does.
 type VaultPlugin struct {
-	client     *Client
-	secretPath string
-	ctx        context.Context // used by Parse(); set at construction via Plugin(ctx)
-	conf       any
-	envPrefix  string // global env prefix (matches xconfig.WithEnvPrefix); used when expanding slice/map containers
+	client      *Client
+	secretPath  string
+	ctx         context.Context // used by Parse(); set at construction via Plugin(ctx)
+	conf        any
+	envPrefix   string            // global env prefix (matches xconfig.WithEnvPrefix); used when expanding slice/map containers
+	keys        map[string]string // flat field name -> Vault key, for Source
+	viewOptions []flat.Option     // options of the views of conf, see plugins.ViewReceiver
 
 	mu sync.Mutex
 }
@@ -118,6 +120,14 @@ func (p *VaultPlugin) Walk(conf any) error {
 	return nil
 }
 
+// SetViewOptions sets the options the plugin views the configuration with.
+func (p *VaultPlugin) SetViewOptions(opts []flat.Option) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.viewOptions = opts
+}
+
 // Visit collects fields tagged with vault:"true" from the initial flat view
 // and, when not configured explicitly, auto-detects the env prefix from the
 // env plugin's already-stamped metadata. Parse and Refresh rebuild their field
@@ -203,20 +213,30 @@ func (p *VaultPlugin) Refresh(ctx context.Context, target any) (plugins.RefreshO
 	return p.applyRefreshSecrets(target, secrets)
 }
 
+// Source reports the Vault key a field was read from. It never includes the
+// secret value.
+func (p *VaultPlugin) Source(fieldName string) plugins.Source {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return plugins.Source{Plugin: vaultTag, Name: p.keys[fieldName]}
+}
+
 func (p *VaultPlugin) applyRefreshSecrets(target any, secrets map[string]string) (plugins.RefreshOutcome, error) {
 	// Re-expand containers in case new map keys / slice indices appeared in
 	// Vault since the last refresh.
 	keys := mapKeys(secrets)
-	nameMap, err := flat.ExpandContainersFromKeys(target, p.envPrefix, keys)
+	nameMap, err := flat.ExpandContainersFromKeys(target, p.envPrefix, keys, p.viewOptions...)
 	if err != nil {
 		return plugins.RefreshOutcome{}, err
 	}
 
-	fields, err := flat.View(target)
+	fields, err := flat.View(target, p.viewOptions...)
 	if err != nil {
 		return plugins.RefreshOutcome{}, err
 	}
 	keyMap := collectVaultFields(fields, nameMap)
+	p.recordKeysLocked(keyMap)
 
 	outcome := plugins.RefreshOutcome{}
 	for _, key := range slices.Sorted(maps.Keys(keyMap)) {
@@ -242,16 +262,17 @@ func (p *VaultPlugin) applyRefreshSecrets(target any, secrets map[string]string)
 // then sets values on every vault-tagged leaf field. Callers must hold p.mu.
 func (p *VaultPlugin) applySecretsLocked(secrets map[string]string) error {
 	keys := mapKeys(secrets)
-	nameMap, err := flat.ExpandContainersFromKeys(p.conf, p.envPrefix, keys)
+	nameMap, err := flat.ExpandContainersFromKeys(p.conf, p.envPrefix, keys, p.viewOptions...)
 	if err != nil {
 		return err
 	}
 
-	fields, err := flat.View(p.conf)
+	fields, err := flat.View(p.conf, p.viewOptions...)
 	if err != nil {
 		return err
 	}
 	keyMap := collectVaultFields(fields, nameMap)
+	p.recordKeysLocked(keyMap)
 
 	// Sorted so that a rejected value always aborts on the same key, leaving the
 	// same fields applied, instead of varying with map iteration order.
@@ -320,6 +341,17 @@ func collectVaultFields(fields flat.Fields, nameMap map[string]string) map[strin
 	return out
 }
 
+// recordKeysLocked remembers the Vault key of every vault-tagged field so that
+// Source can name it. Callers must hold p.mu.
+func (p *VaultPlugin) recordKeysLocked(keyMap map[string]flat.Field) {
+	if p.keys == nil {
+		p.keys = make(map[string]string, len(keyMap))
+	}
+	for key, f := range keyMap {
+		p.keys[f.Name()] = key
+	}
+}
+
 func mapKeys(m map[string]string) []string {
 	keys := make([]string, 0, len(m))
 	for k := range m {
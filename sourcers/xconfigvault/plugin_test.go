@@ -188,6 +188,39 @@ func TestApplySecretsAppliesEmptyVaultValue(t *testing.T) {
 	}
 }
 
+// TestSourceReportsVaultKey verifies that provenance names the Vault key a
+// field was read from, including keys of expanded container entries.
+func TestSourceReportsVaultKey(t *testing.T) {
+	t.Parallel()
+
+	type Server struct {
+		Password string `vault:"true"`
+	}
+
+	type Config struct {
+		Token   string `vault:"true"`
+		Servers []Server
+	}
+
+	cfg := &Config{}
+	plugin := &VaultPlugin{conf: cfg, envPrefix: "APP"}
+
+	err := plugin.applySecretsLocked(map[string]string{
+		"APP_TOKEN":              "token",
+		"APP_SERVERS_0_PASSWORD": "password",
+	})
+	if err != nil {
+		t.Fatalf("applySecretsLocked() error = %v", err)
+	}
+
+	if source := plugin.Source("Token"); source.Plugin != "vault" || source.Name != "APP_TOKEN" {
+		t.Errorf("Source(Token) = %+v, want vault APP_TOKEN", source)
+	}
+	if source := plugin.Source("Servers.0.Password"); source.Name != "APP_SERVERS_0_PASSWORD" {
+		t.Errorf("Source(Servers.0.Password) = %+v, want APP_SERVERS_0_PASSWORD", source)
+	}
+}
+
 // TestApplySecretsInvalidValueFailsDeterministically verifies that when several
 // vault-tagged fields reject their value, Parse always aborts on the same key
 // instead of whichever one Go's randomized map iteration happened to reach
@@ -0,0 +1,127 @@
+package xconfig
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+// ChangeEvent describes a published refresh that changed fields under a
+// subscribed prefix. Like RefreshResult, it never exposes values directly:
+// Previous and Current copy the snapshots on request.
+type ChangeEvent struct {
+	// Changes lists the changed fields under the subscribed prefix, sorted
+	// by name.
+	Changes []plugins.FieldChange
+
+	previous any
+	current  any
+	// warn reports a failure of the subscriber as a warning of the refresh.
+	warn func(error)
+}
+
+// Previous copies the configuration published before the refresh into dst,
+// with the same ownership guarantees as Config.Snapshot.
+func (e ChangeEvent) Previous(dst any) error {
+	return copyConfig(dst, e.previous)
+}
+
+// Current copies the configuration published by the refresh into dst, with
+// the same ownership guarantees as Config.Snapshot.
+func (e ChangeEvent) Current(dst any) error {
+	return copyConfig(dst, e.current)
+}
+
+// Subscribe calls fn with owned copies of the previous and the new
+// configuration after every published refresh that changed a field under
+// prefix, as matched by Config.OnChange. T must be the type passed to Custom
+// or Load. When the snapshots cannot be copied into T, fn is not called and
+// the failure is returned in RefreshResult.Warnings.
+//
+//	unsubscribe, err := xconfig.Subscribe(xc, "Database", func(previous, current Config) {
+//	    pool.Reconnect(current.Database)
+//	})
+func Subscribe[T any](c Config, prefix string, fn func(previous, current T)) (unsubscribe func(), err error) {
+	if isNilConfig(c) {
+		return nil, ErrNilConfig
+	}
+	if fn == nil {
+		return nil, errors.New("xconfig: subscribe callback is nil")
+	}
+	// Reject a mismatched T now rather than on the first change.
+	var probe T
+	if err := c.Snapshot(&probe); err != nil && !errors.Is(err, ErrNotParsed) {
+		return nil, err
+	}
+
+	return c.OnChange(prefix, func(event ChangeEvent) {
+		var previous, current T
+		err := event.Previous(&previous)
+		if err == nil {
+			err = event.Current(&current)
+		}
+		if err != nil {
+			if event.warn != nil {
+				event.warn(fmt.Errorf("xconfig: subscriber of %q: %w", prefix, err))
+			}
+			return
+		}
+		fn(previous, current)
+	}), nil
+}
+
+type subscription struct {
+	prefix string
+	fn     func(ChangeEvent)
+}
+
+func (c *config) OnChange(prefix string, fn func(ChangeEvent)) func() {
+	sub := &subscription{prefix: strings.TrimSuffix(prefix, "."), fn: fn}
+
+	c.subscriptionsMu.Lock()
+	c.subscriptions = append(c.subscriptions, sub)
+	c.subscriptionsMu.Unlock()
+
+	return func() {
+		c.subscriptionsMu.Lock()
+		defer c.subscriptionsMu.Unlock()
+		c.subscriptions = slices.DeleteFunc(c.subscriptions, func(s *subscription) bool { return s == sub })
+	}
+}
+
+// notifySubscribers runs the callbacks whose prefix matches one of changes and
+// returns the failures they reported. previous and current are published
+// snapshots, which are never mutated.
+func (c *config) notifySubscribers(previous, current any, changes []plugins.FieldChange) []error {
+	c.subscriptionsMu.Lock()
+	subscriptions := slices.Clone(c.subscriptions)
+	c.subscriptionsMu.Unlock()
+
+	var warnings []error
+	warn := func(err error) { warnings = append(warnings, err) }
+	for _, sub := range subscriptions {
+		if sub.fn == nil {
+			continue
+		}
+		matched := sub.match(changes)
+		if matched == nil && sub.prefix != "" {
+			continue
+		}
+		sub.fn(ChangeEvent{Changes: matched, previous: previous, current: current, warn: warn})
+	}
+	return warnings
+}
+
+// match returns the changes under the subscription prefix.
+func (s *subscription) match(changes []plugins.FieldChange) []plugins.FieldChange {
+	var matched []plugins.FieldChange
+	for _, change := range changes {
+		if s.prefix == "" || change.FieldName == s.prefix || strings.HasPrefix(change.FieldName, s.prefix+".") {
+			matched = append(matched, change)
+		}
+	}
+	return matched
+}
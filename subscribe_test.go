@@ -0,0 +1,231 @@
+package xconfig_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+)
+
+type subscribeDatabase struct {
+	Host string
+	Port int
+}
+
+type subscribeConfig struct {
+	Database subscribeDatabase
+	Cache    map[string]int
+	Debug    bool
+}
+
+// subscribePlugin applies the next pending mutation on every refresh.
+type subscribePlugin struct {
+	pending []func(*subscribeConfig) []string
+}
+
+func (*subscribePlugin) Walk(any) error { return nil }
+
+func (*subscribePlugin) Parse() error { return nil }
+
+func (p *subscribePlugin) Refresh(_ context.Context, target any) (plugins.RefreshOutcome, error) {
+	if len(p.pending) == 0 {
+		return plugins.RefreshOutcome{}, nil
+	}
+	mutate := p.pending[0]
+	p.pending = p.pending[1:]
+
+	outcome := plugins.RefreshOutcome{}
+	for _, name := range mutate(target.(*subscribeConfig)) {
+		outcome.Changes = append(outcome.Changes, plugins.FieldChange{FieldName: name})
+	}
+	return outcome, nil
+}
+
+func TestSubscribeFiresOnlyForPrefix(t *testing.T) {
+	t.Parallel()
+
+	plugin := &subscribePlugin{pending: []func(*subscribeConfig) []string{
+		func(c *subscribeConfig) []string {
+			c.Debug = true
+			return []string{"Debug"}
+		},
+		func(c *subscribeConfig) []string {
+			c.Database.Port = 5433
+			c.Debug = false
+			return []string{"Database.Port", "Debug"}
+		},
+	}}
+	initial := subscribeConfig{Database: subscribeDatabase{Host: "db", Port: 5432}}
+	manager, err := xconfig.Custom(&initial, plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	type call struct{ previous, current subscribeConfig }
+	var calls []call
+	unsubscribe, err := xconfig.Subscribe(manager, "Database.", func(previous, current subscribeConfig) {
+		calls = append(calls, call{previous, current})
+	})
+	if err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+	var events []xconfig.ChangeEvent
+	manager.OnChange("", func(event xconfig.ChangeEvent) {
+		events = append(events, event)
+	})
+
+	if result := manager.Refresh(t.Context()); !result.Published {
+		t.Fatalf("first Refresh() = %+v, want published", result)
+	}
+	if len(calls) != 0 {
+		t.Fatalf("Subscribe() fired for a change outside Database: %+v", calls)
+	}
+
+	if result := manager.Refresh(t.Context()); !result.Published {
+		t.Fatalf("second Refresh() = %+v, want published", result)
+	}
+	want := []call{{
+		previous: subscribeConfig{Database: subscribeDatabase{Host: "db", Port: 5432}, Debug: true},
+		current:  subscribeConfig{Database: subscribeDatabase{Host: "db", Port: 5433}},
+	}}
+	testutil.Equal(t, want, calls)
+
+	testutil.Equal(t, 2, len(events))
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Database.Port"}, {FieldName: "Debug"}}, events[1].Changes)
+
+	unsubscribe()
+	plugin.pending = append(plugin.pending, func(c *subscribeConfig) []string {
+		c.Database.Host = "replica"
+		return []string{"Database.Host"}
+	})
+	manager.Refresh(t.Context())
+	testutil.Equal(t, 1, len(calls))
+}
+
+func TestSubscribeFiresForUnreportedContainerChanges(t *testing.T) {
+	t.Parallel()
+
+	plugin := &subscribePlugin{pending: []func(*subscribeConfig) []string{
+		func(c *subscribeConfig) []string {
+			delete(c.Cache, "b")
+			return nil
+		},
+		func(c *subscribeConfig) []string {
+			c.Cache["c"] = 0
+			return nil
+		},
+	}}
+	initial := subscribeConfig{Cache: map[string]int{"a": 1, "b": 0}}
+	manager, err := xconfig.Custom(&initial, plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	var caches []map[string]int
+	if _, err := xconfig.Subscribe(manager, "Cache", func(_, current subscribeConfig) {
+		caches = append(caches, current.Cache)
+	}); err != nil {
+		t.Fatalf("Subscribe() error = %v", err)
+	}
+
+	result := manager.Refresh(t.Context())
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Cache"}, {FieldName: "Cache.b"}}, result.Changes)
+	result = manager.Refresh(t.Context())
+	testutil.Equal(t, []plugins.FieldChange{{FieldName: "Cache"}, {FieldName: "Cache.c"}}, result.Changes)
+	testutil.Equal(t, []map[string]int{{"a": 1}, {"a": 1, "c": 0}}, caches)
+}
+
+func TestSubscribeSnapshotsAreOwned(t *testing.T) {
+	t.Parallel()
+
+	plugin := &subscribePlugin{pending: []func(*subscribeConfig) []string{
+		func(c *subscribeConfig) []string {
+			c.Cache["size"] = 2
+			return []string{"Cache.size"}
+		},
+	}}
+	initial := subscribeConfig{Cache: map[string]int{"size": 1}}
+	manager, err := xconfig.Custom(&initial, plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := xconfig.Subscribe(manager, "Cache", func(previous, current subscribeConfig) {
+		previous.Cache["size"] = 99
+		current.Cache["size"] = 99
+	}); err != nil {
+		t.Fatal(err)
+	}
+	manager.Refresh(t.Context())
+
+	snapshot, err := xconfig.Snapshot[subscribeConfig](manager)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, 2, snapshot.Cache["size"])
+}
+
+func TestSubscribeRejectsMismatchedType(t *testing.T) {
+	t.Parallel()
+
+	initial := subscribeConfig{}
+	manager, err := xconfig.Custom(&initial, &subscribePlugin{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := xconfig.Subscribe(manager, "", func(_, _ refreshConfig) {}); err == nil {
+		t.Fatal("Subscribe() with a mismatched type succeeded")
+	}
+}
+
+func TestSubscribeReportsCopyFailures(t *testing.T) {
+	t.Parallel()
+
+	plugin := &subscribePlugin{pending: []func(*subscribeConfig) []string{
+		func(c *subscribeConfig) []string {
+			c.Debug = true
+			return []string{"Debug"}
+		},
+	}}
+	initial := subscribeConfig{}
+	manager, err := xconfig.Custom(&initial, plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Before Parse the type of the callback cannot be checked yet.
+	called := false
+	if _, err := xconfig.Subscribe(manager, "", func(_, _ refreshConfig) { called = true }); err != nil {
+		t.Fatal(err)
+	}
+	if err := manager.Parse(); err != nil {
+		t.Fatal(err)
+	}
+
+	result := manager.Refresh(t.Context())
+	if !result.Published || result.Err != nil {
+		t.Fatalf("Refresh() = %+v, want published", result)
+	}
+	if called {
+		t.Fatal("Subscribe() callback ran with a mismatched type")
+	}
+	if len(result.Warnings) != 1 || !strings.Contains(result.Warnings[0].Error(), `subscriber of ""`) {
+		t.Fatalf("Refresh() warnings = %v, want the subscriber failure", result.Warnings)
+	}
+}
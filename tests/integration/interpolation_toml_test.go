@@ -0,0 +1,69 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfigtoml"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+func TestTOMLInterpolation(t *testing.T) {
+	type Database struct {
+		DSN      string `toml:"dsn"`
+		PoolSize int    `toml:"pool_size"`
+		Debug    bool   `toml:"debug"`
+	}
+	type Config struct {
+		AppName  string   `toml:"app_name"`
+		Database Database `toml:"database"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.toml")
+	content := `# References in comments are kept: ${COMMENT_ONLY}
+app_name = "api"
+
+[database]
+dsn = "postgres://${DB_USER}@${DB_HOST:-localhost}/app"
+pool_size = "${POOL_SIZE:-10}"
+debug = "${DEBUG:-false}"
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		"toml": xconfigtoml.New().Unmarshal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+	l.DisallowUnknownFields(true)
+
+	var cfg Config
+	_, err = xconfig.Load(&cfg,
+		xconfig.WithLoader(l),
+		xconfig.WithSkipEnv(),
+		xconfig.WithSkipFlags(),
+		xconfig.WithInterpolation(loader.MapResolver(map[string]string{"DB_USER": "admin", "DEBUG": "true"})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		AppName:  "api",
+		Database: Database{DSN: "postgres://admin@localhost/app", PoolSize: 10, Debug: true},
+	}
+	if cfg != want {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+	if _, ok := l.PresentFields()["database.pool_size"]; !ok {
+		t.Errorf("PresentFields() = %v, want database.pool_size", l.PresentFields())
+	}
+}
@@ -0,0 +1,77 @@
+package integration_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfigyaml"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+func TestYAMLInterpolation(t *testing.T) {
+	type Database struct {
+		DSN      string `yaml:"dsn"`
+		PoolSize int    `yaml:"pool_size"`
+	}
+	type Config struct {
+		Database Database `yaml:"database"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `database:
+  # References in comments are kept: ${COMMENT_ONLY}
+  dsn: postgres://${DB_USER}@${DB_HOST:-localhost}/app
+  pool_size: ${POOL_SIZE:-10}
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	load := func(values map[string]string) (Config, *loader.Loader, error) {
+		l, err := loader.NewLoader(map[string]loader.Unmarshal{
+			"yaml": xconfigyaml.New().Unmarshal,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := l.AddFile(path, false); err != nil {
+			t.Fatal(err)
+		}
+		l.DisallowUnknownFields(true)
+
+		var cfg Config
+		_, err = xconfig.Load(&cfg,
+			xconfig.WithLoader(l),
+			xconfig.WithSkipEnv(),
+			xconfig.WithSkipFlags(),
+			xconfig.WithInterpolation(loader.MapResolver(values)),
+		)
+		return cfg, l, err
+	}
+
+	cfg, l, err := load(map[string]string{"DB_USER": "admin", "POOL_SIZE": "25"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Database.DSN != "postgres://admin@localhost/app" {
+		t.Errorf("DSN = %q, want postgres://admin@localhost/app", cfg.Database.DSN)
+	}
+	if cfg.Database.PoolSize != 25 {
+		t.Errorf("PoolSize = %d, want 25", cfg.Database.PoolSize)
+	}
+	if _, ok := l.PresentFields()["database.pool_size"]; !ok {
+		t.Errorf("PresentFields() = %v, want database.pool_size", l.PresentFields())
+	}
+
+	_, _, err = load(nil)
+	var interpolationErr *loader.InterpolationError
+	if !errors.As(err, &interpolationErr) {
+		t.Fatalf("Load() error = %v, want *loader.InterpolationError", err)
+	}
+	if interpolationErr.Path != "database.dsn" || interpolationErr.Name != "DB_USER" {
+		t.Errorf("InterpolationError = %+v, want DB_USER in database.dsn", interpolationErr)
+	}
+}
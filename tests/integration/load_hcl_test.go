@@ -0,0 +1,154 @@
+package integration_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfighcl"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+type hclListener struct {
+	Address string `hcl:"address"`
+	TLS     bool   `hcl:"tls" default:"true"`
+}
+
+type hclUpstream struct {
+	URL     string        `hcl:"url"`
+	Timeout time.Duration `hcl:"timeout" default:"5s"`
+	Retries int           `hcl:"retries" default:"3"`
+}
+
+type hclDatabase struct {
+	Host string `hcl:"host"`
+	Port int    `hcl:"port" default:"5432"`
+}
+
+type hclConfig struct {
+	Name      string                 `hcl:"name"`
+	Tags      []string               `hcl:"tags"`
+	Database  hclDatabase            `hcl:"database"`
+	Listeners []hclListener          `hcl:"listener"`
+	Upstreams map[string]hclUpstream `hcl:"upstream"`
+}
+
+func loadHCL(t *testing.T, content string, opts ...xconfig.Option) (*hclConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.hcl")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		xconfighcl.New().Format(): xconfighcl.New().Unmarshal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &hclConfig{}
+	opts = append(opts, xconfig.WithLoader(l), xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	_, err = xconfig.Load(cfg, opts...)
+	return cfg, err
+}
+
+func TestLoadHCLBlocks(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadHCL(t, `
+name = "edge"
+tags = ["a", "b"]
+
+database {
+  host = "db"
+}
+
+listener {
+  address = ":80"
+  tls     = false
+}
+
+listener {
+  address = ":443"
+}
+
+upstream "api" {
+  url     = "http://api"
+  retries = 0
+}
+
+upstream "auth" {
+  url = "http://auth"
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Values set explicitly to zero (tls = false, retries = 0) must survive
+	// the defaults rescan, while omitted keys get their defaults.
+	want := &hclConfig{
+		Name:     "edge",
+		Tags:     []string{"a", "b"},
+		Database: hclDatabase{Host: "db", Port: 5432},
+		Listeners: []hclListener{
+			{Address: ":80", TLS: false},
+			{Address: ":443", TLS: true},
+		},
+		Upstreams: map[string]hclUpstream{
+			"api":  {URL: "http://api", Timeout: 5 * time.Second, Retries: 0},
+			"auth": {URL: "http://auth", Timeout: 5 * time.Second, Retries: 3},
+		},
+	}
+	testutil.Equal(t, want, cfg)
+}
+
+func TestLoadHCLSingleBlockIntoSlice(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadHCL(t, `
+listener {
+  address = ":80"
+  tls     = false
+}
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []hclListener{{Address: ":80", TLS: false}}, cfg.Listeners)
+}
+
+func TestLoadHCLDisallowUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadHCL(t, `
+nmae = "typo"
+
+listener {
+  address = ":80"
+  port    = 80
+}
+
+upstream "api" {
+  uri = "http://api"
+}
+`, xconfig.WithDisallowUnknownFields())
+
+	var unknownErr *loader.UnknownFieldsError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Load() error = %v, want *loader.UnknownFieldsError", err)
+	}
+	for _, fields := range unknownErr.Fields {
+		slices.Sort(fields)
+		testutil.Equal(t, []string{"listener[].port", "nmae", "upstream.*.uri"}, fields)
+	}
+}
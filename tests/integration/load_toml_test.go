@@ -0,0 +1,105 @@
+package integration_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfigtoml"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+type tomlServer struct {
+	Name    string `toml:"name"`
+	Enabled bool   `toml:"enabled" default:"true"`
+}
+
+type tomlConfig struct {
+	Title   string            `toml:"title"`
+	Port    int               `toml:"port" default:"8080"`
+	Labels  map[string]string `toml:"labels"`
+	Servers []tomlServer      `toml:"servers"`
+}
+
+func loadTOML(t *testing.T, content string, opts ...xconfig.Option) (*tomlConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		xconfigtoml.New().Format(): xconfigtoml.New().Unmarshal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &tomlConfig{}
+	opts = append(opts, xconfig.WithLoader(l), xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	_, err = xconfig.Load(cfg, opts...)
+	return cfg, err
+}
+
+func TestLoadTOML(t *testing.T) {
+	t.Parallel()
+
+	cfg, err := loadTOML(t, `
+title = "api"
+
+[labels]
+team = "core"
+
+[[servers]]
+name = "one"
+enabled = false
+
+[[servers]]
+name = "two"
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An explicit false in one array table must not be reset by the default,
+	// while the sibling that omits the key still gets it.
+	want := &tomlConfig{
+		Title:  "api",
+		Port:   8080,
+		Labels: map[string]string{"team": "core"},
+		Servers: []tomlServer{
+			{Name: "one", Enabled: false},
+			{Name: "two", Enabled: true},
+		},
+	}
+	testutil.Equal(t, want, cfg)
+}
+
+func TestLoadTOMLDisallowUnknownFields(t *testing.T) {
+	t.Parallel()
+
+	_, err := loadTOML(t, `
+title = "api"
+unknown = 1
+
+[[servers]]
+name = "one"
+typo = true
+`, xconfig.WithDisallowUnknownFields())
+
+	var unknownErr *loader.UnknownFieldsError
+	if !errors.As(err, &unknownErr) {
+		t.Fatalf("Load() error = %v, want *loader.UnknownFieldsError", err)
+	}
+	for _, fields := range unknownErr.Fields {
+		slices.Sort(fields)
+		testutil.Equal(t, []string{"servers[].typo", "unknown"}, fields)
+	}
+}
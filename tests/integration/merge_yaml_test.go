@@ -0,0 +1,91 @@
+package integration_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfigyaml"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+)
+
+func TestYAMLMergeStrategies(t *testing.T) {
+	type Upstream struct {
+		Name string `yaml:"name"`
+		Host string `yaml:"host"`
+		Port int    `yaml:"port"`
+	}
+	type Config struct {
+		Upstreams []Upstream        `yaml:"upstreams" merge:"merge-by-key:Name"`
+		Allowed   []string          `yaml:"allowed"`
+		Limits    map[string]int    `yaml:"limits"`
+		Labels    map[string]string `yaml:"labels"`
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"base.yaml": `upstreams:
+  - name: api
+    host: api.internal
+    port: 8080
+allowed: [10.0.0.0/8]
+limits:
+  rps: 100
+  burst: 20
+`,
+		"team.yaml": `upstreams:
+  - name: billing
+    host: billing.internal
+allowed: [192.168.0.0/16]
+labels:
+  team: payments
+`,
+		"host.yaml": `upstreams:
+  - name: api
+    port: 9090
+limits:
+  rps: 500
+`,
+	}
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		"yaml": xconfigyaml.New().Unmarshal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"base.yaml", "team.yaml", "host.yaml"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(files[name]), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.AddFile(path, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var cfg Config
+	_, err = xconfig.Load(&cfg,
+		xconfig.WithLoader(l),
+		xconfig.WithSkipEnv(),
+		xconfig.WithSkipFlags(),
+		xconfig.WithMergeStrategy(loader.MergeAppend),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := Config{
+		Upstreams: []Upstream{
+			{Name: "api", Host: "api.internal", Port: 9090},
+			{Name: "billing", Host: "billing.internal"},
+		},
+		Allowed: []string{"10.0.0.0/8", "192.168.0.0/16"},
+		Limits:  map[string]int{"rps": 500, "burst": 20},
+		Labels:  map[string]string{"team": "payments"},
+	}
+	if !reflect.DeepEqual(cfg, want) {
+		t.Errorf("Load() = %+v, want %+v", cfg, want)
+	}
+}
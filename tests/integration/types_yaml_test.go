@@ -0,0 +1,65 @@
+package integration_test
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/decoders/xconfigyaml"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+func TestYAMLTypes(t *testing.T) {
+	type Config struct {
+		MaxBody types.ByteSize `yaml:"max_body"`
+		Listen  types.HostPort `yaml:"listen"`
+		Level   types.LogLevel `yaml:"level"`
+		Mode    types.FileMode `yaml:"mode"`
+		Trusted types.CIDRList `yaml:"trusted"`
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `max_body: 256MiB
+listen: "localhost:8443"
+level: warn
+mode: "0600"
+trusted: 10.0.0.0/8,fd00::/8
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := loader.NewLoader(map[string]loader.Unmarshal{
+		"yaml": xconfigyaml.New().Unmarshal,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := l.AddFile(path, false); err != nil {
+		t.Fatal(err)
+	}
+
+	var cfg Config
+	if _, err := xconfig.Load(&cfg, xconfig.WithLoader(l), xconfig.WithSkipEnv(), xconfig.WithSkipFlags()); err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.MaxBody != 256*types.MiB {
+		t.Errorf("MaxBody = %s, want 256MiB", cfg.MaxBody)
+	}
+	if cfg.Listen != (types.HostPort{Host: "localhost", Port: 8443}) {
+		t.Errorf("Listen = %s, want localhost:8443", cfg.Listen)
+	}
+	if cfg.Level.Level() != slog.LevelWarn {
+		t.Errorf("Level = %s, want WARN", cfg.Level)
+	}
+	if cfg.Mode != 0o600 {
+		t.Errorf("Mode = %s, want 0600", cfg.Mode)
+	}
+	if got := cfg.Trusted.String(); got != "10.0.0.0/8,fd00::/8" {
+		t.Errorf("Trusted = %s, want 10.0.0.0/8,fd00::/8", got)
+	}
+}
@@ -0,0 +1,216 @@
+// Package tlsconfig builds *tls.Config values following the certificates of
+// a types.TLS configuration section, so certificates rotated on disk or in a
+// secret store take effect on refresh without restarting listeners or
+// reconnecting clients.
+//
+//	reloader, err := tlsconfig.New(xc, func(cfg *Config) types.TLS {
+//	    return cfg.Server
+//	})
+//	if err != nil { ... }
+//	defer reloader.Close()
+//
+//	ln, err := tls.Listen("tcp", ":8443", reloader.ServerConfig())
+//
+// Rotated files are picked up by Config.Refresh and Config.StartRefresh, which
+// re-read types.FileContent fields; register the validate plugin to keep a
+// half-rotated certificate and key from being published.
+package tlsconfig
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+// Reloader keeps the certificate and CA pool of a types.TLS section in step
+// with the snapshots the configuration publishes. The *tls.Config values it
+// returns read them on every handshake.
+type Reloader struct {
+	state       atomic.Pointer[state]
+	unsubscribe func()
+
+	errMu sync.Mutex
+	err   error
+}
+
+// state holds what a published section parsed into.
+type state struct {
+	section types.TLS
+	cert    *tls.Certificate
+	pool    *x509.CertPool
+}
+
+func newState(section types.TLS) (*state, error) {
+	cert, err := section.Certificate()
+	if err != nil {
+		return nil, err
+	}
+	pool, err := section.CertPool()
+	if err != nil {
+		return nil, err
+	}
+	return &state{section: section, cert: cert, pool: pool}, nil
+}
+
+// New returns a Reloader following the section of the configuration managed
+// by c that section selects, starting with the latest published snapshot. T
+// must be the type passed to Load or Custom. New fails when that section
+// does not parse; later sections that do not parse are skipped and reported
+// by Err, keeping the previous certificate.
+func New[T any](c xconfig.Config, section func(*T) types.TLS) (*Reloader, error) {
+	if section == nil {
+		return nil, errors.New("tlsconfig: section func is nil")
+	}
+	current, err := xconfig.Snapshot[T](c)
+	if err != nil {
+		return nil, err
+	}
+	initial, err := newState(section(&current))
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Reloader{}
+	r.state.Store(initial)
+	r.unsubscribe, err = xconfig.Subscribe(c, "", func(_, current T) {
+		r.update(section(&current))
+	})
+	if err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *Reloader) update(section types.TLS) {
+	if reflect.DeepEqual(section, r.state.Load().section) {
+		return
+	}
+	next, err := newState(section)
+	r.errMu.Lock()
+	r.err = err
+	r.errMu.Unlock()
+	if err != nil {
+		return
+	}
+	r.state.Store(next)
+}
+
+// Err returns the error of the latest published section that did not parse,
+// or nil once a section parsed again.
+func (r *Reloader) Err() error {
+	r.errMu.Lock()
+	defer r.errMu.Unlock()
+	return r.err
+}
+
+// Close stops following the published snapshots. The *tls.Config values keep
+// using the last certificate.
+func (r *Reloader) Close() {
+	r.unsubscribe()
+}
+
+// Certificate returns the current certificate, or nil when none is
+// configured.
+func (r *Reloader) Certificate() *tls.Certificate {
+	return r.state.Load().cert
+}
+
+// ServerConfig returns a server *tls.Config presenting the current
+// certificate. With the verify-if-given and require-and-verify client auth
+// policies, client certificates are verified against the current CA pool.
+// The protocol settings are those of the section when ServerConfig is called.
+func (r *Reloader) ServerConfig() *tls.Config {
+	section := r.state.Load().section
+	cfg := &tls.Config{
+		MinVersion:   uint16(section.MinVersion),
+		CipherSuites: section.CipherSuites,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			if cert := r.Certificate(); cert != nil {
+				return cert, nil
+			}
+			return nil, errors.New("tlsconfig: no certificate configured")
+		},
+	}
+
+	// crypto/tls verifies client certificates against a fixed pool, so the
+	// policies verifying them are applied by VerifyPeerCertificate instead.
+	verifyClient := r.verifyPeer("", x509.ExtKeyUsageClientAuth)
+	switch auth := section.ClientAuth.AuthType(); auth {
+	case tls.VerifyClientCertIfGiven:
+		cfg.ClientAuth = tls.RequestClientCert
+		cfg.VerifyPeerCertificate = func(rawCerts [][]byte, chains [][]*x509.Certificate) error {
+			if len(rawCerts) == 0 {
+				return nil
+			}
+			return verifyClient(rawCerts, chains)
+		}
+	case tls.RequireAndVerifyClientCert:
+		cfg.ClientAuth = tls.RequireAnyClientCert
+		cfg.VerifyPeerCertificate = verifyClient
+	default:
+		cfg.ClientAuth = auth
+	}
+	return cfg
+}
+
+// ClientConfig returns a client *tls.Config connecting to serverName and
+// presenting the current certificate when the server asks for one. When the
+// section configures a CA bundle, servers are verified against the current
+// pool instead of the system roots.
+func (r *Reloader) ClientConfig(serverName string) *tls.Config {
+	st := r.state.Load()
+	cfg := &tls.Config{
+		ServerName:   serverName,
+		MinVersion:   uint16(st.section.MinVersion),
+		CipherSuites: st.section.CipherSuites,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if cert := r.Certificate(); cert != nil {
+				return cert, nil
+			}
+			return &tls.Certificate{}, nil
+		},
+	}
+	if st.pool != nil {
+		// The pool changes with the snapshots, so crypto/tls must not verify
+		// against a fixed RootCAs.
+		cfg.InsecureSkipVerify = true
+		cfg.VerifyPeerCertificate = r.verifyPeer(serverName, x509.ExtKeyUsageServerAuth)
+	}
+	return cfg
+}
+
+// verifyPeer returns a VerifyPeerCertificate func verifying the peer chain
+// against the current CA pool, or the system roots when none is configured.
+func (r *Reloader) verifyPeer(dnsName string, usage x509.ExtKeyUsage) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+		if len(rawCerts) == 0 {
+			return errors.New("tlsconfig: peer presented no certificate")
+		}
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			cert, err := x509.ParseCertificate(raw)
+			if err != nil {
+				return err
+			}
+			certs[i] = cert
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         r.state.Load().pool,
+			DNSName:       dnsName,
+			Intermediates: x509.NewCertPool(),
+			KeyUsages:     []x509.ExtKeyUsage{usage},
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err := certs[0].Verify(opts)
+		return err
+	}
+}
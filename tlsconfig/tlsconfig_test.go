@@ -0,0 +1,293 @@
+package tlsconfig_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/validate"
+	"github.com/sxwebdev/xconfig/tlsconfig"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+type authority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newAuthority(t *testing.T) *authority {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &authority{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns the PEM certificate and key of a leaf with the given serial.
+func (a *authority) issue(t *testing.T, serial int64, usage x509.ExtKeyUsage) (certPEM, keyPEM []byte) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, a.cert, &key.PublicKey, a.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func writeFile(t *testing.T, path string, content []byte) {
+	t.Helper()
+	if err := os.WriteFile(path, content, 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type config struct {
+	Server types.TLS
+	Client types.TLS
+}
+
+// serve accepts connections on ln until it is closed, writing one byte to
+// each client that completes the handshake.
+func serve(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			_ = conn.SetDeadline(time.Now().Add(5 * time.Second))
+			if err := conn.(*tls.Conn).Handshake(); err != nil {
+				return
+			}
+			_, _ = conn.Write([]byte{1})
+		}()
+	}
+}
+
+// dial connects to addr and returns the serial of the server certificate.
+func dial(t *testing.T, addr string, cfg *tls.Config) (int64, error) {
+	t.Helper()
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, cfg)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	// With TLS 1.3 the server rejects a client certificate after the client
+	// completed its handshake, so the rejection surfaces on the first read.
+	if _, err := conn.Read(make([]byte, 1)); err != nil {
+		return 0, err
+	}
+	return conn.ConnectionState().PeerCertificates[0].SerialNumber.Int64(), nil
+}
+
+func TestReloaderRotatesCertificates(t *testing.T) {
+	t.Parallel()
+
+	ca := newAuthority(t)
+	dir := t.TempDir()
+	path := func(name string) string { return filepath.Join(dir, name) }
+
+	serverCert, serverKey := ca.issue(t, 10, x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, 20, x509.ExtKeyUsageClientAuth)
+	writeFile(t, path("ca.crt"), ca.pem)
+	writeFile(t, path("server.crt"), serverCert)
+	writeFile(t, path("server.key"), serverKey)
+	writeFile(t, path("client.crt"), clientCert)
+	writeFile(t, path("client.key"), clientKey)
+
+	cfg := config{
+		Server: types.TLS{
+			CertFile:   types.FileContent{Path: path("server.crt")},
+			KeyFile:    types.FileContent{Path: path("server.key")},
+			CAFile:     types.FileContent{Path: path("ca.crt")},
+			ClientAuth: types.TLSClientAuth(tls.RequireAndVerifyClientCert),
+		},
+		Client: types.TLS{
+			CertFile: types.FileContent{Path: path("client.crt")},
+			KeyFile:  types.FileContent{Path: path("client.key")},
+			CAFile:   types.FileContent{Path: path("ca.crt")},
+		},
+	}
+	manager, err := xconfig.Load(&cfg,
+		xconfig.WithSkipEnv(),
+		xconfig.WithSkipFlags(),
+		xconfig.WithPlugins(validate.New()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, types.TLSVersion(tls.VersionTLS12), cfg.Server.MinVersion)
+
+	server, err := tlsconfig.New(manager, func(c *config) types.TLS { return c.Server })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer server.Close()
+	client, err := tlsconfig.New(manager, func(c *config) types.TLS { return c.Client })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	ln, err := tls.Listen("tcp", "127.0.0.1:0", server.ServerConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go serve(ln)
+	addr := ln.Addr().String()
+
+	serial, err := dial(t, addr, client.ClientConfig("localhost"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	testutil.Equal(t, int64(10), serial)
+
+	// Clients without a certificate signed by the CA are rejected.
+	anonymous := &tls.Config{ServerName: "localhost", RootCAs: x509.NewCertPool()}
+	anonymous.RootCAs.AddCert(ca.cert)
+	if _, err := dial(t, addr, anonymous); err == nil {
+		t.Fatal("dial without a client certificate succeeded")
+	}
+
+	// A certificate written before its key is not published.
+	rotatedCert, rotatedKey := ca.issue(t, 11, x509.ExtKeyUsageServerAuth)
+	writeFile(t, path("server.crt"), rotatedCert)
+	if result := manager.Refresh(t.Context()); result.Err == nil || result.Published {
+		t.Fatalf("Refresh() of a half-rotated pair = %+v, want a validation error", result)
+	}
+	serial, err = dial(t, addr, client.ClientConfig("localhost"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	testutil.Equal(t, int64(10), serial)
+
+	writeFile(t, path("server.key"), rotatedKey)
+	result := manager.Refresh(t.Context())
+	if result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	testutil.Equal(t, []plugins.FieldChange{
+		{FieldName: "Server.CertFile"},
+		{FieldName: "Server.KeyFile"},
+	}, result.Changes)
+
+	// The listener serves the rotated certificate without being restarted.
+	serial, err = dial(t, addr, client.ClientConfig("localhost"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	testutil.Equal(t, int64(11), serial)
+	testutil.Equal(t, nil, server.Err())
+}
+
+// breakKey is a refreshable plugin replacing the inline key with garbage.
+type breakKey struct{}
+
+func (*breakKey) Visit(flat.Fields) error { return nil }
+
+func (*breakKey) Parse() error { return nil }
+
+func (*breakKey) Refresh(_ context.Context, target any) (plugins.RefreshOutcome, error) {
+	fields, err := flat.View(target)
+	if err != nil {
+		return plugins.RefreshOutcome{}, err
+	}
+	for _, f := range fields {
+		if f.Name() == "TLS.Key" {
+			if err := f.Set("not a key"); err != nil {
+				return plugins.RefreshOutcome{}, err
+			}
+		}
+	}
+	return plugins.RefreshOutcome{Changes: []plugins.FieldChange{{FieldName: "TLS.Key"}}}, nil
+}
+
+func TestReloaderKeepsCertificateOfBrokenSection(t *testing.T) {
+	t.Parallel()
+
+	ca := newAuthority(t)
+	certPEM, keyPEM := ca.issue(t, 30, x509.ExtKeyUsageServerAuth)
+
+	type inline struct {
+		TLS types.TLS
+	}
+	cfg := inline{TLS: types.TLS{Cert: string(certPEM), Key: string(keyPEM)}}
+	manager, err := xconfig.Load(&cfg, xconfig.WithSkipEnv(), xconfig.WithSkipFlags(),
+		xconfig.WithPlugins(&breakKey{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloader, err := tlsconfig.New(manager, func(c *inline) types.TLS { return c.TLS })
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reloader.Close()
+
+	// Without the validate plugin a broken key is published, and the reloader
+	// keeps the previous certificate.
+	if result := manager.Refresh(t.Context()); result.Err != nil || !result.Published {
+		t.Fatalf("Refresh() = %+v, want a published change", result)
+	}
+	if reloader.Err() == nil {
+		t.Fatal("Err() = nil after a broken key was published")
+	}
+	leaf, err := x509.ParseCertificate(reloader.Certificate().Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, int64(30), leaf.SerialNumber.Int64())
+}
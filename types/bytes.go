@@ -0,0 +1,84 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Base64Bytes are bytes written in standard base64. Unpadded and URL-safe
+// encodings are accepted as well.
+type Base64Bytes []byte
+
+var base64Encodings = []*base64.Encoding{
+	base64.StdEncoding,
+	base64.RawStdEncoding,
+	base64.URLEncoding,
+	base64.RawURLEncoding,
+}
+
+// UnmarshalText decodes the base64 text.
+func (b *Base64Bytes) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if str == "" {
+		*b = nil
+		return nil
+	}
+	var err error
+	for _, enc := range base64Encodings {
+		var decoded []byte
+		if decoded, err = enc.DecodeString(str); err == nil {
+			*b = decoded
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid base64: %w", err)
+}
+
+// MarshalText encodes the bytes in standard base64.
+func (b Base64Bytes) MarshalText() ([]byte, error) {
+	return []byte(b.String()), nil
+}
+
+func (b Base64Bytes) String() string {
+	return base64.StdEncoding.EncodeToString(b)
+}
+
+// FormatHint describes the accepted format.
+func (Base64Bytes) FormatHint() string {
+	return "base64-encoded bytes"
+}
+
+// Hex are bytes written in hexadecimal, with an optional "0x" prefix.
+type Hex []byte
+
+// UnmarshalText decodes the hexadecimal text.
+func (h *Hex) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	str = strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+	if str == "" {
+		*h = nil
+		return nil
+	}
+	decoded, err := hex.DecodeString(str)
+	if err != nil {
+		return fmt.Errorf("invalid hex: %w", err)
+	}
+	*h = decoded
+	return nil
+}
+
+// MarshalText encodes the bytes in lowercase hexadecimal.
+func (h Hex) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+func (h Hex) String() string {
+	return hex.EncodeToString(h)
+}
+
+// FormatHint describes the accepted format.
+func (Hex) FormatHint() string {
+	return "hex-encoded bytes"
+}
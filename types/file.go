@@ -0,0 +1,40 @@
+package types
+
+import "strings"
+
+// FileContent is the content of a file whose path is configured, such as a
+// TLS certificate, a private key or a service account token. Sources set
+// Path; the filecontent plugin, which Load includes, reads the file into
+// Content during Parse and again on every Refresh, so a rotated file is
+// published like any other change.
+//
+// FileContent renders as its path, so Usage, GenerateMarkdown and dumps never
+// show the content. Tag the field secret to hide the path as well.
+type FileContent struct {
+	Path    string
+	Content []byte
+}
+
+// UnmarshalText sets the path of the file. The content is kept while the path
+// stays the same and dropped when it changes, until the file is read again.
+func (fc *FileContent) UnmarshalText(text []byte) error {
+	path := strings.TrimSpace(string(text))
+	if path != fc.Path {
+		*fc = FileContent{Path: path}
+	}
+	return nil
+}
+
+// MarshalText renders the path of the file.
+func (fc FileContent) MarshalText() ([]byte, error) {
+	return []byte(fc.Path), nil
+}
+
+func (fc FileContent) String() string {
+	return fc.Path
+}
+
+// FormatHint describes the accepted format.
+func (FileContent) FormatHint() string {
+	return "path to a file"
+}
@@ -0,0 +1,143 @@
+package types
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// URL is a URL parsed with url.Parse. The empty string is the zero URL.
+type URL struct {
+	url.URL
+}
+
+// UnmarshalText parses a URL such as "https://example.com/path".
+func (u *URL) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if str == "" {
+		*u = URL{}
+		return nil
+	}
+	parsed, err := url.Parse(str)
+	if err != nil {
+		return err
+	}
+	*u = URL{URL: *parsed}
+	return nil
+}
+
+// MarshalText renders the URL.
+func (u URL) MarshalText() ([]byte, error) {
+	return []byte(u.String()), nil
+}
+
+func (u URL) String() string {
+	return u.URL.String()
+}
+
+// FormatHint describes the accepted format.
+func (URL) FormatHint() string {
+	return "URL such as https://example.com/path"
+}
+
+// HostPort is a network address written as "host:port", such as
+// "localhost:8080", "[::1]:443" or ":8080" to listen on every interface.
+// The empty string is the zero HostPort.
+type HostPort struct {
+	Host string
+	Port uint16
+}
+
+// UnmarshalText parses an address such as "localhost:8080".
+func (hp *HostPort) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if str == "" {
+		*hp = HostPort{}
+		return nil
+	}
+	host, port, err := net.SplitHostPort(str)
+	if err != nil {
+		return err
+	}
+	p, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return fmt.Errorf("address %s: invalid port %q", str, port)
+	}
+	*hp = HostPort{Host: host, Port: uint16(p)}
+	return nil
+}
+
+// MarshalText renders the address as "host:port".
+func (hp HostPort) MarshalText() ([]byte, error) {
+	return []byte(hp.String()), nil
+}
+
+func (hp HostPort) String() string {
+	if hp == (HostPort{}) {
+		return ""
+	}
+	return net.JoinHostPort(hp.Host, strconv.FormatUint(uint64(hp.Port), 10))
+}
+
+// FormatHint describes the accepted format.
+func (HostPort) FormatHint() string {
+	return "host:port such as localhost:8080"
+}
+
+// CIDRList is a comma-separated list of network prefixes such as
+// "10.0.0.0/8,192.168.0.0/16". A bare address stands for the prefix holding
+// only that address.
+type CIDRList []netip.Prefix
+
+// UnmarshalText parses a comma-separated list of prefixes.
+func (l *CIDRList) UnmarshalText(text []byte) error {
+	var list CIDRList
+	for s := range strings.SplitSeq(string(text), ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(s)
+			if addrErr != nil {
+				return err
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		list = append(list, prefix)
+	}
+	*l = list
+	return nil
+}
+
+// MarshalText renders the list as comma-separated prefixes.
+func (l CIDRList) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l CIDRList) String() string {
+	s := make([]string, len(l))
+	for i, prefix := range l {
+		s[i] = prefix.String()
+	}
+	return strings.Join(s, ",")
+}
+
+// Contains reports whether any prefix of the list contains addr.
+func (l CIDRList) Contains(addr netip.Addr) bool {
+	for _, prefix := range l {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// FormatHint describes the accepted format.
+func (CIDRList) FormatHint() string {
+	return "comma-separated CIDRs such as 10.0.0.0/8,192.168.0.0/16"
+}
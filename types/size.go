@@ -0,0 +1,169 @@
+package types
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"strconv"
+	"strings"
+)
+
+// ByteSize is a number of bytes written with an optional decimal (KB, MB, GB,
+// TB, PB, EB) or binary (KiB, MiB, GiB, TiB, PiB, EiB) unit, such as "512MiB"
+// or "1.5GB". Units are case-insensitive; a bare number is a number of bytes.
+type ByteSize uint64
+
+// Binary byte sizes.
+const (
+	Byte ByteSize = 1 << (10 * iota)
+	KiB
+	MiB
+	GiB
+	TiB
+	PiB
+	EiB
+)
+
+// Decimal byte sizes.
+const (
+	KB ByteSize = 1000 * Byte
+	MB ByteSize = 1000 * KB
+	GB ByteSize = 1000 * MB
+	TB ByteSize = 1000 * GB
+	PB ByteSize = 1000 * TB
+	EB ByteSize = 1000 * PB
+)
+
+// byteUnits lists the units MarshalText picks from, largest first; binary
+// units are preferred over decimal ones of the same magnitude.
+var byteUnits = []struct {
+	name string
+	size ByteSize
+}{
+	{"EiB", EiB}, {"EB", EB},
+	{"PiB", PiB}, {"PB", PB},
+	{"TiB", TiB}, {"TB", TB},
+	{"GiB", GiB}, {"GB", GB},
+	{"MiB", MiB}, {"MB", MB},
+	{"KiB", KiB}, {"KB", KB},
+}
+
+func parseByteUnit(unit string) (ByteSize, bool) {
+	if unit == "" || strings.EqualFold(unit, "B") {
+		return Byte, true
+	}
+	for _, u := range byteUnits {
+		if strings.EqualFold(unit, u.name) {
+			return u.size, true
+		}
+	}
+	return 0, false
+}
+
+// UnmarshalText parses a size such as "512MiB".
+func (s *ByteSize) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	i := strings.IndexFunc(str, func(r rune) bool {
+		return (r < '0' || r > '9') && r != '.'
+	})
+	if i < 0 {
+		i = len(str)
+	}
+	number, unit := str[:i], strings.TrimSpace(str[i:])
+	if number == "" {
+		return fmt.Errorf("invalid byte size %q", str)
+	}
+	size, ok := parseByteUnit(unit)
+	if !ok {
+		return fmt.Errorf("invalid byte size %q: unknown unit %q", str, unit)
+	}
+
+	if !strings.Contains(number, ".") {
+		n, err := strconv.ParseUint(number, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid byte size %q: %w", str, err)
+		}
+		hi, lo := bits.Mul64(n, uint64(size))
+		if hi != 0 {
+			return fmt.Errorf("invalid byte size %q: %w", str, errByteSizeRange)
+		}
+		*s = ByteSize(lo)
+		return nil
+	}
+
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil {
+		return fmt.Errorf("invalid byte size %q: %w", str, err)
+	}
+	f *= float64(size)
+	if f >= math.MaxUint64 {
+		return fmt.Errorf("invalid byte size %q: %w", str, errByteSizeRange)
+	}
+	if f != math.Trunc(f) {
+		return fmt.Errorf("invalid byte size %q: not a whole number of bytes", str)
+	}
+	*s = ByteSize(f)
+	return nil
+}
+
+var errByteSizeRange = errors.New("value out of range")
+
+// MarshalText renders the size with the largest unit dividing it exactly.
+func (s ByteSize) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s ByteSize) String() string {
+	for _, u := range byteUnits {
+		if s >= u.size && s%u.size == 0 {
+			return strconv.FormatUint(uint64(s/u.size), 10) + u.name
+		}
+	}
+	return strconv.FormatUint(uint64(s), 10) + "B"
+}
+
+// Bytes returns the size as a number of bytes.
+func (s ByteSize) Bytes() uint64 {
+	return uint64(s)
+}
+
+// FormatHint describes the accepted format.
+func (ByteSize) FormatHint() string {
+	return "size such as 512MiB or 1.5GB"
+}
+
+// Percent is a percentage written as "25%" or "25"; fractional and values
+// above 100 are accepted.
+type Percent float64
+
+// UnmarshalText parses a percentage such as "25%".
+func (p *Percent) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	number := strings.TrimSpace(strings.TrimSuffix(str, "%"))
+	f, err := strconv.ParseFloat(number, 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return fmt.Errorf("invalid percentage %q", str)
+	}
+	*p = Percent(f)
+	return nil
+}
+
+// MarshalText renders the percentage with a trailing "%".
+func (p Percent) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p Percent) String() string {
+	return strconv.FormatFloat(float64(p), 'f', -1, 64) + "%"
+}
+
+// Fraction returns the percentage as a fraction, 0.25 for 25%.
+func (p Percent) Fraction() float64 {
+	return float64(p) / 100
+}
+
+// FormatHint describes the accepted format.
+func (Percent) FormatHint() string {
+	return "percentage such as 25%"
+}
@@ -0,0 +1,159 @@
+package types
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Regexp is a regular expression in RE2 syntax, compiled with regexp.Compile.
+// The empty string is the zero Regexp, whose embedded *regexp.Regexp is nil.
+type Regexp struct {
+	*regexp.Regexp
+}
+
+// UnmarshalText compiles the regular expression.
+func (r *Regexp) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*r = Regexp{}
+		return nil
+	}
+	re, err := regexp.Compile(string(text))
+	if err != nil {
+		return err
+	}
+	*r = Regexp{Regexp: re}
+	return nil
+}
+
+// MarshalText renders the source text of the regular expression.
+func (r Regexp) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+func (r Regexp) String() string {
+	if r.Regexp == nil {
+		return ""
+	}
+	return r.Regexp.String()
+}
+
+// FormatHint describes the accepted format.
+func (Regexp) FormatHint() string {
+	return "regular expression (RE2 syntax)"
+}
+
+// Location is a time zone loaded with time.LoadLocation, such as "UTC" or
+// "Europe/Berlin". The empty string is the zero Location, whose embedded
+// *time.Location is nil.
+type Location struct {
+	*time.Location
+}
+
+// UnmarshalText loads the time zone.
+func (l *Location) UnmarshalText(text []byte) error {
+	name := strings.TrimSpace(string(text))
+	if name == "" {
+		*l = Location{}
+		return nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	*l = Location{Location: loc}
+	return nil
+}
+
+// MarshalText renders the name of the time zone.
+func (l Location) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l Location) String() string {
+	if l.Location == nil {
+		return ""
+	}
+	return l.Location.String()
+}
+
+// FormatHint describes the accepted format.
+func (Location) FormatHint() string {
+	return "IANA time zone such as Europe/Berlin"
+}
+
+// FileMode is a file permission written in octal, such as "0644" or "0o750".
+type FileMode fs.FileMode
+
+// UnmarshalText parses an octal permission such as "0644".
+func (m *FileMode) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	digits := strings.TrimPrefix(strings.TrimPrefix(str, "0o"), "0O")
+	n, err := strconv.ParseUint(digits, 8, 32)
+	if err != nil || n > 0o7777 {
+		return fmt.Errorf("invalid file mode %q", str)
+	}
+	*m = FileMode(n)
+	return nil
+}
+
+// MarshalText renders the permission in octal.
+func (m FileMode) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m FileMode) String() string {
+	return fmt.Sprintf("%04o", uint32(m))
+}
+
+// Mode returns the permission as an fs.FileMode.
+func (m FileMode) Mode() fs.FileMode {
+	return fs.FileMode(m)
+}
+
+// FormatHint describes the accepted format.
+func (FileMode) FormatHint() string {
+	return "octal file mode such as 0644"
+}
+
+// LogLevel is a slog.Level written as "debug", "info", "warn" or "error",
+// optionally with an offset such as "info+2". Names are case-insensitive and
+// "warning" is accepted for "warn". LogLevel implements slog.Leveler.
+type LogLevel slog.Level
+
+// UnmarshalText parses a level such as "info".
+func (l *LogLevel) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if strings.EqualFold(str, "warning") {
+		str = "warn"
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(str)); err != nil {
+		return err
+	}
+	*l = LogLevel(level)
+	return nil
+}
+
+// MarshalText renders the level as slog does, such as "INFO".
+func (l LogLevel) MarshalText() ([]byte, error) {
+	return []byte(l.String()), nil
+}
+
+func (l LogLevel) String() string {
+	return slog.Level(l).String()
+}
+
+// Level returns the level as a slog.Level.
+func (l LogLevel) Level() slog.Level {
+	return slog.Level(l)
+}
+
+// FormatHint describes the accepted format.
+func (LogLevel) FormatHint() string {
+	return "log level: debug, info, warn or error"
+}
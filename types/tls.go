@@ -0,0 +1,265 @@
+package types
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TLS is a configuration section describing a TLS endpoint: its certificate
+// and key, the CA bundle verifying peers, and the protocol settings.
+// Certificates come from files, re-read on every refresh, or inline PEM, such
+// as a key resolved by the secret plugin; inline PEM takes precedence.
+//
+//	type Config struct {
+//	    Server types.TLS
+//	}
+//
+// The tlsconfig package builds a *tls.Config following the certificates of
+// the latest published snapshot.
+type TLS struct {
+	CertFile FileContent `usage:"PEM certificate chain file"`
+	KeyFile  FileContent `usage:"PEM private key file"`
+	CAFile   FileContent `usage:"PEM CA bundle file verifying peers"`
+
+	Cert string `usage:"PEM certificate chain, instead of CertFile"`
+	Key  string `secret:"" usage:"PEM private key, instead of KeyFile"`
+	CA   string `usage:"PEM CA bundle, instead of CAFile"`
+
+	MinVersion   TLSVersion      `default:"1.2" usage:"lowest accepted TLS version"`
+	CipherSuites TLSCipherSuites `usage:"accepted TLS 1.0-1.2 cipher suites, all secure ones when empty"`
+	ClientAuth   TLSClientAuth   `default:"none" usage:"client certificate policy of servers"`
+}
+
+// Enabled reports whether the section configures a certificate.
+func (t TLS) Enabled() bool {
+	return t.certPEM() != "" || t.keyPEM() != ""
+}
+
+func (t TLS) certPEM() string {
+	if t.Cert != "" {
+		return t.Cert
+	}
+	return string(t.CertFile.Content)
+}
+
+func (t TLS) keyPEM() string {
+	if t.Key != "" {
+		return t.Key
+	}
+	return string(t.KeyFile.Content)
+}
+
+func (t TLS) caPEM() string {
+	if t.CA != "" {
+		return t.CA
+	}
+	return string(t.CAFile.Content)
+}
+
+// Certificate parses the certificate and key. It returns nil when neither is
+// configured, and an error when only one of them is.
+func (t TLS) Certificate() (*tls.Certificate, error) {
+	certPEM, keyPEM := t.certPEM(), t.keyPEM()
+	switch {
+	case certPEM == "" && keyPEM == "":
+		return nil, nil
+	case certPEM == "":
+		return nil, errors.New("tls: key configured without a certificate")
+	case keyPEM == "":
+		return nil, errors.New("tls: certificate configured without a key")
+	}
+	cert, err := tls.X509KeyPair([]byte(certPEM), []byte(keyPEM))
+	if err != nil {
+		return nil, fmt.Errorf("tls: %w", err)
+	}
+	return &cert, nil
+}
+
+// CertPool parses the CA bundle. It returns nil when none is configured.
+func (t TLS) CertPool() (*x509.CertPool, error) {
+	caPEM := t.caPEM()
+	if caPEM == "" {
+		return nil, nil
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM([]byte(caPEM)) {
+		return nil, errors.New("tls: no certificates found in CA bundle")
+	}
+	return pool, nil
+}
+
+// Validate checks that the certificate matches the key and that the CA
+// bundle parses, so the validate plugin keeps a half-rotated pair from being
+// published.
+func (t TLS) Validate() error {
+	if _, err := t.Certificate(); err != nil {
+		return err
+	}
+	_, err := t.CertPool()
+	return err
+}
+
+// TLSVersion is a TLS protocol version written as "1.0", "1.1", "1.2" or
+// "1.3". The empty string is the zero TLSVersion, leaving the crypto/tls
+// default in place.
+type TLSVersion uint16
+
+var tlsVersions = []struct {
+	name    string
+	version uint16
+}{
+	{"1.0", tls.VersionTLS10},
+	{"1.1", tls.VersionTLS11},
+	{"1.2", tls.VersionTLS12},
+	{"1.3", tls.VersionTLS13},
+}
+
+// UnmarshalText parses a version such as "1.2", optionally prefixed with
+// "TLS".
+func (v *TLSVersion) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	name := strings.TrimSpace(strings.TrimPrefix(strings.ToUpper(str), "TLS"))
+	if name == "" {
+		*v = 0
+		return nil
+	}
+	for _, known := range tlsVersions {
+		if name == known.name {
+			*v = TLSVersion(known.version)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid TLS version %q", str)
+}
+
+// MarshalText renders the version such as "1.2".
+func (v TLSVersion) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v TLSVersion) String() string {
+	for _, known := range tlsVersions {
+		if uint16(v) == known.version {
+			return known.name
+		}
+	}
+	if v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("0x%04x", uint16(v))
+}
+
+// FormatHint describes the accepted format.
+func (TLSVersion) FormatHint() string {
+	return "TLS version: 1.0, 1.1, 1.2 or 1.3"
+}
+
+// TLSCipherSuites is a comma-separated list of cipher suite names as listed
+// by tls.CipherSuites and tls.InsecureCipherSuites, such as
+// "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256".
+type TLSCipherSuites []uint16
+
+// UnmarshalText parses a comma-separated list of cipher suite names.
+func (cs *TLSCipherSuites) UnmarshalText(text []byte) error {
+	var suites TLSCipherSuites
+	for name := range strings.SplitSeq(string(text), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		id, ok := cipherSuiteID(name)
+		if !ok {
+			return fmt.Errorf("unknown cipher suite %q", name)
+		}
+		suites = append(suites, id)
+	}
+	*cs = suites
+	return nil
+}
+
+func cipherSuiteID(name string) (uint16, bool) {
+	for _, suites := range [][]*tls.CipherSuite{tls.CipherSuites(), tls.InsecureCipherSuites()} {
+		for _, suite := range suites {
+			if strings.EqualFold(suite.Name, name) {
+				return suite.ID, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// MarshalText renders the list as comma-separated cipher suite names.
+func (cs TLSCipherSuites) MarshalText() ([]byte, error) {
+	return []byte(cs.String()), nil
+}
+
+func (cs TLSCipherSuites) String() string {
+	names := make([]string, len(cs))
+	for i, id := range cs {
+		names[i] = tls.CipherSuiteName(id)
+	}
+	return strings.Join(names, ",")
+}
+
+// FormatHint describes the accepted format.
+func (TLSCipherSuites) FormatHint() string {
+	return "comma-separated cipher suite names such as TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256"
+}
+
+// TLSClientAuth is the client certificate policy of a server, written as
+// "none", "request", "require", "verify-if-given" or "require-and-verify".
+type TLSClientAuth tls.ClientAuthType
+
+var tlsClientAuths = []struct {
+	name string
+	auth tls.ClientAuthType
+}{
+	{"none", tls.NoClientCert},
+	{"request", tls.RequestClientCert},
+	{"require", tls.RequireAnyClientCert},
+	{"verify-if-given", tls.VerifyClientCertIfGiven},
+	{"require-and-verify", tls.RequireAndVerifyClientCert},
+}
+
+// UnmarshalText parses a policy such as "require-and-verify".
+func (a *TLSClientAuth) UnmarshalText(text []byte) error {
+	str := strings.TrimSpace(string(text))
+	if str == "" {
+		*a = TLSClientAuth(tls.NoClientCert)
+		return nil
+	}
+	for _, known := range tlsClientAuths {
+		if strings.EqualFold(str, known.name) {
+			*a = TLSClientAuth(known.auth)
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid client auth %q", str)
+}
+
+// MarshalText renders the policy such as "require-and-verify".
+func (a TLSClientAuth) MarshalText() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+func (a TLSClientAuth) String() string {
+	for _, known := range tlsClientAuths {
+		if tls.ClientAuthType(a) == known.auth {
+			return known.name
+		}
+	}
+	return tls.ClientAuthType(a).String()
+}
+
+// AuthType returns the policy as a tls.ClientAuthType.
+func (a TLSClientAuth) AuthType() tls.ClientAuthType {
+	return tls.ClientAuthType(a)
+}
+
+// FormatHint describes the accepted format.
+func (TLSClientAuth) FormatHint() string {
+	return "none, request, require, verify-if-given or require-and-verify"
+}
@@ -0,0 +1,16 @@
+// Package types provides common configuration value types such as ByteSize,
+// HostPort and LogLevel.
+//
+// Every type is parsed from text with UnmarshalText, so flags, environment
+// variables, defaults and the JSON and YAML decoders all accept the same
+// format, and renders back to that format with MarshalText and String so
+// dumps, defaults in Usage and generated documentation round-trip. FormatHint
+// describes the accepted format; Usage and GenerateMarkdown show it next to
+// the field.
+//
+//	type Config struct {
+//	    MaxBody  types.ByteSize `default:"1MiB"`
+//	    Listen   types.HostPort `default:":8080"`
+//	    LogLevel types.LogLevel `default:"info"`
+//	}
+package types
@@ -0,0 +1,257 @@
+package types_test
+
+import (
+	"encoding"
+	"encoding/json"
+	"log/slog"
+	"net/netip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sxwebdev/xconfig"
+	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/testutil"
+	"github.com/sxwebdev/xconfig/plugins/loader"
+	"github.com/sxwebdev/xconfig/types"
+)
+
+type textValue interface {
+	encoding.TextMarshaler
+	encoding.TextUnmarshaler
+}
+
+func TestTextRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value func() textValue
+		text  string
+		want  string
+	}{
+		{"ByteSize binary", func() textValue { return new(types.ByteSize) }, "512MiB", "512MiB"},
+		{"ByteSize decimal", func() textValue { return new(types.ByteSize) }, "1.5 gb", "1500MB"},
+		{"ByteSize bytes", func() textValue { return new(types.ByteSize) }, "1500", "1500B"},
+		{"ByteSize zero", func() textValue { return new(types.ByteSize) }, "0", "0B"},
+		{"Percent", func() textValue { return new(types.Percent) }, "12.5", "12.5%"},
+		{"URL", func() textValue { return new(types.URL) }, "https://user@example.com:8443/path?q=1", "https://user@example.com:8443/path?q=1"},
+		{"HostPort", func() textValue { return new(types.HostPort) }, "[::1]:443", "[::1]:443"},
+		{"HostPort any host", func() textValue { return new(types.HostPort) }, ":8080", ":8080"},
+		{"Regexp", func() textValue { return new(types.Regexp) }, "^a+b$", "^a+b$"},
+		{"Location", func() textValue { return new(types.Location) }, "UTC", "UTC"},
+		{"FileMode", func() textValue { return new(types.FileMode) }, "0o750", "0750"},
+		{"LogLevel", func() textValue { return new(types.LogLevel) }, "warning", "WARN"},
+		{"Base64Bytes", func() textValue { return new(types.Base64Bytes) }, "aGk", "aGk="},
+		{"Hex", func() textValue { return new(types.Hex) }, "0xCAFE", "cafe"},
+		{"CIDRList", func() textValue { return new(types.CIDRList) }, "10.0.0.0/8, 192.168.1.1", "10.0.0.0/8,192.168.1.1/32"},
+		{"FileContent", func() textValue { return new(types.FileContent) }, " /etc/tls/tls.crt", "/etc/tls/tls.crt"},
+		{"TLSVersion", func() textValue { return new(types.TLSVersion) }, "TLS1.3", "1.3"},
+		{"TLSCipherSuites", func() textValue { return new(types.TLSCipherSuites) }, "tls_ecdhe_ecdsa_with_aes_128_gcm_sha256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384"},
+		{"TLSClientAuth", func() textValue { return new(types.TLSClientAuth) }, "Require-And-Verify", "require-and-verify"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v := tt.value()
+			if err := v.UnmarshalText([]byte(tt.text)); err != nil {
+				t.Fatalf("UnmarshalText(%q): %v", tt.text, err)
+			}
+			text, err := v.MarshalText()
+			if err != nil {
+				t.Fatal(err)
+			}
+			testutil.Equal(t, tt.want, string(text))
+
+			again := tt.value()
+			if err := again.UnmarshalText(text); err != nil {
+				t.Fatalf("UnmarshalText(%q): %v", text, err)
+			}
+			testutil.Equal(t, v, again)
+		})
+	}
+}
+
+func TestTextInvalid(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name  string
+		value textValue
+		text  string
+	}{
+		{"ByteSize unit", new(types.ByteSize), "12XB"},
+		{"ByteSize overflow", new(types.ByteSize), "20EiB"},
+		{"ByteSize fraction", new(types.ByteSize), "1.5B"},
+		{"Percent", new(types.Percent), "half"},
+		{"HostPort", new(types.HostPort), "localhost"},
+		{"HostPort port", new(types.HostPort), "localhost:70000"},
+		{"Regexp", new(types.Regexp), "a("},
+		{"Location", new(types.Location), "Nowhere/Special"},
+		{"FileMode", new(types.FileMode), "0999"},
+		{"LogLevel", new(types.LogLevel), "loud"},
+		{"Base64Bytes", new(types.Base64Bytes), "***"},
+		{"Hex", new(types.Hex), "xyz"},
+		{"CIDRList", new(types.CIDRList), "10.0.0.0/8,nope"},
+		{"TLSVersion", new(types.TLSVersion), "2.0"},
+		{"TLSCipherSuites", new(types.TLSCipherSuites), "TLS_NOPE"},
+		{"TLSClientAuth", new(types.TLSClientAuth), "always"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.value.UnmarshalText([]byte(tt.text)); err == nil {
+				t.Fatalf("UnmarshalText(%q): expected an error", tt.text)
+			}
+		})
+	}
+}
+
+func TestValueHelpers(t *testing.T) {
+	t.Parallel()
+
+	testutil.Equal(t, uint64(3<<20), (3 * types.MiB).Bytes())
+	testutil.Equal(t, 0.25, types.Percent(25).Fraction())
+	testutil.Equal(t, os.FileMode(0o644), types.FileMode(0o644).Mode())
+	testutil.Equal(t, slog.LevelDebug, types.LogLevel(slog.LevelDebug).Level())
+
+	section := types.TLS{Cert: "-----BEGIN CERTIFICATE-----"}
+	if err := section.Validate(); err == nil {
+		t.Error("Validate() of a certificate without a key succeeded")
+	}
+	testutil.Equal(t, nil, types.TLS{}.Validate())
+
+	cidrs := types.CIDRList{netip.MustParsePrefix("10.0.0.0/8")}
+	testutil.Equal(t, true, cidrs.Contains(netip.MustParseAddr("10.1.2.3")))
+	testutil.Equal(t, false, cidrs.Contains(netip.MustParseAddr("192.168.0.1")))
+}
+
+type config struct {
+	MaxBody  types.ByteSize    `default:"1MiB" usage:"largest accepted request body"`
+	Sampling types.Percent     `default:"10%"`
+	Upstream types.URL         `default:"https://example.com/api"`
+	Listen   types.HostPort    `default:":8080"`
+	Match    types.Regexp      `default:"^/v[0-9]+/"`
+	Zone     types.Location    `default:"UTC"`
+	Mode     types.FileMode    `default:"0640"`
+	Level    types.LogLevel    `default:"info"`
+	Key      types.Base64Bytes `default:"c2VjcmV0"`
+	Salt     types.Hex         `default:"00ff"`
+	Trusted  types.CIDRList    `default:"10.0.0.0/8,::1"`
+}
+
+func TestLoadTypes(t *testing.T) {
+	t.Parallel()
+
+	file := `{
+		"MaxBody": "2GiB",
+		"Listen": "127.0.0.1:9090",
+		"Level": "debug",
+		"Trusted": "192.168.0.0/16"
+	}`
+
+	var cfg config
+	c, err := xconfig.Load(&cfg,
+		xconfig.WithSkipEnv(),
+		xconfig.WithSkipFlags(),
+		xconfig.WithPlugins(loader.NewReader(strings.NewReader(file), json.Unmarshal)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testutil.Equal(t, 2*types.GiB, cfg.MaxBody)
+	testutil.Equal(t, types.Percent(10), cfg.Sampling)
+	testutil.Equal(t, "example.com", cfg.Upstream.Host)
+	testutil.Equal(t, types.HostPort{Host: "127.0.0.1", Port: 9090}, cfg.Listen)
+	testutil.Equal(t, true, cfg.Match.MatchString("/v2/users"))
+	testutil.Equal(t, "UTC", cfg.Zone.String())
+	testutil.Equal(t, types.FileMode(0o640), cfg.Mode)
+	testutil.Equal(t, types.LogLevel(slog.LevelDebug), cfg.Level)
+	testutil.Equal(t, "secret", string(cfg.Key))
+	testutil.Equal(t, types.Hex{0x00, 0xff}, cfg.Salt)
+	testutil.Equal(t, "192.168.0.0/16", cfg.Trusted.String())
+
+	fields, err := flat.View(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]string{
+		"MaxBody": "64KiB",
+		"Salt":    "0xbeef",
+		"Trusted": "10.0.0.0/8,172.16.0.0/12",
+	}
+	for _, f := range fields {
+		value, ok := values[f.Name()]
+		if !ok {
+			continue
+		}
+		changed, err := f.SetChanged(value)
+		if err != nil {
+			t.Fatalf("field %s: %v", f.Name(), err)
+		}
+		testutil.Equal(t, true, changed)
+		delete(values, f.Name())
+	}
+	testutil.Equal(t, 0, len(values))
+	testutil.Equal(t, 64*types.KiB, cfg.MaxBody)
+	testutil.Equal(t, types.Hex{0xbe, 0xef}, cfg.Salt)
+	testutil.Equal(t, "10.0.0.0/8,172.16.0.0/12", cfg.Trusted.String())
+
+	snapshot, err := xconfig.Snapshot[config](c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, 2*types.GiB, snapshot.MaxBody)
+	testutil.Equal(t, types.Hex{0x00, 0xff}, snapshot.Salt)
+	testutil.Equal(t, "192.168.0.0/16", snapshot.Trusted.String())
+	testutil.Equal(t, cfg.Match.Regexp, snapshot.Match.Regexp)
+
+	dump, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded config
+	if err := json.Unmarshal(dump, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, snapshot.Listen, decoded.Listen)
+	testutil.Equal(t, snapshot.Key, decoded.Key)
+	testutil.Equal(t, snapshot.Trusted, decoded.Trusted)
+	testutil.Equal(t, snapshot.Upstream.String(), decoded.Upstream.String())
+}
+
+func TestUsageShowsFormatHints(t *testing.T) {
+	t.Parallel()
+
+	var cfg config
+	c, err := xconfig.Load(&cfg, xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, err := c.Usage()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"FORMAT", "size such as 512MiB or 1.5GB", "1MiB", "0640", "INFO"} {
+		if !strings.Contains(usage, want) {
+			t.Errorf("usage does not contain %q:\n%s", want, usage)
+		}
+	}
+
+	markdown, err := xconfig.GenerateMarkdown(&config{}, xconfig.WithSkipEnv(), xconfig.WithSkipFlags())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "largest accepted request body (size such as 512MiB or 1.5GB)"; !strings.Contains(markdown, want) {
+		t.Errorf("markdown does not contain %q:\n%s", want, markdown)
+	}
+	if want := "(host:port such as localhost:8080)"; !strings.Contains(markdown, want) {
+		t.Errorf("markdown does not contain %q:\n%s", want, markdown)
+	}
+}
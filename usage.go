@@ -3,15 +3,39 @@ package xconfig
 import (
 	"bytes"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 	"text/tabwriter"
 
 	"github.com/sxwebdev/xconfig/flat"
 	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/env"
 )
 
-const usageTag = "usage"
+const (
+	usageTag  = "usage"
+	formatKey = "format"
+)
+
+// FormatHinter is implemented by field types describing the text format they
+// accept, such as the types of the types package. Usage shows the hint in a
+// format column and GenerateMarkdown next to the usage of the field.
+type FormatHinter interface {
+	FormatHint() string
+}
+
+// formatHint returns the format hint of the type of f, or "" when it has none.
+func formatHint(f flat.Field) string {
+	t := f.FieldType().Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if h, ok := reflect.New(t).Interface().(FormatHinter); ok {
+		return h.FormatHint()
+	}
+	return ""
+}
 
 func init() {
 	plugins.RegisterTag(usageTag)
@@ -31,6 +55,10 @@ func (c *config) Usage() (string, error) {
 	headers := getHeaders(c.fields)
 
 	buf := bytes.NewBuffer(nil)
+	if err := writeProfiles(buf, c.options); err != nil {
+		return "", err
+	}
+
 	w := tabwriter.NewWriter(buf, 0, 0, 4, ' ', 0)
 	if _, err := fmt.Fprintf(w, "\nSupported Fields:\n"); err != nil {
 		return "", err
@@ -58,7 +86,9 @@ func (c *config) Usage() (string, error) {
 		for i, header := range headers[1:] {
 			value := f.Meta()[header]
 
-			if header == "default" && f.FieldValue().CanInterface() {
+			// An absent optional section holds no values yet: show its
+			// default tags instead.
+			if header == "default" && f.FieldValue().CanInterface() && !flat.Unallocated(f) {
 				value = fmt.Sprintf("%v", f.FieldValue().Interface())
 				if _, ok := f.Tag("secret"); ok {
 					value = ""
@@ -86,8 +116,54 @@ func (c *config) Usage() (string, error) {
 	return buf.String(), nil
 }
 
+// writeProfiles writes the active profiles and whether each file of the
+// loader was found, when profiles are enabled.
+func writeProfiles(buf *bytes.Buffer, o *options) error {
+	if o == nil || !o.withProfiles {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(buf, "\nProfiles: %s\n", profileList(o.activeProfiles, "")); err != nil {
+		return err
+	}
+	if o.loader == nil {
+		return nil
+	}
+
+	w := tabwriter.NewWriter(buf, 0, 0, 4, ' ', 0)
+	if _, err := fmt.Fprintln(w, "Files:"); err != nil {
+		return err
+	}
+	for _, file := range o.loader.Files() {
+		status := "not found"
+		if file.Found {
+			status = "found"
+		}
+		if _, err := fmt.Fprintf(w, "    %s\t%s\n", file.Path, status); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// profileList joins profiles, each wrapped in quote, or returns "none".
+func profileList(profiles []string, quote string) string {
+	if len(profiles) == 0 {
+		return "none"
+	}
+	items := make([]string, len(profiles))
+	for i, profile := range profiles {
+		items[i] = quote + profile + quote
+	}
+	return strings.Join(items, ", ")
+}
+
 func setUsageMeta(fs flat.Fields) {
 	for _, f := range fs {
+		if hint := formatHint(f); hint != "" {
+			f.Meta()[formatKey] = hint
+		}
+
 		usage, ok := f.Tag(usageTag)
 		if !ok {
 			continue
@@ -119,6 +195,8 @@ func getHeaders(fs flat.Fields) []string {
 		"usage": 99,
 		"flag":  3,
 		"env":   4,
+
+		env.FileMetaKey: 5,
 	}
 
 	weight := func(tags []string, i int) int {
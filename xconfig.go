@@ -6,13 +6,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"reflect"
 	"slices"
 	"sync"
 	"time"
 
 	"github.com/sxwebdev/xconfig/flat"
+	"github.com/sxwebdev/xconfig/internal/interpolate"
 	"github.com/sxwebdev/xconfig/plugins"
+	"github.com/sxwebdev/xconfig/plugins/defaults"
 )
 
 const defaultTag = "default"
@@ -68,23 +71,45 @@ type Config interface {
 	// the configuration type.
 	UnknownFields() map[string][]string
 
+	// Provenance returns, per flat field name, the chain of sources that set
+	// the field in the order they were applied. The last entry is the source
+	// of the current value. Sources name env vars, flags, files or keys and
+	// never contain the values themselves.
+	Provenance() map[string][]plugins.Source
+
+	// Explain returns the provenance chain of a single flat field, or nil when
+	// no source has set it.
+	Explain(fieldName string) []plugins.Source
+
 	// Refresh synchronously refreshes every plugin implementing
-	// plugins.Refreshable and atomically publishes the resulting snapshot. If a
-	// plugin fails, the last successfully published snapshot remains current.
+	// plugins.Refreshable and atomically publishes the resulting snapshot. A
+	// changed snapshot is first checked by every plugins.Validator, such as the
+	// validate plugin. If a plugin or a validator fails, the last successfully
+	// published snapshot remains current and the failure is returned in Err.
 	Refresh(ctx context.Context) RefreshResult
 
 	// StartRefresh starts a background goroutine that periodically calls Refresh.
 	// The bounded returned channel reports changes, warnings, and errors and is
 	// closed when the loop stops. Slow or absent consumers never block refresh;
 	// coalesced events are reported through RefreshResult.Dropped. Starting a
-	// valid new loop replaces the previous one. A non-positive interval or a
-	// configuration without any plugins.Refreshable plugin is rejected with
-	// ErrInvalidRefreshInterval or ErrNoRefreshablePlugins, leaving a running
-	// loop unchanged.
+	// valid new loop replaces the previous one. Plugins implementing
+	// plugins.Notifier, such as files loaded with WithWatchFiles, additionally
+	// trigger a refresh as soon as their source changes. A non-positive
+	// interval or a configuration without any plugins.Refreshable plugin is
+	// rejected with ErrInvalidRefreshInterval or ErrNoRefreshablePlugins,
+	// leaving a running loop unchanged.
 	StartRefresh(ctx context.Context, interval time.Duration) (<-chan RefreshResult, error)
 
 	// StopRefresh stops the background refresh goroutine and waits for it to finish.
 	StopRefresh()
+
+	// OnChange calls fn after every published refresh that changed a field
+	// whose flat name is prefix or starts with prefix followed by a dot, such
+	// as "Database" or "Database." for "Database.Host". An empty prefix
+	// matches every published refresh. fn runs synchronously on the goroutine
+	// calling Refresh, in publication order, and must not call Parse, Refresh
+	// or StopRefresh. The returned function removes the subscription.
+	OnChange(prefix string, fn func(ChangeEvent)) (unsubscribe func())
 }
 
 // RefreshResult describes one synchronous refresh or an event emitted by
@@ -99,16 +124,24 @@ type RefreshResult struct {
 
 // Custom returns a new Config. The conf must be a pointer to a struct.
 func Custom(conf any, ps ...plugins.Plugin) (Config, error) {
-	return newConfig(conf, ps...)
+	return newConfig(conf, nil, ps...)
 }
 
-func newConfig(conf any, ps ...plugins.Plugin) (*config, error) {
+// newConfig returns a Config of conf with the plugins ps. viewOptions, such as
+// the converters given with WithConverter, apply to every view.
+func newConfig(conf any, viewOptions []flat.Option, ps ...plugins.Plugin) (*config, error) {
 	c := &config{
 		target:  conf,
 		plugins: make([]plugins.Plugin, 0, len(ps)),
 	}
+	c.viewOptions = slices.Clone(viewOptions)
+	for _, plug := range ps {
+		if provider, ok := plug.(plugins.ViewOptionProvider); ok {
+			c.viewOptions = append(c.viewOptions, provider.ViewOptions()...)
+		}
+	}
 
-	fields, err := flat.View(conf)
+	fields, err := flat.View(conf, c.viewOptions...)
 	if err != nil {
 		return c, err
 	}
@@ -130,22 +163,36 @@ type config struct {
 	staging any // private mutable value passed to Refreshable plugins
 	fields  flat.Fields
 	options *options
+	// viewOptions configure every view of the configuration; see
+	// plugins.ViewReceiver.
+	viewOptions []flat.Option
 
 	operationMu sync.Mutex
 	usageMu     sync.Mutex
 	dataMu      sync.RWMutex
 	current     any
+	provenance  map[string][]plugins.Source
+	// setBy holds, by flat name, the index of the plugin that set each field
+	// last, telling refreshing plugins which fields later sources override.
+	setBy       map[string]int
 	parsed      bool
 	refreshable bool
 
 	refreshMu     sync.Mutex
 	refreshCancel context.CancelFunc
 	refreshDone   chan struct{}
+
+	subscriptionsMu sync.Mutex
+	subscriptions   []*subscription
 }
 
 func (c *config) addPlugin(plug plugins.Plugin) error { //nolint:funcorder
 	var atOnceChecked bool
 
+	if receiver, ok := plug.(plugins.ViewReceiver); ok {
+		receiver.SetViewOptions(c.viewOptions)
+	}
+
 	// if the plugin is a Walker, we need to call Walk on it.
 	walkerPlugin, ok := plug.(plugins.Walker)
 	if ok {
@@ -186,13 +233,53 @@ func (c *config) parse(publishSnapshot bool) error {
 	c.operationMu.Lock()
 	defer c.operationMu.Unlock()
 
-	for _, p := range c.plugins {
-		err := p.Parse()
+	origins := make(provenanceLog)
+	setBy := make(map[string]int)
+	for i, p := range c.plugins {
+		if reporter, ok := p.(plugins.SetReporter); ok {
+			if err := p.Parse(); err != nil {
+				return err
+			}
+			for _, fieldName := range reporter.SetFields() {
+				origins.add(fieldName, pluginSource(p, fieldName))
+				setBy[fieldName] = i
+			}
+			continue
+		}
+
+		before, err := cloneConfigPointer(c.target)
 		if err != nil {
+			return fmt.Errorf("track sources of %T: %w", p, err)
+		}
+		if err := p.Parse(); err != nil {
 			return err
 		}
+		changed, err := changedFieldNames(before, c.target, c.viewOptions)
+		if err != nil {
+			return fmt.Errorf("track sources of %T: %w", p, err)
+		}
+		for _, fieldName := range changed {
+			origins.add(fieldName, pluginSource(p, fieldName))
+			setBy[fieldName] = i
+		}
+	}
+	// A fallback also sets the defaults of the optional sections later
+	// plugins allocated.
+	for i, p := range c.plugins {
+		_, fallback := p.(plugins.Fallback)
+		reporter, ok := p.(plugins.SetReporter)
+		if !fallback || !ok {
+			continue
+		}
+		for _, fieldName := range reporter.SetFields() {
+			if _, ok := setBy[fieldName]; !ok {
+				origins.add(fieldName, pluginSource(p, fieldName))
+				setBy[fieldName] = i
+			}
+		}
 	}
 	if !publishSnapshot {
+		c.publishProvenance(origins, setBy, true)
 		return nil
 	}
 	c.staging = nil
@@ -202,6 +289,7 @@ func (c *config) parse(publishSnapshot bool) error {
 	}
 	c.parsed = true
 	c.publish(current)
+	c.publishProvenance(origins, setBy, true)
 	return nil
 }
 
@@ -217,14 +305,14 @@ func (c *config) Refresh(ctx context.Context) RefreshResult {
 	if !c.refreshable {
 		return result
 	}
-	current := c.currentSnapshot()
+	previous := c.currentSnapshot()
 	if err := ctx.Err(); err != nil {
 		result.Err = err
 		return result
 	}
 
 	if c.staging == nil {
-		staging, err := cloneConfigPointer(current)
+		staging, err := cloneConfigPointer(previous)
 		if err != nil {
 			result.Err = fmt.Errorf("prepare refresh staging: %w", err)
 			return result
@@ -232,12 +320,29 @@ func (c *config) Refresh(ctx context.Context) RefreshResult {
 		c.staging = staging
 	}
 
+	// Plugins keeping a private baseline learn whether the working copy they
+	// wrote to survived the cycle.
+	var refreshed []plugins.RefreshCommitter
+	defer func() {
+		for _, committer := range refreshed {
+			committer.EndRefresh(c.staging != nil)
+		}
+	}()
+
 	changedFields := make(map[string]struct{})
-	for _, p := range c.plugins {
+	origins := make(provenanceLog)
+	setBy := make(map[string]int)
+	for i, p := range c.plugins {
 		refreshable, ok := p.(plugins.Refreshable)
 		if !ok {
 			continue
 		}
+		if committer, ok := p.(plugins.RefreshCommitter); ok {
+			refreshed = append(refreshed, committer)
+		}
+		if receiver, ok := p.(plugins.OverrideReceiver); ok {
+			receiver.SetOverrides(c.overrides(i, setBy))
+		}
 		outcome, err := refreshable.Refresh(ctx, c.staging)
 		result.Warnings = append(result.Warnings, outcome.Warnings...)
 		if err != nil {
@@ -247,14 +352,38 @@ func (c *config) Refresh(ctx context.Context) RefreshResult {
 		}
 		for _, change := range outcome.Changes {
 			changedFields[change.FieldName] = struct{}{}
+			origins.add(change.FieldName, pluginSource(p, change.FieldName))
+			setBy[change.FieldName] = i
 		}
 	}
 
 	// Plugins report value changes only, so container expansion that adds
 	// zero-valued map entries or slice elements is detected here instead.
-	if len(changedFields) == 0 && sameConfigData(c.staging, current) {
+	if len(changedFields) == 0 && sameConfigData(c.staging, previous) {
+		return result
+	}
+	// The same comparison names the fields it found, so subscribers hear of
+	// the entries added or removed without a reported change.
+	differing, err := differingFieldNames(previous, c.staging, c.viewOptions)
+	if err != nil {
+		result.Err = fmt.Errorf("compare refreshed configuration: %w", err)
+		c.staging = nil
 		return result
 	}
+	for _, fieldName := range differing {
+		changedFields[fieldName] = struct{}{}
+	}
+	for _, p := range c.plugins {
+		validator, ok := p.(plugins.Validator)
+		if !ok {
+			continue
+		}
+		if err := validator.Validate(c.staging); err != nil {
+			result.Err = fmt.Errorf("validate refreshed configuration: %w", err)
+			c.staging = nil
+			return result
+		}
+	}
 	current, err := cloneConfigPointer(c.staging)
 	if err != nil {
 		result.Err = fmt.Errorf("publish refreshed configuration: %w", err)
@@ -268,7 +397,9 @@ func (c *config) Refresh(ctx context.Context) RefreshResult {
 	}
 	sortFieldChanges(result.Changes)
 	c.publish(current)
+	c.publishProvenance(origins, setBy, false)
 	result.Published = true
+	result.Warnings = append(result.Warnings, c.notifySubscribers(previous, current, result.Changes)...)
 	return result
 }
 
@@ -293,6 +424,10 @@ func (c *config) StartRefresh(ctx context.Context, interval time.Duration) (<-ch
 	c.refreshDone = done
 	c.refreshMu.Unlock()
 
+	// Watches are in place when StartRefresh returns, so no change made after
+	// it returns is missed.
+	notifications := c.startNotifiers(refreshCtx, results)
+
 	go func() {
 		defer close(done)
 		defer close(results)
@@ -303,21 +438,52 @@ func (c *config) StartRefresh(ctx context.Context, interval time.Duration) (<-ch
 			case <-refreshCtx.Done():
 				return
 			case <-ticker.C:
-				result := c.Refresh(refreshCtx)
-				if refreshCtx.Err() != nil {
-					return
-				}
-				if result.Err == nil && !result.Published && len(result.Warnings) == 0 {
-					continue
-				}
-				deliverRefreshResult(results, result)
+			case <-notifications:
+			}
+			result := c.Refresh(refreshCtx)
+			if refreshCtx.Err() != nil {
+				return
+			}
+			if result.Err == nil && !result.Published && len(result.Warnings) == 0 {
+				continue
 			}
+			deliverRefreshResult(results, result)
 		}
 	}()
 
 	return results, nil
 }
 
+// startNotifiers starts every plugins.Notifier and merges their notifications
+// into the returned channel, which is never closed. A notifier that fails to
+// start is reported on results and left to the ticker.
+func (c *config) startNotifiers(ctx context.Context, results chan RefreshResult) <-chan struct{} {
+	merged := make(chan struct{}, 1)
+	for _, p := range c.plugins {
+		notifier, ok := p.(plugins.Notifier)
+		if !ok {
+			continue
+		}
+		notifications, err := notifier.Notify(ctx)
+		if err != nil {
+			deliverRefreshResult(results, RefreshResult{Err: fmt.Errorf("watch %T: %w", p, err)})
+			continue
+		}
+		if notifications == nil {
+			continue
+		}
+		go func() {
+			for range notifications {
+				select {
+				case merged <- struct{}{}:
+				default:
+				}
+			}
+		}()
+	}
+	return merged
+}
+
 func (c *config) currentSnapshot() any {
 	c.dataMu.RLock()
 	defer c.dataMu.RUnlock()
@@ -330,6 +496,46 @@ func (c *config) publish(current any) {
 	c.current = current
 }
 
+// publishProvenance records the sources collected by a successful cycle. A
+// Parse replaces the previous provenance, while a refresh extends it.
+func (c *config) publishProvenance(origins provenanceLog, setBy map[string]int, reset bool) {
+	c.dataMu.Lock()
+	defer c.dataMu.Unlock()
+	if reset {
+		c.provenance = nil
+		c.setBy = nil
+	}
+	origins.commitTo(c)
+	if c.setBy == nil {
+		c.setBy = make(map[string]int, len(setBy))
+	}
+	maps.Copy(c.setBy, setBy)
+}
+
+// overrides returns the fields whose value was last set by a plugin
+// registered after the one at index, leaving out plugins.Fallback plugins.
+// cycle holds the fields set by the refresh under way.
+func (c *config) overrides(index int, cycle map[string]int) map[string]struct{} {
+	result := make(map[string]struct{})
+	add := func(fieldName string, setter int) {
+		if setter <= index {
+			return
+		}
+		if _, ok := c.plugins[setter].(plugins.Fallback); !ok {
+			result[fieldName] = struct{}{}
+		}
+	}
+	for fieldName, setter := range c.setBy {
+		if _, ok := cycle[fieldName]; !ok {
+			add(fieldName, setter)
+		}
+	}
+	for fieldName, setter := range cycle {
+		add(fieldName, setter)
+	}
+	return result
+}
+
 func deliverRefreshResult(results chan RefreshResult, result RefreshResult) {
 	result.Warnings = limitWarnings(result.Warnings)
 	select {
@@ -431,7 +637,10 @@ func (c *config) StopRefresh() {
 
 // ApplyDefaults applies `default:` struct tags to v. Non-zero fields are left
 // intact, so existing values (including those loaded from a YAML/JSON file via
-// an external unmarshaler) are preserved.
+// an external unmarshaler) are preserved. With WithComputedDefaults, the only
+// option it reads, defaults referencing other fields, such as
+// `default:"${Host}:${Port}"`, are applied last, resolved within the struct
+// being defaulted; otherwise they are set verbatim.
 //
 // v must be a non-nil pointer to one of:
 //   - a struct;
@@ -445,7 +654,7 @@ func (c *config) StopRefresh() {
 // Note: for scalar fields (including bool) Go cannot distinguish "value was
 // explicitly set to the zero value" from "value was not set". If that
 // distinction matters, use a pointer type (e.g. *bool) for the field.
-func ApplyDefaults(v any) error {
+func ApplyDefaults(v any, opts ...Option) error {
 	if v == nil {
 		return errors.New("xconfig: ApplyDefaults requires a non-nil value")
 	}
@@ -455,6 +664,11 @@ func ApplyDefaults(v any) error {
 		return errors.New("xconfig: ApplyDefaults requires a non-nil pointer")
 	}
 
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
 	elem := rv.Elem()
 	switch elem.Kind() {
 	case reflect.Struct:
@@ -462,7 +676,17 @@ func ApplyDefaults(v any) error {
 		if err != nil {
 			return err
 		}
-		return applyDefaultsToFields(fields)
+		if err := applyDefaultsToFields(fields, o.computedDefaults); err != nil {
+			return err
+		}
+		if !o.computedDefaults || !defaults.UsesReferences(v) {
+			return nil
+		}
+		computed := defaults.NewComputed(nil)
+		if err := computed.(plugins.Walker).Walk(v); err != nil {
+			return err
+		}
+		return computed.Parse()
 
 	case reflect.Slice:
 		elemType := elem.Type().Elem()
@@ -481,11 +705,11 @@ func ApplyDefaults(v any) error {
 				if item.IsNil() {
 					continue
 				}
-				if err := ApplyDefaults(item.Interface()); err != nil {
+				if err := ApplyDefaults(item.Interface(), opts...); err != nil {
 					return err
 				}
 			default:
-				if err := ApplyDefaults(item.Addr().Interface()); err != nil {
+				if err := ApplyDefaults(item.Addr().Interface(), opts...); err != nil {
 					return err
 				}
 			}
@@ -497,13 +721,13 @@ func ApplyDefaults(v any) error {
 	}
 }
 
-func applyDefaultsToFields(fields flat.Fields) error {
+func applyDefaultsToFields(fields flat.Fields, computed bool) error {
 	for _, f := range fields {
 		value, ok := f.Tag(defaultTag)
 		if !ok {
 			continue
 		}
-		if !f.IsZero() {
+		if !f.IsZero() || flat.DefaultDeferred(f) || computed && interpolate.HasReferences(value) {
 			continue
 		}
 		if err := f.Set(value); err != nil {
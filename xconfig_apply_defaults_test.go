@@ -135,3 +135,20 @@ func TestApplyDefaults_PreservesExplicitZeroFalseOnlyForPointer(t *testing.T) {
 		t.Errorf("pointer bool: explicit false must be preserved, got %v", v.PtrBool)
 	}
 }
+
+func TestApplyDefaults_ComputedDefaults(t *testing.T) {
+	type endpoint struct {
+		Host string `default:"localhost"`
+		Port int    `default:"8080"`
+		Addr string `default:"${Host}:${Port}"`
+	}
+
+	items := []endpoint{{Host: "a"}, {Addr: "explicit"}}
+	if err := xconfig.ApplyDefaults(&items, xconfig.WithComputedDefaults()); err != nil {
+		t.Fatal(err)
+	}
+	testutil.Equal(t, []endpoint{
+		{Host: "a", Port: 8080, Addr: "a:8080"},
+		{Host: "localhost", Port: 8080, Addr: "explicit"},
+	}, items)
+}